@@ -0,0 +1,701 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+const AD_PLACEMENT_SIDE = "side"
+const AD_PLACEMENT_BELOW = "below"
+
+var AD_PLACEMENTS []string = []string{AD_PLACEMENT_SIDE, AD_PLACEMENT_BELOW}
+
+/*
+	ADVERTISERS
+*/
+
+func (c *EditorController) GetAllAdvertisers(ctx *gin.Context) {
+	type Advertiser struct {
+		Id           int     `json:"id"`
+		Name         string  `json:"name"`
+		ContactName  *string `json:"contactName"`
+		ContactPhone *string `json:"contactPhone"`
+		ContactEmail *string `json:"contactEmail"`
+		Creatives    int     `json:"creatives"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT a.id, a.name, a.contact_name, a.contact_phone, a.contact_email, COUNT(ac.id)
+		FROM advertisers a
+		LEFT JOIN ad_creatives ac ON ac.advertiser_id = a.id
+		GROUP BY a.id
+		ORDER BY a.name ASC`)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	advertisers := []*Advertiser{}
+	for rows.Next() {
+		var a Advertiser
+		if err := rows.Scan(&a.Id, &a.Name, &a.ContactName, &a.ContactPhone, &a.ContactEmail, &a.Creatives); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		advertisers = append(advertisers, &a)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"advertisers": advertisers})
+}
+
+type advertiserRequest struct {
+	Name         string  `json:"name" binding:"required"`
+	ContactName  *string `json:"contactName"`
+	ContactPhone *string `json:"contactPhone"`
+	ContactEmail *string `json:"contactEmail"`
+}
+
+func (c *EditorController) CreateAdvertiser(ctx *gin.Context) {
+	var payload advertiserRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if strings.TrimSpace(payload.Name) == "" {
+		err := errors.New("missing advertiser name")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, `
+		INSERT INTO advertisers (name, contact_name, contact_phone, contact_email)
+		VALUES (?, ?, ?, ?)`,
+		strings.TrimSpace(payload.Name), payload.ContactName, payload.ContactPhone, payload.ContactEmail)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload,
+		gin.H{"message": "advertiser created successfully", "id": id})
+}
+
+func (c *EditorController) UpdateAdvertiser(ctx *gin.Context) {
+	advertiserId, err := strconv.Atoi(ctx.Param("advertiserId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid advertiser id", nil)
+		return
+	}
+	var payload advertiserRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if strings.TrimSpace(payload.Name) == "" {
+		err := errors.New("missing advertiser name")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, `
+		UPDATE advertisers
+		SET name = ?, contact_name = ?, contact_phone = ?, contact_email = ?, updated_at = ?
+		WHERE id = ?`,
+		strings.TrimSpace(payload.Name), payload.ContactName, payload.ContactPhone, payload.ContactEmail,
+		time.Now().UTC(), advertiserId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "advertiser not found", payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "advertiser updated successfully", "id": advertiserId})
+}
+
+func (c *EditorController) DeleteAdvertiser(ctx *gin.Context) {
+	advertiserId, err := strconv.Atoi(ctx.Param("advertiserId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid advertiser id", nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var creatives int
+	if err := c.db.QueryRowContext(_context,
+		"SELECT COUNT(id) FROM ad_creatives WHERE advertiser_id = ?", advertiserId).Scan(&creatives); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if creatives > 0 {
+		err := errors.New("advertiser still has creatives")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(),
+			fmt.Sprintf("delete its %d creative(s) first", creatives), http.StatusConflict, nil)
+		return
+	}
+
+	result, err := c.db.ExecContext(_context, "DELETE FROM advertisers WHERE id = ?", advertiserId)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "advertiser not found", nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "advertiser deleted successfully"})
+}
+
+/*
+	CREATIVES
+*/
+
+func (c *EditorController) GetAllAdCreatives(ctx *gin.Context) {
+	type Creative struct {
+		Id           int        `json:"id"`
+		AdvertiserId int        `json:"advertiserId"`
+		Advertiser   string     `json:"advertiser"`
+		Title        string     `json:"title"`
+		Img          string     `json:"img"`
+		LinkUrl      *string    `json:"linkUrl"`
+		UpdatedAt    *time.Time `json:"updatedAt"`
+	}
+
+	q := `SELECT ac.id, ac.advertiser_id, a.name, ac.title, ac.img, ac.link_url, ac.updated_at
+		FROM ad_creatives ac
+		JOIN advertisers a ON a.id = ac.advertiser_id`
+	args := []any{}
+	if advertiser := ctx.Query("advertiserId"); advertiser != "" {
+		advertiserId, err := strconv.Atoi(advertiser)
+		if err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, "invalid advertiser id", nil)
+			return
+		}
+		q += " WHERE ac.advertiser_id = ?"
+		args = append(args, advertiserId)
+	}
+	q += " ORDER BY ac.id DESC"
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(_context, q, args...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	creatives := []*Creative{}
+	for rows.Next() {
+		var cr Creative
+		var updatedAt []uint8
+		if err := rows.Scan(&cr.Id, &cr.AdvertiserId, &cr.Advertiser, &cr.Title,
+			&cr.Img, &cr.LinkUrl, &updatedAt); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		cr.UpdatedAt = lib.Base64ToTime(updatedAt)
+		creatives = append(creatives, &cr)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"creatives": creatives})
+}
+
+type creativeRequest struct {
+	AdvertiserId int     `json:"advertiserId" binding:"required"`
+	Title        string  `json:"title" binding:"required"`
+	LinkUrl      *string `json:"linkUrl"`
+}
+
+func validateCreative(payload creativeRequest) error {
+	if strings.TrimSpace(payload.Title) == "" {
+		return errors.New("missing creative title")
+	}
+	if payload.LinkUrl != nil && *payload.LinkUrl != "" &&
+		!strings.HasPrefix(*payload.LinkUrl, "https://") && !strings.HasPrefix(*payload.LinkUrl, "http://") {
+		return errors.New("linkUrl must start with http:// or https://")
+	}
+	return nil
+}
+
+func (c *EditorController) CreateAdCreative(ctx *gin.Context) {
+	var payload creativeRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := validateCreative(payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var exists bool
+	if err := c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM advertisers WHERE id = ?)", payload.AdvertiserId).Scan(&exists); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if !exists {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "advertiser not found", payload)
+		return
+	}
+
+	result, err := c.db.ExecContext(_context, `
+		INSERT INTO ad_creatives (advertiser_id, title, img, link_url)
+		VALUES (?, ?, ?, ?)`,
+		payload.AdvertiserId, strings.TrimSpace(payload.Title), "/static/placeholder.jpg", payload.LinkUrl)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload,
+		gin.H{"message": "creative created successfully", "id": id})
+}
+
+func (c *EditorController) UpdateAdCreative(ctx *gin.Context) {
+	creativeId, err := strconv.Atoi(ctx.Param("creativeId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid creative id", nil)
+		return
+	}
+	var payload creativeRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := validateCreative(payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, `
+		UPDATE ad_creatives
+		SET advertiser_id = ?, title = ?, link_url = ?, updated_at = ?
+		WHERE id = ?`,
+		payload.AdvertiserId, strings.TrimSpace(payload.Title), payload.LinkUrl, time.Now().UTC(), creativeId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "creative not found", payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "creative updated successfully", "id": creativeId})
+}
+
+func (c *EditorController) DeleteAdCreative(ctx *gin.Context) {
+	creativeId, err := strconv.Atoi(ctx.Param("creativeId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid creative id", nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var campaigns int
+	if err := c.db.QueryRowContext(_context,
+		"SELECT COUNT(id) FROM ad_campaigns WHERE creative_id = ?", creativeId).Scan(&campaigns); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if campaigns > 0 {
+		err := errors.New("creative is used by campaigns")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(),
+			fmt.Sprintf("delete its %d campaign(s) first", campaigns), http.StatusConflict, nil)
+		return
+	}
+
+	result, err := c.db.ExecContext(_context, "DELETE FROM ad_creatives WHERE id = ?", creativeId)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "creative not found", nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "creative deleted successfully"})
+}
+
+/*
+	CAMPAIGNS
+*/
+
+func (c *EditorController) GetAllAdCampaigns(ctx *gin.Context) {
+	type Campaign struct {
+		Id          int        `json:"id"`
+		CreativeId  int        `json:"creativeId"`
+		Creative    string     `json:"creative"`
+		Advertiser  string     `json:"advertiser"`
+		Img         string     `json:"img"`
+		Placement   string     `json:"placement"`
+		ArticleId   *int       `json:"articleId"`
+		EditionId   *int       `json:"editionId"`
+		StartDate   *time.Time `json:"startDate"`
+		EndDate     *time.Time `json:"endDate"`
+		Priority    int        `json:"priority"`
+		Impressions int        `json:"impressions"`
+		Clicks      int        `json:"clicks"`
+	}
+
+	q := `SELECT cp.id, cp.creative_id, cr.title, a.name, cr.img, cp.placement,
+			cp.article_id, cp.edition_id, cp.start_date, cp.end_date, cp.priority,
+			COALESCE(SUM(s.impressions), 0), COALESCE(SUM(s.clicks), 0)
+		FROM ad_campaigns cp
+		JOIN ad_creatives cr ON cr.id = cp.creative_id
+		JOIN advertisers a ON a.id = cr.advertiser_id
+		LEFT JOIN ad_stats s ON s.campaign_id = cp.id
+		WHERE 1 = 1`
+	args := []any{}
+	for _, filter := range []struct{ query, column string }{
+		{"articleId", "cp.article_id"},
+		{"editionId", "cp.edition_id"},
+		{"creativeId", "cp.creative_id"},
+	} {
+		v := ctx.Query(filter.query)
+		if v == "" {
+			continue
+		}
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, fmt.Sprintf("invalid %s", filter.query), nil)
+			return
+		}
+		q += fmt.Sprintf(" AND %s = ?", filter.column)
+		args = append(args, id)
+	}
+	if ctx.Query("active") == "true" {
+		q += " AND cp.start_date <= now() AND cp.end_date >= now()"
+	}
+	q += " GROUP BY cp.id ORDER BY cp.start_date DESC"
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(_context, q, args...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	campaigns := []*Campaign{}
+	for rows.Next() {
+		var cp Campaign
+		var startDate, endDate []uint8
+		if err := rows.Scan(&cp.Id, &cp.CreativeId, &cp.Creative, &cp.Advertiser, &cp.Img,
+			&cp.Placement, &cp.ArticleId, &cp.EditionId, &startDate, &endDate, &cp.Priority,
+			&cp.Impressions, &cp.Clicks); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		cp.StartDate = lib.Base64ToTime(startDate)
+		cp.EndDate = lib.Base64ToTime(endDate)
+		campaigns = append(campaigns, &cp)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"campaigns": campaigns})
+}
+
+type campaignRequest struct {
+	CreativeId int    `json:"creativeId" binding:"required"`
+	Placement  string `json:"placement" binding:"required"`
+	ArticleId  *int   `json:"articleId"`
+	EditionId  *int   `json:"editionId"`
+	StartDate  string `json:"startDate" binding:"required"`
+	EndDate    string `json:"endDate" binding:"required"`
+	Priority   int    `json:"priority"`
+}
+
+func parseCampaign(payload campaignRequest) (time.Time, time.Time, error) {
+	if !slices.Contains(AD_PLACEMENTS, payload.Placement) {
+		return time.Time{}, time.Time{}, errors.New("placement must be either side or below")
+	}
+	if payload.ArticleId != nil && payload.EditionId != nil {
+		return time.Time{}, time.Time{}, errors.New("assign a campaign to an article or an edition, not both")
+	}
+	start, err := time.Parse(time.RFC3339, payload.StartDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid startDate format (use ISO 8601)")
+	}
+	end, err := time.Parse(time.RFC3339, payload.EndDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid endDate format (use ISO 8601)")
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, errors.New("endDate must be after startDate")
+	}
+	return start.UTC(), end.UTC(), nil
+}
+
+// creativeExists checks the creative a campaign runs, answering 404 when it
+// does not exist.
+func (c *EditorController) creativeExists(ctx *gin.Context, _context context.Context, payload campaignRequest) bool {
+	var exists bool
+	err := c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM ad_creatives WHERE id = ?)", payload.CreativeId).Scan(&exists)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return false
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return false
+	}
+	if !exists {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "creative not found", payload)
+		return false
+	}
+	return true
+}
+
+func (c *EditorController) CreateAdCampaign(ctx *gin.Context) {
+	var payload campaignRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	start, end, err := parseCampaign(payload)
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if !c.creativeExists(ctx, _context, payload) {
+		return
+	}
+
+	result, err := c.db.ExecContext(_context, `
+		INSERT INTO ad_campaigns (creative_id, placement, article_id, edition_id, start_date, end_date, priority)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		payload.CreativeId, payload.Placement, payload.ArticleId, payload.EditionId, start, end, payload.Priority)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload,
+		gin.H{"message": "campaign created successfully", "id": id})
+}
+
+func (c *EditorController) UpdateAdCampaign(ctx *gin.Context) {
+	campaignId, err := strconv.Atoi(ctx.Param("campaignId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid campaign id", nil)
+		return
+	}
+	var payload campaignRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	start, end, err := parseCampaign(payload)
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if !c.creativeExists(ctx, _context, payload) {
+		return
+	}
+
+	result, err := c.db.ExecContext(_context, `
+		UPDATE ad_campaigns
+		SET creative_id = ?, placement = ?, article_id = ?, edition_id = ?,
+			start_date = ?, end_date = ?, priority = ?, updated_at = ?
+		WHERE id = ?`,
+		payload.CreativeId, payload.Placement, payload.ArticleId, payload.EditionId,
+		start, end, payload.Priority, time.Now().UTC(), campaignId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "campaign not found", payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "campaign updated successfully", "id": campaignId})
+}
+
+func (c *EditorController) DeleteAdCampaign(ctx *gin.Context) {
+	campaignId, err := strconv.Atoi(ctx.Param("campaignId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid campaign id", nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, "DELETE FROM ad_campaigns WHERE id = ?", campaignId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "campaign not found", nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "campaign deleted successfully"})
+}
+
+func (c *EditorController) GetAdCampaignStats(ctx *gin.Context) {
+	campaignId, err := strconv.Atoi(ctx.Param("campaignId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid campaign id", nil)
+		return
+	}
+
+	type DailyStat struct {
+		Day         string `json:"day"`
+		Impressions int    `json:"impressions"`
+		Clicks      int    `json:"clicks"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var exists bool
+	if err := c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM ad_campaigns WHERE id = ?)", campaignId).Scan(&exists); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if !exists {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "campaign not found", nil)
+		return
+	}
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT day, impressions, clicks FROM ad_stats
+		WHERE campaign_id = ? ORDER BY day ASC`, campaignId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	stats := []*DailyStat{}
+	var impressions, clicks int
+	for rows.Next() {
+		var s DailyStat
+		if err := rows.Scan(&s.Day, &s.Impressions, &s.Clicks); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		impressions += s.Impressions
+		clicks += s.Clicks
+		stats = append(stats, &s)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
+		"campaignId":  campaignId,
+		"impressions": impressions,
+		"clicks":      clicks,
+		"daily":       stats,
+	})
+}
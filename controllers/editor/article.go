@@ -24,12 +24,13 @@ func (c *EditorController) GetArticleById(ctx *gin.Context) {
 	}
 
 	type Article struct {
-		Title       *string    `json:"title"`
-		WriterId    *int       `json:"writerId"`
-		CoverImg    *string    `json:"coverImg"`
-		ContentJSON *string    `json:"contents"`
-		CategoryId  *int       `json:"categoryId"`
-		UpdatedAt   *time.Time `json:"updatedAt"`
+		Title        *string            `json:"title"`
+		WriterId     *int               `json:"writerId"`
+		Contributors []*lib.Contributor `json:"contributors"`
+		CoverImg     *string            `json:"coverImg"`
+		ContentJSON  *string            `json:"contents"`
+		CategoryId   *int               `json:"categoryId"`
+		UpdatedAt    *time.Time         `json:"updatedAt"`
 	}
 
 	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
@@ -61,6 +62,12 @@ func (c *EditorController) GetArticleById(ctx *gin.Context) {
 	}
 	article.UpdatedAt = lib.Base64ToTime(updatedAt)
 
+	article.Contributors, err = lib.GetArticleContributors(_context, c.db, parsedArticleId)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
 	c.res.SuccessWithStatusOKJSON(ctx, nil, article)
 }
 
@@ -71,6 +78,24 @@ func (c *EditorController) GetArticleByEdition(ctx *gin.Context) {
 		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
 		return
 	}
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 50,
+		MaxLimit:     200,
+		Sorts: map[string]string{
+			"id":        "a.id",
+			"title":     "a.title",
+			"category":  "c.label",
+			"updatedAt": "COALESCE(a.updated_at, a.created_at)",
+		},
+		DefaultSort: "id",
+		Filters:     map[string]string{"categoryId": "a.category_id", "writerId": "a.writer_id"},
+		Search:      []string{"a.title"},
+		IdColumn:    "a.id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
 
 	type article struct {
 		Id                   *string    `json:"id"`
@@ -84,6 +109,23 @@ func (c *EditorController) GetArticleByEdition(ctx *gin.Context) {
 	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
 	defer cancel()
 
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context, `
+		SELECT COUNT(a.id) FROM articles a
+		JOIN categories c ON c.id = a.category_id
+		WHERE a.edition_id = ? AND a.deleted_at IS NULL`+filter, append([]any{parsedEditionId}, filterArgs...)...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
 	articles := []*article{}
 	rows, err := c.db.QueryContext(_context,
 		`SELECT 
@@ -92,12 +134,15 @@ func (c *EditorController) GetArticleByEdition(ctx *gin.Context) {
         w.writer_name as writer,
         c.label as category, 
         a.published_date,
-        e.id as edition_id
+        e.id as edition_id,
+        `+list.SortColumn()+`
       FROM active_edition ae, articles a 
       JOIN categories c ON c.id = a.category_id 
       JOIN editions e ON e.id = a.edition_id 
       JOIN writers w ON	w.id = a.writer_id
-      WHERE a.edition_id = ?`, parsedEditionId)
+      WHERE a.edition_id = ? AND a.deleted_at IS NULL`+where+`
+      ORDER BY `+list.OrderBy()+` `+paginate,
+		append(append([]any{parsedEditionId}, args...), paginateArgs...)...)
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
 		return
@@ -111,6 +156,7 @@ func (c *EditorController) GetArticleByEdition(ctx *gin.Context) {
 	for rows.Next() {
 		var article article
 		var articlePublishedDate []uint8
+		var sortValue sql.NullString
 		if err := rows.Scan(
 			&article.Id,
 			&article.Title,
@@ -118,17 +164,42 @@ func (c *EditorController) GetArticleByEdition(ctx *gin.Context) {
 			&article.Category,
 			&articlePublishedDate,
 			&article.EditionId,
+			&sortValue,
 		); err != nil {
 			log.Println(err.Error())
+			continue
 		}
 		article.ArticlePublishedDate = lib.Base64ToTime(articlePublishedDate)
+		id, _ := strconv.Atoi(*article.Id)
+		list.Scanned(sortValue, id)
 		articles = append(articles, &article)
 	}
 
-	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"articles": articles})
+	c.res.SuccessWithMeta(ctx, nil, gin.H{"articles": articles[:list.Trim()]}, list.Meta(total))
 }
 
 func (c *EditorController) GetDrafts(ctx *gin.Context) {
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		Sorts: map[string]string{
+			"title":     "a.title",
+			"updatedAt": "COALESCE(a.updated_at, a.created_at)",
+		},
+		DefaultSort: "-updatedAt",
+		Filters: map[string]string{
+			"editionId":  "a.edition_id",
+			"categoryId": "a.category_id",
+			"writerId":   "a.writer_id",
+		},
+		Search:   []string{"a.title"},
+		IdColumn: "a.id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
 	type article struct {
 		Id                 *string    `json:"id"`
 		Title              *string    `json:"title"`
@@ -140,12 +211,29 @@ func (c *EditorController) GetDrafts(ctx *gin.Context) {
 	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
 	defer cancel()
 
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context,
+		`SELECT COUNT(a.id) FROM articles a WHERE a.published_date is null AND a.deleted_at IS NULL`+filter, filterArgs...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
 	articles := []*article{}
 	rows, err := c.db.QueryContext(_context,
-		`SELECT articles.id, title, w.writer_name, c.label as category, updated_at FROM articles
-      JOIN categories c ON c.id=articles.category_id
-      JOIN writers w ON w.id=articles.writer_id
-      WHERE published_date is null`)
+		`SELECT a.id, a.title, w.writer_name, c.label as category, a.updated_at, `+list.SortColumn()+` FROM articles a
+      JOIN categories c ON c.id=a.category_id
+      JOIN writers w ON w.id=a.writer_id
+      WHERE a.published_date is null AND a.deleted_at IS NULL`+where+`
+      ORDER BY `+list.OrderBy()+` `+paginate,
+		append(args, paginateArgs...)...)
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
 		return
@@ -159,20 +247,25 @@ func (c *EditorController) GetDrafts(ctx *gin.Context) {
 	for rows.Next() {
 		var article article
 		var articleUpdatedDate []uint8
+		var sortValue sql.NullString
 		if err := rows.Scan(
 			&article.Id,
 			&article.Title,
 			&article.Writer,
 			&article.Category,
 			&articleUpdatedDate,
+			&sortValue,
 		); err != nil {
 			log.Println(err.Error())
+			continue
 		}
 		article.ArticleUpdatedDate = lib.Base64ToTime(articleUpdatedDate)
+		id, _ := strconv.Atoi(*article.Id)
+		list.Scanned(sortValue, id)
 		articles = append(articles, &article)
 	}
 
-	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"articles": articles})
+	c.res.SuccessWithMeta(ctx, nil, gin.H{"articles": articles[:list.Trim()]}, list.Meta(total))
 }
 
 func (c *EditorController) ArchiveArticle(ctx *gin.Context) {
@@ -184,7 +277,7 @@ func (c *EditorController) ArchiveArticle(ctx *gin.Context) {
 	}
 
 	var exists bool
-	err = c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM articles WHERE id = ?)", id).Scan(&exists)
+	err = c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM articles WHERE id = ? AND deleted_at IS NULL)", id).Scan(&exists)
 	if err != nil {
 		c.res.AbortDatabaseError(ctx, err, nil)
 		return
@@ -205,39 +298,9 @@ func (c *EditorController) ArchiveArticle(ctx *gin.Context) {
 		return
 	}
 
-	res := gin.H{"message": "article archived successfully"}
-	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, res)
-}
-
-func (c *EditorController) DeleteArticlePermanent(ctx *gin.Context) {
-	articleID := ctx.Param("articleId")
-	id, err := strconv.Atoi(articleID)
-	if err != nil {
-		c.res.AbortInvalidArticle(ctx, err, err.Error(), nil)
-		return
-	}
-	var exists bool
-	err = c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM articles WHERE id = ?)", id).Scan(&exists)
-	if err != nil {
-		c.res.AbortDatabaseError(ctx, err, nil)
-		return
-	}
-	if !exists {
-		c.res.AbortArticleNotFound(ctx, err, "", nil)
-		return
-	}
-
-	_, err = c.db.Exec(`
-		DELETE FROM articles
-		WHERE id = ?`,
-		id,
-	)
-	if err != nil {
-		c.res.AbortDatabaseError(ctx, err, nil)
-		return
-	}
+	lib.InvalidateEditionToc()
 
-	res := gin.H{"message": "article deleted successfully"}
+	res := gin.H{"message": "article archived successfully"}
 	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, res)
 }
 
@@ -249,7 +312,7 @@ func (c *EditorController) PublishArticle(ctx *gin.Context) {
 		return
 	}
 	var exists bool
-	err = c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM articles WHERE id = ?)", id).Scan(&exists)
+	err = c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM articles WHERE id = ? AND deleted_at IS NULL)", id).Scan(&exists)
 	if err != nil {
 		c.res.AbortDatabaseError(ctx, err, nil)
 		return
@@ -270,6 +333,8 @@ func (c *EditorController) PublishArticle(ctx *gin.Context) {
 		return
 	}
 
+	lib.InvalidateEditionToc()
+
 	res := gin.H{"message": "article published successfully"}
 	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, res)
 }
@@ -284,15 +349,13 @@ func (c *EditorController) CreateArticle(ctx *gin.Context) {
 		return
 	}
 
-	const UNCATEGORIZED = 1
-	const UNKNOWN_WRITER = 1
 	now := time.Now().UTC()
 	imgPath := "/static/placeholder.jpg"
 	adsStr := `{"side":[],"below":""}`
 	article, err := c.db.Exec(`
 		INSERT INTO articles (edition_id, title, category_id, writer_id, created_at, updated_at, cover_img, thumb_img, ads_json)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, payload.EditionId, "Untitled Article", UNCATEGORIZED, UNKNOWN_WRITER, now, now, imgPath, imgPath, adsStr)
+	`, payload.EditionId, "Untitled Article", lib.UNCATEGORIZED, UNKNOWN_WRITER, now, now, imgPath, imgPath, adsStr)
 
 	if err != nil {
 		c.res.AbortDatabaseError(ctx, err, nil)
@@ -328,25 +391,39 @@ func (c *EditorController) SaveDraft(ctx *gin.Context) {
 		return
 	}
 
+	contents, err := lib.ParseContents(string(payload.Contents))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid contents", nil)
+		return
+	}
+	wordCount := contents.WordCount()
+	readingTime := lib.ReadingTime(wordCount)
+	excerpt := contents.Excerpt(lib.EXCERPT_LENGTH)
+
 	now := time.Now().UTC()
 
 	_, err = c.db.Exec(`
         UPDATE articles
-        SET content_json = ?, thumb_text = ?, updated_at = ?
+        SET content_json = ?, thumb_text = ?, updated_at = ?, word_count = ?, reading_time = ?, excerpt = ?
         WHERE id = ?
-    `, string(payload.Contents), payload.ThumbText, now, articleId)
+    `, string(payload.Contents), payload.ThumbText, now, wordCount, readingTime, excerpt, articleId)
 	if err != nil {
 		c.res.AbortDatabaseError(ctx, err, nil)
 		return
 	}
+	// the table of contents shows the thumb text and reading time
+	lib.InvalidateEditionToc()
 
 	c.res.SuccessWithStatusOKJSON(
 		ctx,
 		gin.H{"content": "content JSON (hidden)"},
 		gin.H{
-			"message":   "draft saved successfully",
-			"id":        articleId,
-			"updatedAt": now,
+			"message":     "draft saved successfully",
+			"id":          articleId,
+			"updatedAt":   now,
+			"wordCount":   wordCount,
+			"readingTime": readingTime,
+			"excerpt":     excerpt,
 		})
 }
 
@@ -383,7 +460,17 @@ func (c *EditorController) SaveTWC(ctx *gin.Context) {
 	slug := formatTitleToSlug(payload.Title)
 	now := time.Now().UTC()
 
-	_, err = c.db.Exec(`
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(_context, `
 		UPDATE articles
 		SET updated_at = ?, title = ?, slug = ?, category_id = ?, writer_id = ?
 		WHERE id = ?`,
@@ -394,11 +481,25 @@ func (c *EditorController) SaveTWC(ctx *gin.Context) {
 		payload.Writer,
 		parsedArticleId,
 	)
-
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
 	if err != nil {
 		c.res.AbortDatabaseError(ctx, err, nil)
 		return
 	}
+	// a new primary author may have been credited as an author already
+	if err := lib.DropPrimaryAuthorCredits(_context, tx, payload.Writer); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	lib.InvalidateEditionToc()
 
 	res := gin.H{"message": "attributes saved successfully", "article_id": parsedArticleId}
 	c.res.SuccessWithStatusOKJSON(ctx, payload, res)
@@ -413,11 +514,12 @@ func (c *EditorController) GetArticleInfo(ctx *gin.Context) {
 	}
 
 	type Article struct {
-		Id         *int    `json:"id"`
-		Title      *string `json:"title"`
-		WriterId   *int    `json:"writerId"`
-		CategoryId *int    `json:"categoryId"`
-		EditionId  *int    `json:"editionId"`
+		Id           *int               `json:"id"`
+		Title        *string            `json:"title"`
+		WriterId     *int               `json:"writerId"`
+		Contributors []*lib.Contributor `json:"contributors"`
+		CategoryId   *int               `json:"categoryId"`
+		EditionId    *int               `json:"editionId"`
 	}
 
 	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
@@ -447,6 +549,12 @@ func (c *EditorController) GetArticleInfo(ctx *gin.Context) {
 		return
 	}
 
+	article.Contributors, err = lib.GetArticleContributors(_context, c.db, parsedArticleId)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
 	c.res.SuccessWithStatusOKJSON(ctx, nil, article)
 }
 
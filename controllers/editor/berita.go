@@ -3,6 +3,7 @@ package editor
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,13 +20,49 @@ import (
 )
 
 func (c *EditorController) GetAllBerita(ctx *gin.Context) {
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		Sorts: map[string]string{
+			"publishStart": "publish_start",
+			"createdAt":    "created_at",
+			"title":        "title",
+			"priority":     "priority",
+		},
+		DefaultSort: "-publishStart",
+		Filters:     map[string]string{"section": "section", "sectionId": "section_id"},
+		Search:      []string{"title"},
+		IdColumn:    "id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
 	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
 	defer cancel()
 
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context,
+		`SELECT COUNT(id) FROM announcements WHERE deleted_at is null`+filter, filterArgs...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
 	rows, err := c.db.QueryContext(_context, `
-	SELECT id, title, section, thumb_img, descriptions,
-	details, created_at, publish_start, publish_end FROM announcements
-		WHERE deleted_at is null ORDER BY publish_start DESC`)
+	SELECT id, title, section, section_id, thumb_img, descriptions,
+	details, created_at, publish_start, publish_end, event_start, event_end, location, rrule,
+	priority, pinned_at, pinned_until, updated_at, updated_by, `+list.SortColumn()+` FROM announcements
+		WHERE deleted_at is null`+where+` ORDER BY `+list.OrderBy()+` `+paginate,
+		append(args, paginateArgs...)...)
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
 		return
@@ -40,28 +77,47 @@ func (c *EditorController) GetAllBerita(ctx *gin.Context) {
 		Id           int        `json:"id"`
 		Title        string     `json:"title"`
 		Section      string     `json:"section"`
+		SectionId    *int       `json:"sectionId"`
 		ThumbImg     string     `json:"thumbImg"`
 		Desc         string     `json:"descriptions"`
 		Details      string     `json:"details"`
 		CreatedAt    *time.Time `json:"createdAt"`
 		PublishStart *time.Time `json:"publishStart"`
 		PublishEnd   *time.Time `json:"publishEnd"`
+		Priority     int        `json:"priority"`
+		PinnedAt     *time.Time `json:"pinnedAt"`
+		PinnedUntil  *time.Time `json:"pinnedUntil"`
+		UpdatedAt    *time.Time `json:"updatedAt"`
+		UpdatedBy    *int       `json:"updatedBy"`
+		beritaEvent
 	}
 
 	news := []*Berita{}
 	for rows.Next() {
 		var result Berita
-		var createdAt, publishStart, publishEnd []uint8
+		var createdAt, publishStart, publishEnd, eventStart, eventEnd, pinnedAt, pinnedUntil, updatedAt []uint8
+		var sortValue sql.NullString
 		if err := rows.Scan(
 			&result.Id,
 			&result.Title,
 			&result.Section,
+			&result.SectionId,
 			&result.ThumbImg,
 			&result.Desc,
 			&result.Details,
 			&createdAt,
 			&publishStart,
 			&publishEnd,
+			&eventStart,
+			&eventEnd,
+			&result.Location,
+			&result.RRule,
+			&result.Priority,
+			&pinnedAt,
+			&pinnedUntil,
+			&updatedAt,
+			&result.UpdatedBy,
+			&sortValue,
 		); err != nil {
 			log.Println(err)
 		}
@@ -69,10 +125,16 @@ func (c *EditorController) GetAllBerita(ctx *gin.Context) {
 		result.CreatedAt = lib.Base64ToTime(createdAt)
 		result.PublishStart = lib.Base64ToTime(publishStart)
 		result.PublishEnd = lib.Base64ToTime(publishEnd)
+		result.EventStart = lib.Base64ToTime(eventStart)
+		result.EventEnd = lib.Base64ToTime(eventEnd)
+		result.PinnedAt = lib.Base64ToTime(pinnedAt)
+		result.PinnedUntil = lib.Base64ToTime(pinnedUntil)
+		result.UpdatedAt = lib.Base64ToTime(updatedAt)
+		list.Scanned(sortValue, result.Id)
 		news = append(news, &result)
 	}
 
-	c.res.SuccessWithStatusOKJSON(ctx, nil, news)
+	c.res.SuccessWithMeta(ctx, nil, news[:list.Trim()], list.Meta(total))
 }
 
 func (c *EditorController) CreateBerita(ctx *gin.Context) {
@@ -81,13 +143,16 @@ func (c *EditorController) CreateBerita(ctx *gin.Context) {
 
 	type RequestModel struct {
 		Title        string `json:"title" binding:"required"`
-		Section      string `json:"section" binding:"required"`
+		Section      string `json:"section"`
+		SectionId    int    `json:"sectionId"`
 		Desc         string `json:"descriptions" binding:"required"`
 		Details      string `json:"details" binding:"required"`
 		PublishStart string `json:"publishStart" binding:"required"`
 		PublishEnd   string `json:"publishEnd" binding:"required"`
 		FileName     string `json:"thumbImg" binding:"required"`
 		ContentType  string `json:"contentType" binding:"required"`
+		// optional, for announcements about an event
+		Event *beritaEventBody `json:"event"`
 	}
 
 	var payload RequestModel
@@ -106,20 +171,48 @@ func (c *EditorController) CreateBerita(ctx *gin.Context) {
 		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
 		return
 	}
+	var event beritaEvent
+	if payload.Event != nil {
+		if event, err = payload.Event.parse(); err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+			return
+		}
+	}
+	sectionId, section, err := findBeritaSection(_context, c.db, payload.SectionId, payload.Section)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == errUnknownSection {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
 
 	imgPath := "/static/placeholder.jpg"
 	result, err := c.db.ExecContext(_context, `
 	INSERT INTO announcements
-	(title, section, thumb_img, descriptions, details, publish_start, publish_end)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+	(title, section, section_id, thumb_img, descriptions, details, publish_start, publish_end,
+		event_start, event_end, location, rrule, updated_at, updated_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		payload.Title,
-		payload.Section,
+		section,
+		sectionId,
 		imgPath,
 		payload.Desc,
 		payload.Details,
 		pubStart,
 		pubEnd,
+		event.EventStart,
+		event.EventEnd,
+		event.Location,
+		event.RRule,
+		time.Now().UTC(),
+		lib.AdminId(ctx),
 	)
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
@@ -225,8 +318,8 @@ func (c *EditorController) UpdateBeritaThumbnail(ctx *gin.Context) {
 
 	if _, err := c.db.ExecContext(_context, `
 		UPDATE announcements
-		SET thumb_img = ?
-		WHERE id = ?`, comp.Url, parsedBeritaId); err != nil {
+		SET thumb_img = ?, updated_at = ?, updated_by = ?
+		WHERE id = ?`, comp.Url, time.Now().UTC(), lib.AdminId(ctx), parsedBeritaId); err != nil {
 		c.res.AbortDatabaseError(ctx, err, payload)
 		return
 	}
@@ -272,9 +365,9 @@ func (c *EditorController) UpdateBeritaPublishing(ctx *gin.Context) {
 		return
 	}
 
-	query := `UPDATE announcements SET publish_start = ?, publish_end = ? WHERE id = ?`
+	query := `UPDATE announcements SET publish_start = ?, publish_end = ?, updated_at = ?, updated_by = ? WHERE id = ?`
 
-	result, err := c.db.ExecContext(_context, query, pubStart, pubEnd, beritaId)
+	result, err := c.db.ExecContext(_context, query, pubStart, pubEnd, time.Now().UTC(), lib.AdminId(ctx), beritaId)
 
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
@@ -301,35 +394,3 @@ func (c *EditorController) UpdateBeritaPublishing(ctx *gin.Context) {
 
 	c.res.SuccessWithStatusJSON(ctx, http.StatusOK, nil, responsePayload)
 }
-
-func (c *EditorController) DeleteBeritaPermanent(ctx *gin.Context) {
-	beritaid := ctx.Param("id")
-	id, err := strconv.Atoi(beritaid)
-	if err != nil {
-		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
-		return
-	}
-	var exists bool
-	err = c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM announcements WHERE id = ?)", id).Scan(&exists)
-	if err != nil {
-		c.res.AbortDatabaseError(ctx, err, nil)
-		return
-	}
-	if !exists {
-		c.res.AbortArticleNotFound(ctx, err, "", nil)
-		return
-	}
-
-	_, err = c.db.Exec(`
-		DELETE FROM announcements
-		WHERE id = ?`,
-		id,
-	)
-	if err != nil {
-		c.res.AbortDatabaseError(ctx, err, nil)
-		return
-	}
-
-	res := gin.H{"message": "berita deleted successfully"}
-	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, res)
-}
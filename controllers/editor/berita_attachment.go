@@ -0,0 +1,522 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+type beritaAttachmentResponseModel struct {
+	Id          int        `json:"id"`
+	FileName    string     `json:"fileName"`
+	Url         string     `json:"url"`
+	ContentType string     `json:"contentType"`
+	Kind        string     `json:"kind"`
+	Size        *int64     `json:"size"`
+	Caption     *string    `json:"caption"`
+	SortOrder   int        `json:"sortOrder"`
+	Uploaded    bool       `json:"uploaded"`
+	CreatedAt   *time.Time `json:"createdAt"`
+	UploadedAt  *time.Time `json:"uploadedAt"`
+}
+
+// beritaAttachmentParams reads the :id and :attachmentId path parameters.
+func (c *EditorController) beritaAttachmentParams(ctx *gin.Context) (int, int, bool) {
+	beritaId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return 0, 0, false
+	}
+	attachmentId, err := strconv.Atoi(ctx.Param("attachmentId"))
+	if err != nil {
+		c.res.AbortInvalidAttachment(ctx, err, err.Error(), nil)
+		return 0, 0, false
+	}
+	return beritaId, attachmentId, true
+}
+
+func trimCaption(caption *string) (*string, error) {
+	if caption == nil {
+		return nil, nil
+	}
+	trimmed := strings.TrimSpace(*caption)
+	if trimmed == "" {
+		return nil, nil
+	}
+	if len([]rune(trimmed)) > lib.ATTACHMENT_MAX_CAPTION {
+		return nil, fmt.Errorf("caption must be at most %d characters", lib.ATTACHMENT_MAX_CAPTION)
+	}
+	return &trimmed, nil
+}
+
+// GetBeritaAttachments lists every attachment of a berita, including the ones
+// whose upload has not been confirmed yet.
+func (c *EditorController) GetBeritaAttachments(ctx *gin.Context) {
+	beritaId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var exists bool
+	err = c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM announcements WHERE id = ?)", beritaId).Scan(&exists)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if !exists {
+		err := errors.New("berita not found")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusNotFound, nil)
+		return
+	}
+
+	rows, err := c.db.QueryContext(_context, `
+	SELECT id, object_path, file_name, content_type, size_bytes, caption, sort_order, created_at, uploaded_at
+	FROM announcement_attachments
+		WHERE announcement_id = ?
+		ORDER BY sort_order ASC, id ASC`, beritaId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	attachments := []*beritaAttachmentResponseModel{}
+	for rows.Next() {
+		var result beritaAttachmentResponseModel
+		var objectPath string
+		var createdAt, uploadedAt []uint8
+		if err := rows.Scan(
+			&result.Id,
+			&objectPath,
+			&result.FileName,
+			&result.ContentType,
+			&result.Size,
+			&result.Caption,
+			&result.SortOrder,
+			&createdAt,
+			&uploadedAt,
+		); err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+		result.Url = lib.ObjectLocation(objectPath)
+		result.Kind = lib.AttachmentKind(result.ContentType)
+		result.CreatedAt = lib.Base64ToTime(createdAt)
+		result.UploadedAt = lib.Base64ToTime(uploadedAt)
+		result.Uploaded = result.UploadedAt != nil
+		attachments = append(attachments, &result)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, attachments)
+}
+
+// CreateBeritaAttachment registers an attachment and returns a signed URL to
+// PUT the file to. The attachment stays hidden from the site until the upload
+// is confirmed with ConfirmBeritaAttachment.
+func (c *EditorController) CreateBeritaAttachment(ctx *gin.Context) {
+	beritaId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		FileName    string  `json:"fileName" binding:"required"`
+		ContentType string  `json:"contentType" binding:"required"`
+		Size        int64   `json:"size" binding:"required"`
+		Caption     *string `json:"caption"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	payload.FileName = strings.TrimSpace(payload.FileName)
+	contentType := strings.ToLower(strings.TrimSpace(payload.ContentType))
+	if err := lib.ValidateAttachment(payload.FileName, contentType, payload.Size); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+	caption, err := trimCaption(payload.Caption)
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	// locking the berita keeps concurrent uploads from sharing a position
+	var id int
+	err = tx.QueryRowContext(_context,
+		"SELECT id FROM announcements WHERE id = ? AND deleted_at is null FOR UPDATE", beritaId).Scan(&id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortWithStatusJSON(ctx, err, "berita not found", err.Error(), http.StatusNotFound, payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	var count, nextOrder int
+	if err := tx.QueryRowContext(_context, `
+	SELECT COUNT(id), COALESCE(MAX(sort_order) + 1, 0) FROM announcement_attachments
+		WHERE announcement_id = ?`, beritaId).Scan(&count, &nextOrder); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if count >= lib.BERITA_MAX_ATTACHMENTS {
+		err := fmt.Errorf("a berita can have at most %d attachments", lib.BERITA_MAX_ATTACHMENTS)
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusConflict, payload)
+		return
+	}
+
+	result, err := tx.ExecContext(_context, `
+	INSERT INTO announcement_attachments
+	(announcement_id, object_path, file_name, content_type, caption, sort_order, created_at, created_by)
+		VALUES (?, '', ?, ?, ?, ?, ?, ?)`,
+		beritaId, payload.FileName, contentType, caption, nextOrder, time.Now().UTC(), lib.AdminId(ctx))
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	attachmentId, err := result.LastInsertId()
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	// the attachment id keeps two files with the same name apart
+	obj := fmt.Sprintf("berita/%d/attachments/%d/%s", beritaId, attachmentId, payload.FileName)
+	if _, err := tx.ExecContext(_context,
+		"UPDATE announcement_attachments SET object_path = ? WHERE id = ?", obj, attachmentId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	signedUrl, err := services.GetSignedURL(_context, obj, contentType)
+	if err != nil {
+		c.res.AbortStorageError(ctx, err, payload)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload, gin.H{
+		"message":  "attachment created successfully",
+		"id":       attachmentId,
+		"url":      signedUrl,
+		"location": obj,
+		"maxBytes": lib.AttachmentMaxBytes(contentType),
+	})
+}
+
+// ConfirmBeritaAttachment checks the uploaded file against the declared type
+// and the size limit, then publishes the attachment. Files that fail the
+// check are removed together with their attachment.
+func (c *EditorController) ConfirmBeritaAttachment(ctx *gin.Context) {
+	beritaId, attachmentId, ok := c.beritaAttachmentParams(ctx)
+	if !ok {
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var objectPath, contentType string
+	var uploadedAt []uint8
+	err := c.db.QueryRowContext(_context, `
+	SELECT object_path, content_type, uploaded_at FROM announcement_attachments
+		WHERE id = ? AND announcement_id = ?`, attachmentId, beritaId).Scan(&objectPath, &contentType, &uploadedAt)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortAttachmentNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if uploadedAt != nil {
+		c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
+			"message":    "attachment already uploaded",
+			"id":         attachmentId,
+			"uploadedAt": lib.Base64ToTime(uploadedAt),
+		})
+		return
+	}
+
+	attrs, err := services.GetObjectAttrs(_context, objectPath)
+	if err == lib.ErrNoObject {
+		c.res.AbortWithStatusJSON(ctx, err, "file has not been uploaded yet", err.Error(), http.StatusConflict, nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortStorageError(ctx, err, nil)
+		return
+	}
+
+	var invalid error
+	if !strings.EqualFold(attrs.ContentType, contentType) {
+		invalid = fmt.Errorf("uploaded file is %s, expected %s", attrs.ContentType, contentType)
+	} else if attrs.Size > lib.AttachmentMaxBytes(contentType) {
+		invalid = fmt.Errorf("uploaded file is too large, %s files may be at most %d MB",
+			lib.AttachmentKind(contentType), lib.AttachmentMaxBytes(contentType)>>20)
+	}
+	if invalid != nil {
+		if _, err := c.db.ExecContext(_context,
+			"DELETE FROM announcement_attachments WHERE id = ?", attachmentId); err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+		if _, err := services.DeleteObjects(_context, []string{objectPath}, nil); err != nil {
+			log.Println("confirm attachment: storage cleanup failed:", err.Error())
+		}
+		c.res.AbortInvalidRequestBody(ctx, invalid, invalid.Error()+", the attachment was removed", nil)
+		return
+	}
+
+	now := time.Now().UTC()
+	if _, err := c.db.ExecContext(_context, `
+	UPDATE announcement_attachments SET size_bytes = ?, uploaded_at = ?
+		WHERE id = ?`, attrs.Size, now, attachmentId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
+		"message":    "attachment uploaded successfully",
+		"id":         attachmentId,
+		"size":       attrs.Size,
+		"uploadedAt": now,
+	})
+}
+
+// UpdateBeritaAttachment changes the caption of an attachment, an empty or
+// null caption removes it.
+func (c *EditorController) UpdateBeritaAttachment(ctx *gin.Context) {
+	beritaId, attachmentId, ok := c.beritaAttachmentParams(ctx)
+	if !ok {
+		return
+	}
+
+	type reqBody struct {
+		Caption *string `json:"caption"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	caption, err := trimCaption(payload.Caption)
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var exists bool
+	err = c.db.QueryRowContext(_context, `
+	SELECT EXISTS(SELECT 1 FROM announcement_attachments WHERE id = ? AND announcement_id = ?)`,
+		attachmentId, beritaId).Scan(&exists)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if !exists {
+		c.res.AbortAttachmentNotFound(ctx, lib.ErrAttachmentNotFound, "", payload)
+		return
+	}
+
+	if _, err := c.db.ExecContext(_context,
+		"UPDATE announcement_attachments SET caption = ? WHERE id = ?", caption, attachmentId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message": "attachment updated successfully",
+		"id":      attachmentId,
+		"caption": caption,
+	})
+}
+
+// ReorderBeritaAttachments takes every attachment id of the berita in the
+// order they are to be shown.
+func (c *EditorController) ReorderBeritaAttachments(ctx *gin.Context) {
+	beritaId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		AttachmentIds []int `json:"attachmentIds"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(_context,
+		"SELECT id FROM announcement_attachments WHERE announcement_id = ? FOR UPDATE", beritaId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	attachments := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		attachments[id] = true
+	}
+	rows.Close()
+
+	seen := map[int]bool{}
+	for _, id := range payload.AttachmentIds {
+		if !attachments[id] {
+			err := fmt.Errorf("attachment %d does not belong to berita %d", id, beritaId)
+			c.res.AbortInvalidAttachment(ctx, err, err.Error(), payload)
+			return
+		}
+		if seen[id] {
+			err := fmt.Errorf("attachment %d is listed twice", id)
+			c.res.AbortInvalidAttachment(ctx, err, err.Error(), payload)
+			return
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(attachments) {
+		err := errors.New("incomplete attachment list")
+		c.res.AbortInvalidRequestBody(ctx, err,
+			fmt.Sprintf("expected all %d attachments, got %d", len(attachments), len(seen)), payload)
+		return
+	}
+
+	for order, id := range payload.AttachmentIds {
+		if _, err := tx.ExecContext(_context,
+			"UPDATE announcement_attachments SET sort_order = ? WHERE id = ?", order, id); err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "attachment order updated"})
+}
+
+// DeleteBeritaAttachment removes an attachment and its file.
+func (c *EditorController) DeleteBeritaAttachment(ctx *gin.Context) {
+	beritaId, attachmentId, ok := c.beritaAttachmentParams(ctx)
+	if !ok {
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var objectPath string
+	err := c.db.QueryRowContext(_context, `
+	SELECT object_path FROM announcement_attachments
+		WHERE id = ? AND announcement_id = ?`, attachmentId, beritaId).Scan(&objectPath)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortAttachmentNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	if _, err := c.db.ExecContext(_context,
+		"DELETE FROM announcement_attachments WHERE id = ?", attachmentId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	deleted, err := services.DeleteObjects(_context, []string{objectPath}, nil)
+	res := gin.H{
+		"message":        "attachment deleted successfully",
+		"id":             attachmentId,
+		"objectsDeleted": deleted,
+		"storageCleanup": "ok",
+	}
+	if err != nil {
+		log.Println("delete attachment: storage cleanup failed:", err.Error())
+		res["storageCleanup"] = err.Error()
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, res)
+}
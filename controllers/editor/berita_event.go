@@ -0,0 +1,96 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+)
+
+// beritaEvent is the event an announcement is about, if any. It is held at
+// EventStart and repeats following RRule when set.
+type beritaEvent struct {
+	EventStart *time.Time `json:"eventStart"`
+	EventEnd   *time.Time `json:"eventEnd"`
+	Location   *string    `json:"location"`
+	RRule      *string    `json:"rrule"`
+}
+
+func (e *beritaEvent) validate() error {
+	if e.EventStart == nil {
+		if e.EventEnd != nil || e.Location != nil || e.RRule != nil {
+			return errors.New("missing event start")
+		}
+		return nil
+	}
+	if e.EventEnd != nil && !e.EventEnd.After(*e.EventStart) {
+		return errors.New("event end must be after event start")
+	}
+	if e.Location != nil && utf8.RuneCountInString(*e.Location) > 255 {
+		return errors.New("location must be at most 255 characters")
+	}
+	if e.RRule != nil {
+		if _, err := lib.ParseRRule(*e.RRule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *beritaEvent) equal(o *beritaEvent) bool {
+	sameTime := func(x, y *time.Time) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && x.Equal(*y))
+	}
+	sameString := func(x, y *string) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && *x == *y)
+	}
+	return sameTime(e.EventStart, o.EventStart) && sameTime(e.EventEnd, o.EventEnd) &&
+		sameString(e.Location, o.Location) && sameString(e.RRule, o.RRule)
+}
+
+// beritaEventBody is the event as sent by the editor, e.g.
+// {"start": "2026-01-06T19:00:00+07:00", "end": "...", "location": "Aula",
+// "rrule": "FREQ=WEEKLY;BYDAY=TU"}.
+type beritaEventBody struct {
+	Start    string  `json:"start"`
+	End      *string `json:"end"`
+	Location string  `json:"location"`
+	RRule    string  `json:"rrule"`
+}
+
+func (b *beritaEventBody) parse() (beritaEvent, error) {
+	var event beritaEvent
+	start, err := time.Parse(time.RFC3339, b.Start)
+	if err != nil {
+		return event, errors.New("invalid event start format (use ISO 8601)")
+	}
+	start = start.UTC()
+	event.EventStart = &start
+	if b.End != nil && *b.End != "" {
+		end, err := time.Parse(time.RFC3339, *b.End)
+		if err != nil {
+			return event, errors.New("invalid event end format (use ISO 8601)")
+		}
+		end = end.UTC()
+		event.EventEnd = &end
+	}
+	if location := strings.TrimSpace(b.Location); location != "" {
+		event.Location = &location
+	}
+	if strings.TrimSpace(b.RRule) != "" {
+		rule, err := lib.ParseRRule(b.RRule)
+		if err != nil {
+			return event, err
+		}
+		if rule.Until != nil && rule.Until.Before(start) {
+			return event, fmt.Errorf("%w: UNTIL is before the event start", lib.ErrInvalidRRule)
+		}
+		// stored in canonical form so feeds can hand it out as is
+		canonical := rule.String()
+		event.RRule = &canonical
+	}
+	return event, event.validate()
+}
@@ -0,0 +1,166 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+const BERITA_MAX_PRIORITY = 100
+
+// PinBerita puts an announcement at the top of the homepage overview, until
+// the optional "until" time or until it is unpinned.
+func (c *EditorController) PinBerita(ctx *gin.Context) {
+	beritaId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		Until *string `json:"until"`
+	}
+	var payload reqBody
+	// the body is optional
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+			return
+		}
+	}
+	now := time.Now().UTC()
+	var until *time.Time
+	if payload.Until != nil && *payload.Until != "" {
+		t, err := time.Parse(time.RFC3339, *payload.Until)
+		if err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, "invalid until format (use ISO 8601)", payload)
+			return
+		}
+		if !t.After(now) {
+			err := errors.New("until must be in the future")
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+			return
+		}
+		t = t.UTC()
+		until = &t
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, `
+		UPDATE announcements SET pinned_at = ?, pinned_until = ?, pinned_by = ?
+		WHERE id = ? AND deleted_at is null`,
+		now, until, lib.AdminId(ctx), beritaId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		err := errors.New("berita not found")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusNotFound, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message":     "berita pinned successfully",
+		"id":          beritaId,
+		"pinnedUntil": until,
+	})
+}
+
+func (c *EditorController) UnpinBerita(ctx *gin.Context) {
+	beritaId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, `
+		UPDATE announcements SET pinned_at = NULL, pinned_until = NULL, pinned_by = NULL
+		WHERE id = ? AND deleted_at is null AND pinned_at IS NOT NULL`, beritaId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		err := errors.New("berita not found")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "berita does not exist or is not pinned", http.StatusNotFound, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "berita unpinned successfully", "id": beritaId})
+}
+
+// SetBeritaPriority ranks an announcement within its section on the homepage
+// overview, higher first. The default is 0.
+func (c *EditorController) SetBeritaPriority(ctx *gin.Context) {
+	beritaId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		Priority *int `json:"priority" binding:"required"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if *payload.Priority < -BERITA_MAX_PRIORITY || *payload.Priority > BERITA_MAX_PRIORITY {
+		err := fmt.Errorf("priority must be between %d and %d", -BERITA_MAX_PRIORITY, BERITA_MAX_PRIORITY)
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var exists bool
+	err = c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM announcements WHERE id = ? AND deleted_at is null)", beritaId).Scan(&exists)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if !exists {
+		err := errors.New("berita not found")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusNotFound, payload)
+		return
+	}
+
+	if _, err := c.db.ExecContext(_context,
+		"UPDATE announcements SET priority = ? WHERE id = ?", *payload.Priority, beritaId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message":  "berita priority updated",
+		"id":       beritaId,
+		"priority": *payload.Priority,
+	})
+}
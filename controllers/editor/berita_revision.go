@@ -0,0 +1,464 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// beritaContent is the editable part of an announcement, and what a revision keeps.
+type beritaContent struct {
+	Title        string     `json:"title"`
+	Section      string     `json:"section"`
+	SectionId    *int       `json:"sectionId"`
+	Desc         string     `json:"descriptions"`
+	Details      string     `json:"details"`
+	PublishStart *time.Time `json:"publishStart"`
+	PublishEnd   *time.Time `json:"publishEnd"`
+	beritaEvent
+}
+
+func (b *beritaContent) validate() error {
+	b.Title = strings.TrimSpace(b.Title)
+	b.Section = strings.TrimSpace(b.Section)
+	b.Desc = strings.TrimSpace(b.Desc)
+	switch {
+	case b.Title == "":
+		return errors.New("missing title")
+	case utf8.RuneCountInString(b.Title) > 255:
+		return errors.New("title must be at most 255 characters")
+	case b.Section == "" && b.SectionId == nil:
+		return errors.New("missing section")
+	case utf8.RuneCountInString(b.Section) > 64:
+		return errors.New("section must be at most 64 characters")
+	case b.Desc == "":
+		return errors.New("missing descriptions")
+	case strings.TrimSpace(b.Details) == "":
+		return errors.New("missing details")
+	case b.PublishStart == nil || b.PublishEnd == nil:
+		return errors.New("missing publishing period")
+	case !b.PublishEnd.After(*b.PublishStart):
+		return errors.New("publishEnd must be after publishStart")
+	}
+	return b.beritaEvent.validate()
+}
+
+func (b *beritaContent) equal(o *beritaContent) bool {
+	sameTime := func(x, y *time.Time) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && x.Equal(*y))
+	}
+	sameId := func(x, y *int) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && *x == *y)
+	}
+	return b.Title == o.Title && b.Section == o.Section && sameId(b.SectionId, o.SectionId) &&
+		b.Desc == o.Desc && b.Details == o.Details &&
+		sameTime(b.PublishStart, o.PublishStart) && sameTime(b.PublishEnd, o.PublishEnd) &&
+		b.beritaEvent.equal(&o.beritaEvent)
+}
+
+// saveBeritaContent replaces the content of an announcement, first storing
+// the current version as the next revision. It reports the revision number,
+// or 0 when nothing changed, and errUnknownSection for sections that do not
+// exist (anymore).
+func (c *EditorController) saveBeritaContent(_context context.Context, tx *sql.Tx, beritaId int, content *beritaContent, adminId *int) (int, error) {
+	requestedSection := 0
+	if content.SectionId != nil {
+		requestedSection = *content.SectionId
+	}
+	sectionId, label, err := findBeritaSection(_context, tx, requestedSection, content.Section)
+	if err != nil {
+		return 0, err
+	}
+	content.SectionId, content.Section = &sectionId, label
+
+	var current beritaContent
+	var publishStart, publishEnd, eventStart, eventEnd, editedAt []uint8
+	var editedBy *int
+	err = tx.QueryRowContext(_context, `
+		SELECT title, section, section_id, descriptions, details, publish_start, publish_end,
+			event_start, event_end, location, rrule,
+			COALESCE(updated_at, created_at), updated_by
+		FROM announcements
+		WHERE id = ? AND deleted_at is null
+		FOR UPDATE`, beritaId).Scan(
+		&current.Title,
+		&current.Section,
+		&current.SectionId,
+		&current.Desc,
+		&current.Details,
+		&publishStart,
+		&publishEnd,
+		&eventStart,
+		&eventEnd,
+		&current.Location,
+		&current.RRule,
+		&editedAt,
+		&editedBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+	current.PublishStart = lib.Base64ToTime(publishStart)
+	current.PublishEnd = lib.Base64ToTime(publishEnd)
+	current.EventStart = lib.Base64ToTime(eventStart)
+	current.EventEnd = lib.Base64ToTime(eventEnd)
+	if current.equal(content) {
+		return 0, nil
+	}
+
+	var revision int
+	if err := tx.QueryRowContext(_context,
+		"SELECT COALESCE(MAX(revision), 0) + 1 FROM announcement_revisions WHERE announcement_id = ?",
+		beritaId).Scan(&revision); err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(_context, `
+		INSERT INTO announcement_revisions
+		(announcement_id, revision, title, section, section_id, descriptions, details, publish_start, publish_end,
+			event_start, event_end, location, rrule, edited_at, edited_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		beritaId, revision, current.Title, current.Section, current.SectionId, current.Desc, current.Details,
+		current.PublishStart, current.PublishEnd, current.EventStart, current.EventEnd, current.Location, current.RRule,
+		lib.Base64ToTime(editedAt), editedBy, now); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(_context, `
+		UPDATE announcements
+		SET title = ?, section = ?, section_id = ?, descriptions = ?, details = ?, publish_start = ?, publish_end = ?,
+			event_start = ?, event_end = ?, location = ?, rrule = ?, updated_at = ?, updated_by = ?
+		WHERE id = ?`,
+		content.Title, content.Section, content.SectionId, content.Desc, content.Details, content.PublishStart, content.PublishEnd,
+		content.EventStart, content.EventEnd, content.Location, content.RRule, now, adminId, beritaId); err != nil {
+		return 0, err
+	}
+	return revision, nil
+}
+
+// UpdateBerita edits the content of an announcement. The publishing period
+// and the event are optional and kept as is when left out, "event": null
+// removes the event.
+func (c *EditorController) UpdateBerita(ctx *gin.Context) {
+	beritaId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		Title        string          `json:"title"`
+		Section      string          `json:"section"`
+		SectionId    *int            `json:"sectionId"`
+		Desc         string          `json:"descriptions"`
+		Details      string          `json:"details"`
+		PublishStart *string         `json:"publishStart"`
+		PublishEnd   *string         `json:"publishEnd"`
+		Event        json.RawMessage `json:"event"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	content := beritaContent{
+		Title:     payload.Title,
+		Section:   payload.Section,
+		SectionId: payload.SectionId,
+		Desc:      payload.Desc,
+		Details:   payload.Details,
+	}
+	for _, field := range []struct {
+		name  string
+		value *string
+		dest  **time.Time
+	}{
+		{"publishStart", payload.PublishStart, &content.PublishStart},
+		{"publishEnd", payload.PublishEnd, &content.PublishEnd},
+	} {
+		if field.value == nil {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, *field.value)
+		if err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, fmt.Sprintf("invalid %s format (use ISO 8601)", field.name), payload)
+			return
+		}
+		t = t.UTC()
+		*field.dest = &t
+	}
+	keepEvent := len(payload.Event) == 0
+	if !keepEvent && string(payload.Event) != "null" {
+		var event beritaEventBody
+		if err := json.Unmarshal(payload.Event, &event); err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+			return
+		}
+		if content.beritaEvent, err = event.parse(); err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+			return
+		}
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	if content.PublishStart == nil || content.PublishEnd == nil || keepEvent {
+		var current beritaContent
+		var publishStart, publishEnd, eventStart, eventEnd []uint8
+		err := tx.QueryRowContext(_context, `
+			SELECT publish_start, publish_end, event_start, event_end, location, rrule
+			FROM announcements WHERE id = ? AND deleted_at is null`,
+			beritaId).Scan(&publishStart, &publishEnd, &eventStart, &eventEnd, &current.Location, &current.RRule)
+		if _context.Err() == context.DeadlineExceeded {
+			c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+			return
+		}
+		if err == sql.ErrNoRows {
+			c.res.AbortWithStatusJSON(ctx, err, "berita not found", err.Error(), http.StatusNotFound, payload)
+			return
+		}
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		if content.PublishStart == nil {
+			content.PublishStart = lib.Base64ToTime(publishStart)
+		}
+		if content.PublishEnd == nil {
+			content.PublishEnd = lib.Base64ToTime(publishEnd)
+		}
+		if keepEvent {
+			current.EventStart = lib.Base64ToTime(eventStart)
+			current.EventEnd = lib.Base64ToTime(eventEnd)
+			content.beritaEvent = current.beritaEvent
+		}
+	}
+	if err := content.validate(); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	revision, err := c.saveBeritaContent(_context, tx, beritaId, &content, lib.AdminId(ctx))
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err == errUnknownSection {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortWithStatusJSON(ctx, err, "berita not found", err.Error(), http.StatusNotFound, payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	if revision == 0 {
+		c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "berita unchanged", "id": beritaId})
+		return
+	}
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message":  "berita updated successfully",
+		"id":       beritaId,
+		"revision": revision,
+	})
+}
+
+// GetBeritaRevisions lists the earlier versions of an announcement, newest first.
+func (c *EditorController) GetBeritaRevisions(ctx *gin.Context) {
+	beritaId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type revisionResponseModel struct {
+		Revision int `json:"revision"`
+		beritaContent
+		EditedAt  *time.Time `json:"editedAt"`
+		EditedBy  *int       `json:"editedBy"`
+		CreatedAt *time.Time `json:"createdAt"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var exists bool
+	err = c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM announcements WHERE id = ?)", beritaId).Scan(&exists)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if !exists {
+		c.res.AbortWithStatusJSON(ctx, sql.ErrNoRows, "berita not found", "", http.StatusNotFound, nil)
+		return
+	}
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT revision, title, section, section_id, descriptions, details, publish_start, publish_end,
+			event_start, event_end, location, rrule, edited_at, edited_by, created_at
+		FROM announcement_revisions
+		WHERE announcement_id = ?
+		ORDER BY revision DESC`, beritaId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	revisions := []*revisionResponseModel{}
+	for rows.Next() {
+		var revision revisionResponseModel
+		var publishStart, publishEnd, eventStart, eventEnd, editedAt, createdAt []uint8
+		if err := rows.Scan(
+			&revision.Revision,
+			&revision.Title,
+			&revision.Section,
+			&revision.SectionId,
+			&revision.Desc,
+			&revision.Details,
+			&publishStart,
+			&publishEnd,
+			&eventStart,
+			&eventEnd,
+			&revision.Location,
+			&revision.RRule,
+			&editedAt,
+			&revision.EditedBy,
+			&createdAt,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		revision.PublishStart = lib.Base64ToTime(publishStart)
+		revision.PublishEnd = lib.Base64ToTime(publishEnd)
+		revision.EventStart = lib.Base64ToTime(eventStart)
+		revision.EventEnd = lib.Base64ToTime(eventEnd)
+		revision.EditedAt = lib.Base64ToTime(editedAt)
+		revision.CreatedAt = lib.Base64ToTime(createdAt)
+		revisions = append(revisions, &revision)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, revisions)
+}
+
+// RestoreBeritaRevision brings back an earlier version. The version it
+// replaces becomes a revision itself, so restoring can be undone.
+func (c *EditorController) RestoreBeritaRevision(ctx *gin.Context) {
+	beritaId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+	revisionNumber, err := strconv.Atoi(ctx.Param("revision"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid revision", nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer tx.Rollback()
+
+	var content beritaContent
+	var publishStart, publishEnd, eventStart, eventEnd []uint8
+	err = tx.QueryRowContext(_context, `
+		SELECT title, section, section_id, descriptions, details, publish_start, publish_end,
+			event_start, event_end, location, rrule
+		FROM announcement_revisions
+		WHERE announcement_id = ? AND revision = ?`, beritaId, revisionNumber).Scan(
+		&content.Title,
+		&content.Section,
+		&content.SectionId,
+		&content.Desc,
+		&content.Details,
+		&publishStart,
+		&publishEnd,
+		&eventStart,
+		&eventEnd,
+		&content.Location,
+		&content.RRule,
+	)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortWithStatusJSON(ctx, err, "revision not found", err.Error(), http.StatusNotFound, nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	content.PublishStart = lib.Base64ToTime(publishStart)
+	content.PublishEnd = lib.Base64ToTime(publishEnd)
+	content.EventStart = lib.Base64ToTime(eventStart)
+	content.EventEnd = lib.Base64ToTime(eventEnd)
+
+	revision, err := c.saveBeritaContent(_context, tx, beritaId, &content, lib.AdminId(ctx))
+	if err == sql.ErrNoRows {
+		c.res.AbortWithStatusJSON(ctx, err, "berita not found", err.Error(), http.StatusNotFound, nil)
+		return
+	}
+	if err == errUnknownSection {
+		err := fmt.Errorf("the section %q of this revision no longer exists", content.Section)
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusConflict, nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
+		"message":  fmt.Sprintf("revision %d restored successfully", revisionNumber),
+		"id":       beritaId,
+		"revision": revision,
+	})
+}
@@ -0,0 +1,459 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+const SECTION_MAX_OVERVIEW_LIMIT = 20
+
+var errUnknownSection = errors.New("unknown section, create it first")
+
+var sectionSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+var sectionColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// sectionSlug matches the slugs migration 010 gave the existing sections.
+func sectionSlug(label string) string {
+	return strings.Trim(sectionSlugPattern.ReplaceAllString(strings.ToLower(strings.TrimSpace(label)), "-"), "-")
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// findBeritaSection resolves the section a berita is filed under, by id when
+// given and otherwise by slug or label. It returns errUnknownSection when
+// there is no such section.
+func findBeritaSection(_context context.Context, q queryRower, sectionId int, section string) (int, string, error) {
+	var id int
+	var label string
+	err := sql.ErrNoRows
+	if sectionId != 0 {
+		err = q.QueryRowContext(_context,
+			"SELECT id, label FROM announcement_sections WHERE id = ?", sectionId).Scan(&id, &label)
+	}
+	if err == sql.ErrNoRows && strings.TrimSpace(section) != "" {
+		err = q.QueryRowContext(_context,
+			"SELECT id, label FROM announcement_sections WHERE slug = ? OR label = ? ORDER BY slug = ? DESC LIMIT 1",
+			sectionSlug(section), strings.TrimSpace(section), sectionSlug(section)).Scan(&id, &label)
+	}
+	if err == sql.ErrNoRows {
+		return 0, "", errUnknownSection
+	}
+	return id, label, err
+}
+
+type beritaSectionBody struct {
+	Label string  `json:"label"`
+	Slug  string  `json:"slug"`
+	Color *string `json:"color"`
+	// how many berita the homepage overview shows, null for the default
+	// and 0 to leave the section out
+	OverviewLimit *int `json:"overviewLimit"`
+}
+
+func (b *beritaSectionBody) validate() error {
+	b.Label = strings.TrimSpace(b.Label)
+	b.Slug = sectionSlug(b.Slug)
+	if b.Color != nil {
+		color := strings.ToLower(strings.TrimSpace(*b.Color))
+		b.Color = &color
+		if color == "" {
+			b.Color = nil
+		}
+	}
+	switch {
+	case b.Label == "":
+		return errors.New("missing label")
+	case utf8.RuneCountInString(b.Label) > 64:
+		return errors.New("label must be at most 64 characters")
+	case len(b.Slug) > 64:
+		return errors.New("slug must be at most 64 characters")
+	case b.Color != nil && !sectionColorPattern.MatchString(*b.Color):
+		return errors.New("color must look like #1a2b3c")
+	case b.OverviewLimit != nil && (*b.OverviewLimit < 0 || *b.OverviewLimit > SECTION_MAX_OVERVIEW_LIMIT):
+		return fmt.Errorf("overviewLimit must be between 0 and %d", SECTION_MAX_OVERVIEW_LIMIT)
+	}
+	return nil
+}
+
+// slugTaken reports whether another section already uses slug.
+func slugTaken(_context context.Context, q queryRower, slug string, sectionId int) (bool, error) {
+	var taken bool
+	err := q.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM announcement_sections WHERE slug = ? AND id <> ?)", slug, sectionId).Scan(&taken)
+	return taken, err
+}
+
+func (c *EditorController) GetBeritaSections(ctx *gin.Context) {
+	type sectionResponseModel struct {
+		Id            int     `json:"id"`
+		Label         string  `json:"label"`
+		Slug          string  `json:"slug"`
+		Color         *string `json:"color"`
+		Order         int     `json:"order"`
+		OverviewLimit *int    `json:"overviewLimit"`
+		Berita        int     `json:"berita"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT s.id, s.label, s.slug, s.color, s.sort_order, s.overview_limit, COUNT(a.id)
+		FROM announcement_sections s
+		LEFT JOIN announcements a ON a.section_id = s.id AND a.deleted_at is null
+		GROUP BY s.id, s.label, s.slug, s.color, s.sort_order, s.overview_limit
+		ORDER BY s.sort_order ASC, s.label ASC`)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	sections := []*sectionResponseModel{}
+	for rows.Next() {
+		var section sectionResponseModel
+		if err := rows.Scan(
+			&section.Id,
+			&section.Label,
+			&section.Slug,
+			&section.Color,
+			&section.Order,
+			&section.OverviewLimit,
+			&section.Berita,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		sections = append(sections, &section)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, sections)
+}
+
+// CreateBeritaSection adds a section at the end of the list. The slug is
+// derived from the label unless given.
+func (c *EditorController) CreateBeritaSection(ctx *gin.Context) {
+	var payload beritaSectionBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := payload.validate(); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+	if payload.Slug == "" {
+		payload.Slug = sectionSlug(payload.Label)
+	}
+	if payload.Slug == "" {
+		err := errors.New("missing slug")
+		c.res.AbortInvalidRequestBody(ctx, err, "the label has no letters or digits, give a slug", payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	taken, err := slugTaken(_context, c.db, payload.Slug, 0)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if taken {
+		err := fmt.Errorf("slug %q is already used", payload.Slug)
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusConflict, payload)
+		return
+	}
+
+	result, err := c.db.ExecContext(_context, `
+		INSERT INTO announcement_sections (label, slug, color, overview_limit, sort_order, created_at)
+		SELECT ?, ?, ?, ?, COALESCE(MAX(sort_order) + 1, 0), ? FROM announcement_sections`,
+		payload.Label, payload.Slug, payload.Color, payload.OverviewLimit, time.Now().UTC())
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload, gin.H{
+		"message": "section created successfully",
+		"id":      id,
+		"slug":    payload.Slug,
+	})
+}
+
+// UpdateBeritaSection renames or recolors a section. The slug is kept when
+// left out so links to the section keep working.
+func (c *EditorController) UpdateBeritaSection(ctx *gin.Context) {
+	sectionId, err := strconv.Atoi(ctx.Param("sectionId"))
+	if err != nil {
+		c.res.AbortInvalidSection(ctx, err, err.Error(), nil)
+		return
+	}
+	var payload beritaSectionBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := payload.validate(); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	var slug string
+	err = tx.QueryRowContext(_context,
+		"SELECT slug FROM announcement_sections WHERE id = ? FOR UPDATE", sectionId).Scan(&slug)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortSectionNotFound(ctx, err, err.Error(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if payload.Slug != "" && payload.Slug != slug {
+		taken, err := slugTaken(_context, tx, payload.Slug, sectionId)
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		if taken {
+			err := fmt.Errorf("slug %q is already used", payload.Slug)
+			c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusConflict, payload)
+			return
+		}
+		slug = payload.Slug
+	}
+
+	if _, err := tx.ExecContext(_context,
+		"UPDATE announcement_sections SET label = ?, slug = ?, color = ?, overview_limit = ?, updated_at = ? WHERE id = ?",
+		payload.Label, slug, payload.Color, payload.OverviewLimit, time.Now().UTC(), sectionId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	// announcements keep a copy of the label
+	result, err := tx.ExecContext(_context,
+		"UPDATE announcements SET section = ? WHERE section_id = ?", payload.Label, sectionId)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	updated, _ := result.RowsAffected()
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message":       "section updated successfully",
+		"id":            sectionId,
+		"slug":          slug,
+		"updatedBerita": updated,
+	})
+}
+
+// ReorderBeritaSections takes every section id in the new order.
+func (c *EditorController) ReorderBeritaSections(ctx *gin.Context) {
+	type reqBody struct {
+		SectionIds []int `json:"sectionIds"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(_context, "SELECT id FROM announcement_sections FOR UPDATE")
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	sections := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		sections[id] = true
+	}
+	rows.Close()
+
+	seen := map[int]bool{}
+	for _, id := range payload.SectionIds {
+		if !sections[id] {
+			err := fmt.Errorf("section %d does not exist", id)
+			c.res.AbortInvalidSection(ctx, err, err.Error(), payload)
+			return
+		}
+		if seen[id] {
+			err := fmt.Errorf("section %d is listed twice", id)
+			c.res.AbortInvalidSection(ctx, err, err.Error(), payload)
+			return
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(sections) {
+		err := errors.New("incomplete section list")
+		c.res.AbortInvalidRequestBody(ctx, err,
+			fmt.Sprintf("expected all %d sections, got %d", len(sections), len(seen)), payload)
+		return
+	}
+
+	for order, id := range payload.SectionIds {
+		if _, err := tx.ExecContext(_context,
+			"UPDATE announcement_sections SET sort_order = ? WHERE id = ?", order, id); err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "section order updated"})
+}
+
+// DeleteBeritaSection removes a section. Sections still holding berita,
+// trashed ones included, need ?moveTo= naming the section they move to.
+func (c *EditorController) DeleteBeritaSection(ctx *gin.Context) {
+	sectionId, err := strconv.Atoi(ctx.Param("sectionId"))
+	if err != nil {
+		c.res.AbortInvalidSection(ctx, err, err.Error(), nil)
+		return
+	}
+	moveTo := 0
+	if v := ctx.Query("moveTo"); v != "" {
+		if moveTo, err = strconv.Atoi(v); err != nil || moveTo == sectionId {
+			c.res.AbortInvalidSection(ctx, lib.ErrInvalidSection, "invalid moveTo section", nil)
+			return
+		}
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer tx.Rollback()
+
+	var used int
+	err = tx.QueryRowContext(_context, `
+		SELECT COUNT(a.id) FROM announcement_sections s
+		LEFT JOIN announcements a ON a.section_id = s.id
+		WHERE s.id = ?
+		GROUP BY s.id`, sectionId).Scan(&used)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortSectionNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	var moved int64
+	if used > 0 {
+		if moveTo == 0 {
+			err := fmt.Errorf("section still holds %d berita", used)
+			c.res.AbortWithStatusJSON(ctx, err, err.Error(),
+				"repeat with ?moveTo=<sectionId> to move them first", http.StatusConflict, nil)
+			return
+		}
+		var label string
+		err := tx.QueryRowContext(_context,
+			"SELECT label FROM announcement_sections WHERE id = ?", moveTo).Scan(&label)
+		if err == sql.ErrNoRows {
+			c.res.AbortSectionNotFound(ctx, err, "moveTo section does not exist", nil)
+			return
+		}
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+		result, err := tx.ExecContext(_context,
+			"UPDATE announcements SET section_id = ?, section = ? WHERE section_id = ?", moveTo, label, sectionId)
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+		moved, _ = result.RowsAffected()
+	}
+	if _, err := tx.ExecContext(_context, "DELETE FROM announcement_sections WHERE id = ?", sectionId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{
+		"message":     "section deleted successfully",
+		"id":          sectionId,
+		"movedBerita": moved,
+	})
+}
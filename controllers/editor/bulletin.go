@@ -0,0 +1,926 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// snapshotBerita copies the berita matching cond into bulletin items, in the
+// order of the homepage overview. Recurring events are pinned down to their
+// occurrence in the bulletin week, or the next one after it.
+func snapshotBerita(_context context.Context, q queryer, weekStart time.Time, cond string, args ...any) ([]*lib.BulletinItem, error) {
+	rows, err := q.QueryContext(_context, `
+	SELECT a.id, a.section, a.title, a.descriptions, a.event_start, a.event_end, a.location, a.rrule
+	FROM announcements a
+	LEFT JOIN announcement_sections s ON s.id = a.section_id
+		WHERE a.deleted_at is null AND `+cond+`
+		ORDER BY s.sort_order IS NULL, s.sort_order ASC,
+			(a.pinned_at IS NOT NULL AND (a.pinned_until IS NULL OR a.pinned_until > now())) DESC,
+			a.priority DESC, a.publish_start DESC, a.id ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	items := []*lib.BulletinItem{}
+	for rows.Next() {
+		var item lib.BulletinItem
+		var beritaId int
+		var eventStart, eventEnd []uint8
+		var rrule sql.NullString
+		if err := rows.Scan(
+			&beritaId,
+			&item.Section,
+			&item.Title,
+			&item.Descriptions,
+			&eventStart,
+			&eventEnd,
+			&item.Location,
+			&rrule,
+		); err != nil {
+			return nil, err
+		}
+		item.BeritaId = &beritaId
+		item.EventStart = lib.Base64ToTime(eventStart)
+		item.EventEnd = lib.Base64ToTime(eventEnd)
+		if item.EventStart != nil && rrule.Valid {
+			occurrences, err := lib.EventOccurrences(*item.EventStart, item.EventEnd, rrule.String, weekStart, weekEnd, 1)
+			if err != nil {
+				log.Println(err.Error())
+			}
+			if len(occurrences) == 0 {
+				next, _ := lib.NextOccurrence(*item.EventStart, item.EventEnd, rrule.String, weekStart)
+				if next != nil {
+					occurrences = append(occurrences, next)
+				}
+			}
+			if len(occurrences) > 0 {
+				item.EventStart = &occurrences[0].Start
+				item.EventEnd = occurrences[0].End
+			}
+		}
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+func insertBulletinItems(_context context.Context, tx *sql.Tx, bulletinId int, items []*lib.BulletinItem, firstOrder int) ([]int64, error) {
+	ids := []int64{}
+	for i, item := range items {
+		result, err := tx.ExecContext(_context, `
+		INSERT INTO bulletin_items
+		(bulletin_id, announcement_id, section, title, descriptions, event_start, event_end, location, note, sort_order)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			bulletinId, item.BeritaId, item.Section, item.Title, item.Descriptions,
+			item.EventStart, item.EventEnd, item.Location, item.Note, firstOrder+i)
+		if err != nil {
+			return nil, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func trimOptional(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// lockBulletin locks a bulletin for the rest of tx and aborts with 404 when
+// it does not exist.
+func (c *EditorController) lockBulletin(ctx *gin.Context, _context context.Context, tx *sql.Tx, bulletinId int, reqData any) bool {
+	var id int
+	err := tx.QueryRowContext(_context, "SELECT id FROM bulletins WHERE id = ? FOR UPDATE", bulletinId).Scan(&id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), reqData)
+		return false
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortBulletinNotFound(ctx, err, err.Error(), reqData)
+		return false
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, reqData)
+		return false
+	}
+	return true
+}
+
+func touchBulletin(ctx *gin.Context, _context context.Context, tx *sql.Tx, bulletinId int) error {
+	_, err := tx.ExecContext(_context,
+		"UPDATE bulletins SET updated_at = ?, updated_by = ? WHERE id = ?",
+		time.Now().UTC(), lib.AdminId(ctx), bulletinId)
+	return err
+}
+
+func (c *EditorController) GetBulletins(ctx *gin.Context) {
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		Sorts:        map[string]string{"weekStart": "week_start", "number": "COALESCE(number, 0)"},
+		DefaultSort:  "-weekStart",
+		Search:       []string{"title"},
+		IdColumn:     "id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type bulletin struct {
+		Id          int        `json:"id"`
+		Number      *int       `json:"number"`
+		Title       string     `json:"title"`
+		WeekStart   string     `json:"weekStart"`
+		WeekEnd     string     `json:"weekEnd"`
+		Items       int        `json:"items"`
+		Published   bool       `json:"published"`
+		PublishedAt *time.Time `json:"publishedAt"`
+		UpdatedAt   *time.Time `json:"updatedAt"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context,
+		"SELECT COUNT(id) FROM bulletins WHERE 1 = 1"+filter, filterArgs...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
+	rows, err := c.db.QueryContext(_context, `
+		SELECT id, number, title, week_start, week_end,
+			(SELECT COUNT(i.id) FROM bulletin_items i WHERE i.bulletin_id = bulletins.id),
+			published_at, COALESCE(updated_at, created_at), `+list.SortColumn()+`
+		FROM bulletins
+		WHERE 1 = 1`+where+`
+		ORDER BY `+list.OrderBy()+` `+paginate,
+		append(args, paginateArgs...)...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	bulletins := []*bulletin{}
+	for rows.Next() {
+		var result bulletin
+		var publishedAt, updatedAt []uint8
+		var sortValue sql.NullString
+		if err := rows.Scan(
+			&result.Id,
+			&result.Number,
+			&result.Title,
+			&result.WeekStart,
+			&result.WeekEnd,
+			&result.Items,
+			&publishedAt,
+			&updatedAt,
+			&sortValue,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		result.PublishedAt = lib.Base64ToTime(publishedAt)
+		result.Published = result.PublishedAt != nil
+		result.UpdatedAt = lib.Base64ToTime(updatedAt)
+		list.Scanned(sortValue, result.Id)
+		bulletins = append(bulletins, &result)
+	}
+
+	c.res.SuccessWithMeta(ctx, nil, bulletins[:list.Trim()], list.Meta(total))
+}
+
+// CreateBulletin drafts the bulletin of the week containing weekStart
+// (YYYY-MM-DD, any day of the week) from the berita published during it.
+func (c *EditorController) CreateBulletin(ctx *gin.Context) {
+	type reqBody struct {
+		WeekStart string  `json:"weekStart" binding:"required"`
+		Title     *string `json:"title"`
+		Intro     *string `json:"intro"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	day, err := time.ParseInLocation(time.DateOnly, payload.WeekStart, lib.ParishLocation())
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid weekStart date (use YYYY-MM-DD)", payload)
+		return
+	}
+	weekStart, weekEnd := lib.BulletinWeek(day)
+	title := lib.BULLETIN_NAME
+	if t := trimOptional(payload.Title); t != nil {
+		title = *t
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	var existing int
+	err = tx.QueryRowContext(_context,
+		"SELECT id FROM bulletins WHERE week_start = ?", weekStart.Format(time.DateOnly)).Scan(&existing)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err == nil {
+		err := fmt.Errorf("the week of %s already has bulletin %d", weekStart.Format(time.DateOnly), existing)
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusConflict, payload)
+		return
+	}
+	if err != sql.ErrNoRows {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	// active during the week: published before it ends and not expired
+	// before it starts
+	from := weekStart.UTC()
+	to := weekStart.AddDate(0, 0, 7).UTC()
+	items, err := snapshotBerita(_context, tx, weekStart,
+		"a.publish_start < ? AND a.publish_end >= ?", to, from)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	now := time.Now().UTC()
+	result, err := tx.ExecContext(_context, `
+	INSERT INTO bulletins (title, week_start, week_end, intro, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		title, weekStart.Format(time.DateOnly), weekEnd.Format(time.DateOnly),
+		trimOptional(payload.Intro), now, lib.AdminId(ctx))
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	bulletinId, err := result.LastInsertId()
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if _, err := insertBulletinItems(_context, tx, int(bulletinId), items, 0); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload, gin.H{
+		"message":   "bulletin created successfully",
+		"id":        bulletinId,
+		"weekStart": weekStart.Format(time.DateOnly),
+		"weekEnd":   weekEnd.Format(time.DateOnly),
+		"items":     len(items),
+	})
+}
+
+func (c *EditorController) GetBulletinById(ctx *gin.Context) {
+	bulletinId, err := strconv.Atoi(ctx.Param("bulletinId"))
+	if err != nil {
+		c.res.AbortInvalidBulletin(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	bulletin, err := lib.GetBulletin(_context, c.db, bulletinId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortBulletinNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, bulletin)
+}
+
+// GetBulletinHTML and GetBulletinPDF preview a bulletin, drafts included.
+func (c *EditorController) GetBulletinHTML(ctx *gin.Context) {
+	c.writeBulletin(ctx, "html")
+}
+
+func (c *EditorController) GetBulletinPDF(ctx *gin.Context) {
+	c.writeBulletin(ctx, "pdf")
+}
+
+func (c *EditorController) writeBulletin(ctx *gin.Context, format string) {
+	bulletinId, err := strconv.Atoi(ctx.Param("bulletinId"))
+	if err != nil {
+		c.res.AbortInvalidBulletin(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	bulletin, err := lib.GetBulletin(_context, c.db, bulletinId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortBulletinNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	c.res.WriteBulletin(ctx, bulletin, format)
+}
+
+// UpdateBulletin changes the title and the opening note of a bulletin, an
+// empty intro removes it.
+func (c *EditorController) UpdateBulletin(ctx *gin.Context) {
+	bulletinId, err := strconv.Atoi(ctx.Param("bulletinId"))
+	if err != nil {
+		c.res.AbortInvalidBulletin(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		Title string  `json:"title" binding:"required"`
+		Intro *string `json:"intro"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	title := strings.TrimSpace(payload.Title)
+	if title == "" {
+		err := errors.New("empty title")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, `
+	UPDATE bulletins SET title = ?, intro = ?, updated_at = ?, updated_by = ?
+		WHERE id = ?`,
+		title, trimOptional(payload.Intro), time.Now().UTC(), lib.AdminId(ctx), bulletinId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortBulletinNotFound(ctx, lib.ErrBulletinNotFound, "", payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "bulletin updated successfully", "id": bulletinId})
+}
+
+// PublishBulletin adds a bulletin to the public archive. It gets the next
+// number the first time it is published and keeps it afterwards.
+func (c *EditorController) PublishBulletin(ctx *gin.Context) {
+	bulletinId, err := strconv.Atoi(ctx.Param("bulletinId"))
+	if err != nil {
+		c.res.AbortInvalidBulletin(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer tx.Rollback()
+
+	var number sql.NullInt64
+	var publishedAt []uint8
+	err = tx.QueryRowContext(_context,
+		"SELECT number, published_at FROM bulletins WHERE id = ? FOR UPDATE", bulletinId).Scan(&number, &publishedAt)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortBulletinNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if publishedAt != nil {
+		err := errors.New("bulletin is already published")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusConflict, nil)
+		return
+	}
+
+	if !number.Valid {
+		// the lock on the last number keeps two publications from taking the
+		// same one
+		if err := tx.QueryRowContext(_context,
+			"SELECT COALESCE(MAX(number), 0) + 1 FROM bulletins FOR UPDATE").Scan(&number.Int64); err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+		number.Valid = true
+	}
+
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(_context, `
+	UPDATE bulletins SET number = ?, published_at = ?, published_by = ?
+		WHERE id = ?`, number.Int64, now, lib.AdminId(ctx), bulletinId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
+		"message":     "bulletin published successfully",
+		"id":          bulletinId,
+		"number":      number.Int64,
+		"publishedAt": now,
+	})
+}
+
+// UnpublishBulletin takes a bulletin out of the archive, it keeps its number.
+func (c *EditorController) UnpublishBulletin(ctx *gin.Context) {
+	bulletinId, err := strconv.Atoi(ctx.Param("bulletinId"))
+	if err != nil {
+		c.res.AbortInvalidBulletin(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, `
+	UPDATE bulletins SET published_at = NULL, published_by = NULL
+		WHERE id = ? AND published_at IS NOT NULL`, bulletinId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortBulletinNotFound(ctx, lib.ErrBulletinNotFound, "bulletin does not exist or is not published", nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "bulletin unpublished successfully", "id": bulletinId})
+}
+
+// DeleteBulletin discards a draft. Bulletins that were ever published have a
+// number and stay, unpublish them instead.
+func (c *EditorController) DeleteBulletin(ctx *gin.Context) {
+	bulletinId, err := strconv.Atoi(ctx.Param("bulletinId"))
+	if err != nil {
+		c.res.AbortInvalidBulletin(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var number sql.NullInt64
+	err = c.db.QueryRowContext(_context, "SELECT number FROM bulletins WHERE id = ?", bulletinId).Scan(&number)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortBulletinNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if number.Valid {
+		err := fmt.Errorf("bulletin %d has been published as No. %d", bulletinId, number.Int64)
+		c.res.AbortWithStatusJSON(ctx, err, "only drafts can be deleted", err.Error(), http.StatusConflict, nil)
+		return
+	}
+
+	if _, err := c.db.ExecContext(_context,
+		"DELETE FROM bulletins WHERE id = ? AND number IS NULL", bulletinId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "bulletin deleted successfully", "id": bulletinId})
+}
+
+// AddBulletinItem appends an item to a bulletin: a copy of a berita when
+// beritaId is given, otherwise a free item written by the editor.
+func (c *EditorController) AddBulletinItem(ctx *gin.Context) {
+	bulletinId, err := strconv.Atoi(ctx.Param("bulletinId"))
+	if err != nil {
+		c.res.AbortInvalidBulletin(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		BeritaId     *int    `json:"beritaId"`
+		Section      *string `json:"section"`
+		Title        *string `json:"title"`
+		Descriptions *string `json:"descriptions"`
+		Note         *string `json:"note"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if payload.BeritaId == nil && trimOptional(payload.Title) == nil {
+		err := errors.New("either beritaId or title is required")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	var weekStart string
+	err = tx.QueryRowContext(_context,
+		"SELECT week_start FROM bulletins WHERE id = ? FOR UPDATE", bulletinId).Scan(&weekStart)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortBulletinNotFound(ctx, err, err.Error(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	var item *lib.BulletinItem
+	if payload.BeritaId != nil {
+		week, err := time.ParseInLocation(time.DateOnly, weekStart, lib.ParishLocation())
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		items, err := snapshotBerita(_context, tx, week, "a.id = ?", *payload.BeritaId)
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		if len(items) == 0 {
+			err := errors.New("berita not found")
+			c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusNotFound, payload)
+			return
+		}
+		item = items[0]
+	} else {
+		item = &lib.BulletinItem{Title: *trimOptional(payload.Title)}
+	}
+	if section := trimOptional(payload.Section); section != nil {
+		item.Section = *section
+	}
+	if descriptions := trimOptional(payload.Descriptions); descriptions != nil {
+		item.Descriptions = *descriptions
+	}
+	item.Note = trimOptional(payload.Note)
+
+	var nextOrder int
+	if err := tx.QueryRowContext(_context,
+		"SELECT COALESCE(MAX(sort_order) + 1, 0) FROM bulletin_items WHERE bulletin_id = ?", bulletinId).Scan(&nextOrder); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	ids, err := insertBulletinItems(_context, tx, bulletinId, []*lib.BulletinItem{item}, nextOrder)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if err := touchBulletin(ctx, _context, tx, bulletinId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload, gin.H{"message": "bulletin item added successfully", "id": ids[0]})
+}
+
+// UpdateBulletinItem annotates an item or edits its copy of the berita. Only
+// the fields present are changed, an empty note removes it.
+func (c *EditorController) UpdateBulletinItem(ctx *gin.Context) {
+	bulletinId, err := strconv.Atoi(ctx.Param("bulletinId"))
+	if err != nil {
+		c.res.AbortInvalidBulletin(ctx, err, err.Error(), nil)
+		return
+	}
+	itemId, err := strconv.Atoi(ctx.Param("itemId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid item id", nil)
+		return
+	}
+
+	type reqBody struct {
+		Section      *string `json:"section"`
+		Title        *string `json:"title"`
+		Descriptions *string `json:"descriptions"`
+		Note         *string `json:"note"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	sets := []string{}
+	args := []any{}
+	if payload.Section != nil {
+		sets = append(sets, "section = ?")
+		args = append(args, strings.TrimSpace(*payload.Section))
+	}
+	if payload.Title != nil {
+		title := trimOptional(payload.Title)
+		if title == nil {
+			err := errors.New("empty title")
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+			return
+		}
+		sets = append(sets, "title = ?")
+		args = append(args, *title)
+	}
+	if payload.Descriptions != nil {
+		sets = append(sets, "descriptions = ?")
+		args = append(args, strings.TrimSpace(*payload.Descriptions))
+	}
+	if payload.Note != nil {
+		sets = append(sets, "note = ?")
+		args = append(args, trimOptional(payload.Note))
+	}
+	if len(sets) == 0 {
+		err := errors.New("nothing to update")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	if !c.lockBulletin(ctx, _context, tx, bulletinId, payload) {
+		return
+	}
+	result, err := tx.ExecContext(_context,
+		"UPDATE bulletin_items SET "+strings.Join(sets, ", ")+" WHERE id = ? AND bulletin_id = ?",
+		append(args, itemId, bulletinId)...)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var exists bool
+		if err := tx.QueryRowContext(_context,
+			"SELECT EXISTS(SELECT 1 FROM bulletin_items WHERE id = ? AND bulletin_id = ?)", itemId, bulletinId).Scan(&exists); err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		// MySQL reports 0 rows for updates that change nothing
+		if !exists {
+			err := errors.New("bulletin item not found")
+			c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusNotFound, payload)
+			return
+		}
+	}
+	if err := touchBulletin(ctx, _context, tx, bulletinId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "bulletin item updated successfully", "id": itemId})
+}
+
+// ReorderBulletinItems takes every item id of the bulletin in print order.
+func (c *EditorController) ReorderBulletinItems(ctx *gin.Context) {
+	bulletinId, err := strconv.Atoi(ctx.Param("bulletinId"))
+	if err != nil {
+		c.res.AbortInvalidBulletin(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		ItemIds []int `json:"itemIds"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	if !c.lockBulletin(ctx, _context, tx, bulletinId, payload) {
+		return
+	}
+	rows, err := tx.QueryContext(_context,
+		"SELECT id FROM bulletin_items WHERE bulletin_id = ? FOR UPDATE", bulletinId)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	items := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		items[id] = true
+	}
+	rows.Close()
+
+	seen := map[int]bool{}
+	for _, id := range payload.ItemIds {
+		if !items[id] {
+			err := fmt.Errorf("item %d does not belong to bulletin %d", id, bulletinId)
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+			return
+		}
+		if seen[id] {
+			err := fmt.Errorf("item %d is listed twice", id)
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+			return
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(items) {
+		err := errors.New("incomplete item list")
+		c.res.AbortInvalidRequestBody(ctx, err,
+			fmt.Sprintf("expected all %d items, got %d", len(items), len(seen)), payload)
+		return
+	}
+
+	for order, id := range payload.ItemIds {
+		if _, err := tx.ExecContext(_context,
+			"UPDATE bulletin_items SET sort_order = ? WHERE id = ?", order, id); err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+	}
+	if err := touchBulletin(ctx, _context, tx, bulletinId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "bulletin item order updated"})
+}
+
+func (c *EditorController) DeleteBulletinItem(ctx *gin.Context) {
+	bulletinId, err := strconv.Atoi(ctx.Param("bulletinId"))
+	if err != nil {
+		c.res.AbortInvalidBulletin(ctx, err, err.Error(), nil)
+		return
+	}
+	itemId, err := strconv.Atoi(ctx.Param("itemId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid item id", nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer tx.Rollback()
+
+	if !c.lockBulletin(ctx, _context, tx, bulletinId, nil) {
+		return
+	}
+	result, err := tx.ExecContext(_context,
+		"DELETE FROM bulletin_items WHERE id = ? AND bulletin_id = ?", itemId, bulletinId)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		err := errors.New("bulletin item not found")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusNotFound, nil)
+		return
+	}
+	if err := touchBulletin(ctx, _context, tx, bulletinId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "bulletin item deleted successfully", "id": itemId})
+}
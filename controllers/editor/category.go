@@ -24,7 +24,7 @@ func (c *EditorController) GetCategoriesByEdition(ctx *gin.Context) {
 		SELECT DISTINCT c.id, c.label
 		FROM categories c
 		JOIN articles a ON a.category_id = c.id
-		WHERE a.edition_id = ?
+		WHERE a.edition_id = ? AND a.deleted_at IS NULL
 		ORDER BY c.label ASC
 	`, editionId)
 
@@ -174,6 +174,7 @@ func (c *EditorController) CreateCategory(ctx *gin.Context) {
 		return
 	}
 
+	lib.InvalidateEditionToc()
 	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload, gin.H{"message": "category created successfully"})
 }
 
@@ -214,5 +215,6 @@ func (c *EditorController) UpdateCategoryOrder(ctx *gin.Context) {
 		return
 	}
 
+	lib.InvalidateEditionToc()
 	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload, gin.H{"message": "category order updated"})
 }
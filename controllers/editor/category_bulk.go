@@ -0,0 +1,327 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// editionCategories returns the ids of the categories owned by an edition,
+// locking them for the rest of the transaction.
+func editionCategories(ctx context.Context, tx *sql.Tx, editionId int) (map[int]bool, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id FROM categories WHERE edition_id = ? AND id <> ? FOR UPDATE", editionId, lib.UNCATEGORIZED)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		categories[id] = true
+	}
+	return categories, rows.Err()
+}
+
+// parseEditionCategory reads :editionId and :categoryId, rejecting Uncategorized.
+func (c *EditorController) parseEditionCategory(ctx *gin.Context) (int, int, bool) {
+	editionId, err := strconv.Atoi(ctx.Param("editionId"))
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return 0, 0, false
+	}
+	categoryId, err := strconv.Atoi(ctx.Param("categoryId"))
+	if err != nil {
+		c.res.AbortInvalidCategory(ctx, err, err.Error(), nil)
+		return 0, 0, false
+	}
+	if categoryId == lib.UNCATEGORIZED {
+		err := errors.New("uncategorized cannot be changed")
+		c.res.AbortInvalidCategory(ctx, err, err.Error(), nil)
+		return 0, 0, false
+	}
+	return editionId, categoryId, true
+}
+
+// ReorderCategories takes the complete ordered list of an edition's categories
+// and rewrites every `order` in one transaction.
+func (c *EditorController) ReorderCategories(ctx *gin.Context) {
+	editionId, err := strconv.Atoi(ctx.Param("editionId"))
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		CategoryIds []int `json:"categoryIds"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	categories, err := editionCategories(_context, tx, editionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	seen := map[int]bool{}
+	for _, id := range payload.CategoryIds {
+		if !categories[id] {
+			err := fmt.Errorf("category %d does not belong to edition %d", id, editionId)
+			c.res.AbortInvalidCategory(ctx, err, err.Error(), payload)
+			return
+		}
+		if seen[id] {
+			err := fmt.Errorf("category %d is listed twice", id)
+			c.res.AbortInvalidCategory(ctx, err, err.Error(), payload)
+			return
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(categories) {
+		err := errors.New("incomplete category list")
+		c.res.AbortInvalidRequestBody(ctx, err,
+			fmt.Sprintf("expected all %d categories of the edition, got %d", len(categories), len(seen)), payload)
+		return
+	}
+
+	for order, id := range payload.CategoryIds {
+		if _, err := tx.ExecContext(_context,
+			"UPDATE categories SET `order` = ? WHERE id = ?", order, id); err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	lib.InvalidateEditionToc()
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "category order updated", "id": editionId})
+}
+
+func (c *EditorController) RenameCategory(ctx *gin.Context) {
+	editionId, categoryId, ok := c.parseEditionCategory(ctx)
+	if !ok {
+		return
+	}
+
+	type reqBody struct {
+		Label string `json:"label"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	label := strings.TrimSpace(payload.Label)
+	if label == "" {
+		err := errors.New("missing category name")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// construct key
+	ck := strings.ReplaceAll(strings.ToLower(label), " ", "_")
+
+	result, err := c.db.ExecContext(_context,
+		"UPDATE categories SET label = ?, `key` = ? WHERE id = ? AND edition_id = ?",
+		label, ck, categoryId, editionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var exists bool
+		if err := c.db.QueryRowContext(_context,
+			"SELECT EXISTS(SELECT 1 FROM categories WHERE id = ? AND edition_id = ?)",
+			categoryId, editionId).Scan(&exists); err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		// an unchanged label also affects no rows
+		if !exists {
+			c.res.AbortCategoryNotFound(ctx, lib.ErrCategoryNotFound, "", payload)
+			return
+		}
+	}
+	lib.InvalidateEditionToc()
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "category renamed successfully", "id": categoryId})
+}
+
+// DeleteCategory removes a category, moving its articles to Uncategorized.
+func (c *EditorController) DeleteCategory(ctx *gin.Context) {
+	editionId, categoryId, ok := c.parseEditionCategory(ctx)
+	if !ok {
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer tx.Rollback()
+
+	categories, err := editionCategories(_context, tx, editionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if !categories[categoryId] {
+		c.res.AbortCategoryNotFound(ctx, lib.ErrCategoryNotFound,
+			fmt.Sprintf("category %d does not belong to edition %d", categoryId, editionId), nil)
+		return
+	}
+
+	result, err := tx.ExecContext(_context,
+		"UPDATE articles SET category_id = ? WHERE category_id = ?", lib.UNCATEGORIZED, categoryId)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	moved, _ := result.RowsAffected()
+	if _, err := tx.ExecContext(_context, "DELETE FROM categories WHERE id = ?", categoryId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	lib.InvalidateEditionToc()
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{
+		"message":       "category deleted successfully",
+		"id":            categoryId,
+		"movedArticles": moved,
+	})
+}
+
+// MergeCategories moves the articles of sourceIds into targetId and removes the sources.
+func (c *EditorController) MergeCategories(ctx *gin.Context) {
+	editionId, err := strconv.Atoi(ctx.Param("editionId"))
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		TargetId  int   `json:"targetId"`
+		SourceIds []int `json:"sourceIds"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if len(payload.SourceIds) == 0 {
+		err := errors.New("missing sourceIds")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	categories, err := editionCategories(_context, tx, editionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if !categories[payload.TargetId] {
+		err := fmt.Errorf("category %d does not belong to edition %d", payload.TargetId, editionId)
+		c.res.AbortInvalidCategory(ctx, err, err.Error(), payload)
+		return
+	}
+	for _, id := range payload.SourceIds {
+		if id == payload.TargetId || !categories[id] {
+			err := fmt.Errorf("category %d cannot be merged into %d", id, payload.TargetId)
+			c.res.AbortInvalidCategory(ctx, err, err.Error(), payload)
+			return
+		}
+	}
+
+	var moved int64
+	for _, id := range payload.SourceIds {
+		result, err := tx.ExecContext(_context,
+			"UPDATE articles SET category_id = ? WHERE category_id = ?", payload.TargetId, id)
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		n, _ := result.RowsAffected()
+		moved += n
+		if _, err := tx.ExecContext(_context, "DELETE FROM categories WHERE id = ?", id); err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	lib.InvalidateEditionToc()
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message":       "categories merged successfully",
+		"id":            payload.TargetId,
+		"movedArticles": moved,
+	})
+}
@@ -0,0 +1,328 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// GetComments is the moderation queue. It lists pending comments unless
+// ?status= names another status or "all".
+func (c *EditorController) GetComments(ctx *gin.Context) {
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 50,
+		MaxLimit:     200,
+		Sorts:        map[string]string{"createdAt": "c.created_at", "spamScore": "c.spam_score"},
+		DefaultSort:  "createdAt",
+		Filters:      map[string]string{"articleId": "c.article_id"},
+		Search:       []string{"c.body", "c.author_name", "c.author_email"},
+		IdColumn:     "c.id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	status := ctx.DefaultQuery("status", lib.COMMENT_PENDING)
+	if status != "all" {
+		if !slices.Contains(lib.COMMENT_STATUSES, status) {
+			c.res.AbortInvalidRequestBody(ctx, lib.ErrInvalidBody,
+				"status must be one of "+strings.Join(lib.COMMENT_STATUSES, ", ")+" or all", nil)
+			return
+		}
+		list.AddCondition("c.status = ?", status)
+	}
+
+	type commentResponseModel struct {
+		Id           int        `json:"id"`
+		ArticleId    int        `json:"articleId"`
+		ArticleTitle string     `json:"articleTitle"`
+		ParentId     *int       `json:"parentId"`
+		AuthorName   string     `json:"authorName"`
+		AuthorEmail  *string    `json:"authorEmail"`
+		Body         string     `json:"body"`
+		Status       string     `json:"status"`
+		SpamScore    int        `json:"spamScore"`
+		SpamReasons  *string    `json:"spamReasons"`
+		Commenter    string     `json:"commenter"`
+		CreatedAt    *time.Time `json:"createdAt"`
+		ModeratedAt  *time.Time `json:"moderatedAt"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context,
+		"SELECT COUNT(c.id) FROM article_comments c WHERE 1 = 1"+filter, filterArgs...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
+	rows, err := c.db.QueryContext(_context, `
+		SELECT c.id, c.article_id, a.title, c.parent_id, c.author_name, c.author_email, c.body,
+			c.status, c.spam_score, c.spam_reasons, c.ip_hash, c.created_at, c.moderated_at,
+			`+list.SortColumn()+`
+		FROM article_comments c
+		JOIN articles a ON a.id = c.article_id
+		WHERE 1 = 1`+where+`
+		ORDER BY `+list.OrderBy()+` `+paginate,
+		append(args, paginateArgs...)...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	comments := []*commentResponseModel{}
+	for rows.Next() {
+		var comment commentResponseModel
+		var ipHash string
+		var createdAt, moderatedAt []uint8
+		var sortValue sql.NullString
+		if err := rows.Scan(
+			&comment.Id,
+			&comment.ArticleId,
+			&comment.ArticleTitle,
+			&comment.ParentId,
+			&comment.AuthorName,
+			&comment.AuthorEmail,
+			&comment.Body,
+			&comment.Status,
+			&comment.SpamScore,
+			&comment.SpamReasons,
+			&ipHash,
+			&createdAt,
+			&moderatedAt,
+			&sortValue,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		// enough to spot several comments from one client, useless otherwise
+		comment.Commenter = ipHash[:min(len(ipHash), 12)]
+		comment.CreatedAt = lib.Base64ToTime(createdAt)
+		comment.ModeratedAt = lib.Base64ToTime(moderatedAt)
+		list.Scanned(sortValue, comment.Id)
+		comments = append(comments, &comment)
+	}
+
+	c.res.SuccessWithMeta(ctx, nil, comments[:list.Trim()], list.Meta(total))
+}
+
+func (c *EditorController) ApproveComment(ctx *gin.Context) {
+	c.moderateComment(ctx, lib.COMMENT_APPROVED)
+}
+
+func (c *EditorController) RejectComment(ctx *gin.Context) {
+	c.moderateComment(ctx, lib.COMMENT_REJECTED)
+}
+
+func (c *EditorController) moderateComment(ctx *gin.Context, status string) {
+	commentId, err := strconv.Atoi(ctx.Param("commentId"))
+	if err != nil {
+		c.res.AbortInvalidComment(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context,
+		"UPDATE article_comments SET status = ?, moderated_at = ? WHERE id = ?",
+		status, time.Now().UTC(), commentId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortCommentNotFound(ctx, lib.ErrCommentNotFound, "", nil)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"message": "comment " + status + " successfully", "id": commentId})
+}
+
+// BanCommenter rejects a comment and bans its email address and client, also
+// rejecting everything they still have waiting in the queue.
+func (c *EditorController) BanCommenter(ctx *gin.Context) {
+	commentId, err := strconv.Atoi(ctx.Param("commentId"))
+	if err != nil {
+		c.res.AbortInvalidComment(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		Reason string `json:"reason"`
+	}
+	var payload reqBody
+	// the body is optional
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+			return
+		}
+	}
+	var reason *string
+	if r := strings.TrimSpace(payload.Reason); r != "" {
+		reason = &r
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	var email sql.NullString
+	var ipHash string
+	err = tx.QueryRowContext(_context,
+		"SELECT author_email, ip_hash FROM article_comments WHERE id = ? FOR UPDATE", commentId).Scan(&email, &ipHash)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortCommentNotFound(ctx, err, err.Error(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	now := time.Now().UTC()
+	bans := map[string]string{lib.BAN_IP: ipHash}
+	if email.Valid {
+		bans[lib.BAN_EMAIL] = email.String
+	}
+	for kind, value := range bans {
+		if _, err := tx.ExecContext(_context, `
+			INSERT INTO comment_bans (kind, value, reason, created_at) VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE reason = COALESCE(VALUES(reason), reason)`,
+			kind, value, reason, now); err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+	}
+
+	result, err := tx.ExecContext(_context, `
+		UPDATE article_comments SET status = ?, moderated_at = ?
+		WHERE id = ? OR (status IN (?, ?) AND (ip_hash = ? OR author_email = ?))`,
+		lib.COMMENT_REJECTED, now, commentId, lib.COMMENT_PENDING, lib.COMMENT_SPAM, ipHash, email)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	rejected, _ := result.RowsAffected()
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message":          "commenter banned successfully",
+		"id":               commentId,
+		"bans":             len(bans),
+		"rejectedComments": rejected,
+	})
+}
+
+func (c *EditorController) GetCommentBans(ctx *gin.Context) {
+	type banResponseModel struct {
+		Id        int        `json:"id"`
+		Kind      string     `json:"kind"`
+		Value     string     `json:"value"`
+		Reason    *string    `json:"reason"`
+		CreatedAt *time.Time `json:"createdAt"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(_context,
+		"SELECT id, kind, value, reason, created_at FROM comment_bans ORDER BY created_at DESC, id DESC")
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	bans := []*banResponseModel{}
+	for rows.Next() {
+		var ban banResponseModel
+		var createdAt []uint8
+		if err := rows.Scan(&ban.Id, &ban.Kind, &ban.Value, &ban.Reason, &createdAt); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		// client hashes mean nothing to moderators, show the same prefix as the queue
+		if ban.Kind == lib.BAN_IP {
+			ban.Value = ban.Value[:min(len(ban.Value), 12)]
+		}
+		ban.CreatedAt = lib.Base64ToTime(createdAt)
+		bans = append(bans, &ban)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, bans)
+}
+
+func (c *EditorController) DeleteCommentBan(ctx *gin.Context) {
+	banId, err := strconv.Atoi(ctx.Param("banId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid ban id", nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, "DELETE FROM comment_bans WHERE id = ?", banId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		err := fmt.Errorf("ban %d not found", banId)
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusNotFound, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "ban lifted successfully", "id": banId})
+}
@@ -0,0 +1,173 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *EditorController) GetArticleContributors(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("articleId"))
+	if err != nil {
+		c.res.AbortInvalidArticle(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	contributors, err := lib.GetArticleContributors(_context, c.db, articleId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if len(contributors) == 0 {
+		c.res.AbortArticleNotFound(ctx, lib.ErrArticleNotFound, "", nil)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"contributors": contributors})
+}
+
+// UpdateArticleContributors replaces the additional contributors of an article.
+// The primary author is still set through save-info (articles.writer_id).
+func (c *EditorController) UpdateArticleContributors(ctx *gin.Context) {
+	articleId, err := strconv.Atoi(ctx.Param("articleId"))
+	if err != nil {
+		c.res.AbortInvalidArticle(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type contributor struct {
+		WriterId int    `json:"writerId"`
+		Role     string `json:"role"`
+	}
+	type reqBody struct {
+		Contributors []contributor `json:"contributors"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	writerIds := []any{}
+	uniqueWriters := map[int]bool{}
+	seen := map[string]bool{}
+	for i, item := range payload.Contributors {
+		if !slices.Contains(lib.CONTRIBUTOR_ROLES, item.Role) {
+			err := fmt.Errorf("invalid role %q", item.Role)
+			c.res.AbortInvalidRequestBody(ctx, err,
+				fmt.Sprintf("role must be one of %s", strings.Join(lib.CONTRIBUTOR_ROLES, ", ")), payload)
+			return
+		}
+		if item.WriterId < 1 {
+			err := fmt.Errorf("invalid writer at index %d", i)
+			c.res.AbortInvalidWriter(ctx, err, err.Error(), payload)
+			return
+		}
+		key := fmt.Sprintf("%d:%s", item.WriterId, item.Role)
+		if seen[key] {
+			err := errors.New("duplicate contributor")
+			c.res.AbortInvalidRequestBody(ctx, err,
+				fmt.Sprintf("writer %d is listed twice as %s", item.WriterId, item.Role), payload)
+			return
+		}
+		seen[key] = true
+		if !uniqueWriters[item.WriterId] {
+			uniqueWriters[item.WriterId] = true
+			writerIds = append(writerIds, item.WriterId)
+		}
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var primaryWriter int
+	err = c.db.QueryRowContext(_context, "SELECT writer_id FROM articles WHERE id = ?", articleId).Scan(&primaryWriter)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortArticleNotFound(ctx, err, "", payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	if len(writerIds) > 0 {
+		var found int
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(writerIds)), ",")
+		err = c.db.QueryRowContext(_context,
+			"SELECT COUNT(id) FROM writers WHERE id IN ("+placeholders+")", writerIds...).Scan(&found)
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		if found != len(writerIds) {
+			c.res.AbortWriterNotFound(ctx, lib.ErrWriterNotFound, "one or more writers do not exist", payload)
+			return
+		}
+	}
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(_context,
+		"DELETE FROM article_contributors WHERE article_id = ?", articleId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	position := 0
+	for _, item := range payload.Contributors {
+		// the primary author is implied, do not store it twice
+		if item.WriterId == primaryWriter && item.Role == lib.ROLE_AUTHOR {
+			continue
+		}
+		if _, err := tx.ExecContext(_context, `
+			INSERT INTO article_contributors (article_id, writer_id, role, position)
+			VALUES (?, ?, ?, ?)`, articleId, item.WriterId, item.Role, position); err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		position++
+	}
+
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(_context,
+		"UPDATE articles SET updated_at = ? WHERE id = ?", now, articleId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	// the table of contents lists contributors
+	lib.InvalidateEditionToc()
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message":   "contributors saved successfully",
+		"id":        articleId,
+		"updatedAt": now,
+	})
+}
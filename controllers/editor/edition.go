@@ -15,6 +15,7 @@ type EditionResponseModel struct {
 	Id           int        `json:"id"`
 	Title        string     `json:"title"`
 	PublishedAt  *time.Time `json:"publishedAt"`
+	ArchivedAt   *time.Time `json:"archivedAt"`
 	EditionYear  *int       `json:"year"`
 	CoverImg     *string    `json:"coverImg"`
 	ThumbnailImg *string    `json:"thumbImg"`
@@ -27,7 +28,7 @@ func (c *EditorController) GetAllEditions(ctx *gin.Context) {
 	editions := []*EditionResponseModel{}
 	rows, err := c.db.QueryContext(_context, `
 		SELECT editions.id, title, thumb_img,
-			cover_img, published_at, edition_year,
+			cover_img, published_at, archived_at, edition_year,
 			edition_id as active_edition
 		FROM editions, active_edition
 		ORDER BY created_at DESC
@@ -45,19 +46,21 @@ func (c *EditorController) GetAllEditions(ctx *gin.Context) {
 	var activeEdition int
 	for rows.Next() {
 		var edition EditionResponseModel
-		var publishedAt []uint8
+		var publishedAt, archivedAt []uint8
 		if err := rows.Scan(
 			&edition.Id,
 			&edition.Title,
 			&edition.ThumbnailImg,
 			&edition.CoverImg,
 			&publishedAt,
+			&archivedAt,
 			&edition.EditionYear,
 			&activeEdition,
 		); err != nil {
 			c.res.AbortDatabaseError(ctx, err, nil)
 		}
 		edition.PublishedAt = lib.Base64ToTime(publishedAt)
+		edition.ArchivedAt = lib.Base64ToTime(archivedAt)
 		editions = append(editions, &edition)
 	}
 
@@ -112,6 +115,7 @@ func (c *EditorController) EditEditionInfo(ctx *gin.Context) {
 		c.res.AbortDatabaseError(ctx, err, req)
 		return
 	}
+	lib.InvalidateEditionToc()
 
 	c.res.SuccessWithStatusOKJSON(
 		ctx,
@@ -240,23 +244,28 @@ func (c *EditorController) PublishEdition(ctx *gin.Context) {
 		return
 	}
 
-	_, err = tx.ExecContext(_context, `
-		UPDATE active_edition
-		SET edition_id = ?
-	`, parsedEditionId)
-	if _context.Err() == context.DeadlineExceeded {
-		c.res.AbortDatabaseTimeout(ctx, err, nil)
-		return
-	}
-	if err != nil {
-		c.res.AbortDatabaseError(ctx, err, nil)
-		return
+	defer tx.Rollback()
+
+	// ?activate=false publishes without replacing the active edition
+	if ctx.Query("activate") != "false" {
+		_, err = tx.ExecContext(_context, `
+			UPDATE active_edition
+			SET edition_id = ?
+		`, parsedEditionId)
+		if _context.Err() == context.DeadlineExceeded {
+			c.res.AbortDatabaseTimeout(ctx, err, nil)
+			return
+		}
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
 	}
 
 	now := time.Now().UTC()
 	_, err = tx.ExecContext(_context, `
 		UPDATE editions
-		SET published_at = ?
+		SET published_at = ?, archived_at = NULL
 		WHERE id = ?
 	`, now, parsedEditionId)
 	if _context.Err() == context.DeadlineExceeded {
@@ -273,6 +282,7 @@ func (c *EditorController) PublishEdition(ctx *gin.Context) {
 		c.res.AbortDatabaseError(ctx, err, nil)
 		return
 	}
+	lib.InvalidateEditionToc()
 
 	c.res.SuccessWithStatusOKJSON(
 		ctx,
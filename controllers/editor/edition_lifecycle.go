@@ -0,0 +1,313 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+var errOnlyPublishedEdition = errors.New("edition is the only published edition")
+
+// nextActiveEdition returns the most recently published edition other than
+// editionId, so the public site never points at a withdrawn edition.
+func nextActiveEdition(ctx context.Context, tx *sql.Tx, editionId int) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx, `
+		SELECT id FROM editions
+		WHERE id <> ? AND published_at IS NOT NULL AND archived_at IS NULL
+		ORDER BY published_at DESC
+		LIMIT 1`, editionId).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, errOnlyPublishedEdition
+	}
+	return id, err
+}
+
+func (c *EditorController) UnpublishEdition(ctx *gin.Context) {
+	c.withdrawEdition(ctx, false)
+}
+
+func (c *EditorController) ArchiveEdition(ctx *gin.Context) {
+	c.withdrawEdition(ctx, true)
+}
+
+// withdrawEdition takes an edition off the public site. Unpublishing returns it
+// to draft, archiving retires it. If it was the active edition, the latest other
+// published edition becomes active.
+func (c *EditorController) withdrawEdition(ctx *gin.Context, archive bool) {
+	editionId := ctx.Param("editionId")
+	parsedEditionId, err := strconv.Atoi(editionId)
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(_context, "SELECT id FROM editions WHERE id = ? FOR UPDATE", parsedEditionId).Scan(&id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortEditionNotFound(ctx, err, "", nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	var activeEdition int
+	if err := tx.QueryRowContext(_context, "SELECT edition_id FROM active_edition").Scan(&activeEdition); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if activeEdition == parsedEditionId {
+		activeEdition, err = nextActiveEdition(_context, tx, parsedEditionId)
+		if err == errOnlyPublishedEdition {
+			c.res.AbortWithStatusJSON(ctx, err, err.Error(),
+				"publish another edition before withdrawing the active one", http.StatusConflict, nil)
+			return
+		}
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+		if _, err := tx.ExecContext(_context, "UPDATE active_edition SET edition_id = ?", activeEdition); err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+	}
+
+	var archivedAt *time.Time
+	message := "edition unpublished successfully"
+	if archive {
+		now := time.Now().UTC()
+		archivedAt = &now
+		message = "edition archived successfully"
+	}
+	if _, err := tx.ExecContext(_context, `
+		UPDATE editions
+		SET published_at = NULL, archived_at = ?
+		WHERE id = ?`, archivedAt, parsedEditionId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	lib.InvalidateEditionToc()
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
+		"message":       message,
+		"id":            parsedEditionId,
+		"archivedAt":    archivedAt,
+		"activeEdition": activeEdition,
+	})
+}
+
+func (c *EditorController) SetActiveEdition(ctx *gin.Context) {
+	editionId := ctx.Param("editionId")
+	parsedEditionId, err := strconv.Atoi(editionId)
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var publishedAt []uint8
+	err = c.db.QueryRowContext(_context,
+		"SELECT published_at FROM editions WHERE id = ?", parsedEditionId).Scan(&publishedAt)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortEditionNotFound(ctx, err, "", nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if lib.Base64ToTime(publishedAt) == nil {
+		err := errors.New("edition is not published")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(),
+			"only a published edition can be active", http.StatusConflict, nil)
+		return
+	}
+
+	if _, err := c.db.ExecContext(_context,
+		"UPDATE active_edition SET edition_id = ?", parsedEditionId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
+		"message":       "active edition updated successfully",
+		"activeEdition": parsedEditionId,
+	})
+}
+
+// DeleteEdition removes an edition with its categories, articles and bucket
+// objects. Editions with published articles are only deleted when the request
+// repeats the edition id: ?cascade=true&confirm=<editionId>.
+func (c *EditorController) DeleteEdition(ctx *gin.Context) {
+	editionId := ctx.Param("editionId")
+	parsedEditionId, err := strconv.Atoi(editionId)
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return
+	}
+	cascade := ctx.Query("cascade") == "true" && ctx.Query("confirm") == editionId
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	var year int
+	var coverImg, thumbImg sql.NullString
+	err = c.db.QueryRowContext(_context,
+		"SELECT edition_year, cover_img, thumb_img FROM editions WHERE id = ?", parsedEditionId).Scan(
+		&year, &coverImg, &thumbImg)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortEditionNotFound(ctx, err, "", nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	var activeEdition int
+	if err := c.db.QueryRowContext(_context, "SELECT edition_id FROM active_edition").Scan(&activeEdition); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if activeEdition == parsedEditionId {
+		err := errors.New("edition is active")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(),
+			"pick another active edition before deleting this one", http.StatusConflict, nil)
+		return
+	}
+
+	var published int
+	if err := c.db.QueryRowContext(_context, `
+		SELECT COUNT(id) FROM articles
+		WHERE edition_id = ? AND published_date IS NOT NULL AND archived_date IS NULL AND deleted_at IS NULL`,
+		parsedEditionId).Scan(&published); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if published > 0 && !cascade {
+		err := errors.New("edition has published articles")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(),
+			fmt.Sprintf("%d published article(s) would be deleted, repeat with ?cascade=true&confirm=%d",
+				published, parsedEditionId),
+			http.StatusConflict, nil)
+		return
+	}
+
+	objects := []string{
+		services.ObjectPathFromURL(coverImg.String),
+		services.ObjectPathFromURL(thumbImg.String),
+	}
+	prefixes := []string{fmt.Sprintf("zaitun/editions/%d/%d/", year, parsedEditionId)}
+	rows, err := c.db.QueryContext(_context,
+		"SELECT id, cover_img, thumb_img FROM articles WHERE edition_id = ?", parsedEditionId)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	articles := 0
+	for rows.Next() {
+		var articleId int
+		var articleCover, articleThumb sql.NullString
+		if err := rows.Scan(&articleId, &articleCover, &articleThumb); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		articles++
+		prefixes = append(prefixes, fmt.Sprintf("zaitun/articles/%d/%d/", year, articleId))
+		objects = append(objects,
+			services.ObjectPathFromURL(articleCover.String),
+			services.ObjectPathFromURL(articleThumb.String))
+	}
+	rows.Close()
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []struct {
+		query string
+		args  []any
+	}{
+		// articles of other editions filed under this edition's categories
+		{`UPDATE articles SET category_id = ?
+			WHERE edition_id <> ? AND category_id IN (SELECT id FROM categories WHERE edition_id = ? AND id <> ?)`,
+			[]any{lib.UNCATEGORIZED, parsedEditionId, parsedEditionId, lib.UNCATEGORIZED}},
+		{"DELETE FROM articles WHERE edition_id = ?", []any{parsedEditionId}},
+		{"DELETE FROM categories WHERE edition_id = ? AND id <> ?", []any{parsedEditionId, lib.UNCATEGORIZED}},
+		{"DELETE FROM editions WHERE id = ?", []any{parsedEditionId}},
+	} {
+		if _, err := tx.ExecContext(_context, stmt.query, stmt.args...); err != nil {
+			if _context.Err() == context.DeadlineExceeded {
+				c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+				return
+			}
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	lib.InvalidateEditionToc()
+
+	// the rows are gone, a storage failure only leaves orphaned objects behind
+	deleted, err := services.DeleteObjects(_context, objects, prefixes)
+	res := gin.H{
+		"message":        "edition deleted successfully",
+		"id":             parsedEditionId,
+		"articles":       articles,
+		"objectsDeleted": deleted,
+		"storageCleanup": "ok",
+	}
+	if err != nil {
+		log.Println("delete edition: storage cleanup failed:", err.Error())
+		res["storageCleanup"] = err.Error()
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, res)
+}
@@ -0,0 +1,247 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// DuplicateEdition creates a new draft edition from an existing one: its
+// categories in order, its edition-wide ad campaigns when a new run
+// (adsStartDate, adsEndDate) is given and, when articleIds is given, those
+// articles as empty drafts (recurring columns keep their title, writer and
+// contributors).
+func (c *EditorController) DuplicateEdition(ctx *gin.Context) {
+	editionId := ctx.Param("editionId")
+	sourceId, err := strconv.Atoi(editionId)
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		Title        string `json:"title"`
+		Year         int    `json:"year"`
+		CopyAds      *bool  `json:"copyAds"`
+		AdsStartDate string `json:"adsStartDate"`
+		AdsEndDate   string `json:"adsEndDate"`
+		ArticleIds   []int  `json:"articleIds"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if strings.TrimSpace(payload.Title) == "" {
+		err := errors.New("missing edition title")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+	if payload.Year < 1970 {
+		c.res.AbortInvalidRequestBody(ctx, lib.ErrInvalidBody, "year must be greater than 1970", payload)
+		return
+	}
+
+	// the campaigns of the source edition have run their course, cloned ones
+	// run over the range given for the new edition
+	var adsStart, adsEnd *time.Time
+	if payload.AdsStartDate != "" || payload.AdsEndDate != "" {
+		start, err := time.Parse(time.RFC3339, payload.AdsStartDate)
+		if err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, "invalid adsStartDate format (use ISO 8601)", payload)
+			return
+		}
+		end, err := time.Parse(time.RFC3339, payload.AdsEndDate)
+		if err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, "invalid adsEndDate format (use ISO 8601)", payload)
+			return
+		}
+		if !end.After(start) {
+			err := errors.New("adsEndDate must be after adsStartDate")
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+			return
+		}
+		start, end = start.UTC(), end.UTC()
+		adsStart, adsEnd = &start, &end
+	}
+	copyAds := adsStart != nil
+	if payload.CopyAds != nil {
+		copyAds = *payload.CopyAds
+	}
+	if copyAds && adsStart == nil {
+		err := errors.New("copying ads needs adsStartDate and adsEndDate")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	var exists bool
+	err = c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM editions WHERE id = ?)", sourceId).Scan(&exists)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if !exists {
+		c.res.AbortEditionNotFound(ctx, lib.ErrEditionNotFound, "", payload)
+		return
+	}
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	imgPath := "/static/placeholder.jpg"
+	result, err := tx.ExecContext(_context,
+		"INSERT INTO editions (title, edition_year, thumb_img, cover_img) VALUES (?, ?, ?, ?)",
+		strings.TrimSpace(payload.Title), payload.Year, imgPath, imgPath)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	newId64, err := result.LastInsertId()
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	newId := int(newId64)
+
+	type category struct {
+		id    int
+		label string
+		key   sql.NullString
+		order int
+	}
+	rows, err := tx.QueryContext(_context,
+		"SELECT id, label, `key`, `order` FROM categories WHERE edition_id = ? AND id <> ? ORDER BY `order` ASC",
+		sourceId, lib.UNCATEGORIZED)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	categories := []*category{}
+	for rows.Next() {
+		var cat category
+		if err := rows.Scan(&cat.id, &cat.label, &cat.key, &cat.order); err != nil {
+			rows.Close()
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		categories = append(categories, &cat)
+	}
+	rows.Close()
+
+	// source category id -> cloned category id
+	categoryIds := map[int]int{lib.UNCATEGORIZED: lib.UNCATEGORIZED}
+	for _, cat := range categories {
+		result, err := tx.ExecContext(_context,
+			"INSERT INTO categories (label, `key`, edition_id, `order`) VALUES (?, ?, ?, ?)",
+			cat.label, cat.key, newId, cat.order)
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		categoryIds[cat.id] = int(id)
+	}
+
+	var ads int64
+	if copyAds {
+		result, err := tx.ExecContext(_context, `
+			INSERT INTO ad_campaigns (creative_id, placement, edition_id, start_date, end_date, priority)
+			SELECT creative_id, placement, ?, ?, ?, priority
+			FROM ad_campaigns
+			WHERE edition_id = ? AND article_id IS NULL`,
+			newId, adsStart, adsEnd, sourceId)
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		ads, _ = result.RowsAffected()
+	}
+
+	now := time.Now().UTC()
+	articles := []int{}
+	copied := map[int]bool{}
+	for _, articleId := range payload.ArticleIds {
+		if copied[articleId] {
+			continue
+		}
+		copied[articleId] = true
+		var title, adsJSON string
+		var categoryId, writerId int
+		err := tx.QueryRowContext(_context, `
+			SELECT title, category_id, writer_id, ads_json FROM articles
+			WHERE id = ? AND edition_id = ? AND deleted_at IS NULL`,
+			articleId, sourceId).Scan(&title, &categoryId, &writerId, &adsJSON)
+		if err == sql.ErrNoRows {
+			c.res.AbortArticleNotFound(ctx, err,
+				fmt.Sprintf("article %d does not belong to edition %d or is in the trash", articleId, sourceId), payload)
+			return
+		}
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		newCategory, ok := categoryIds[categoryId]
+		if !ok {
+			newCategory = lib.UNCATEGORIZED
+		}
+
+		result, err := tx.ExecContext(_context, `
+			INSERT INTO articles (edition_id, title, slug, category_id, writer_id, created_at, updated_at, cover_img, thumb_img, ads_json)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			newId, title, formatTitleToSlug(title), newCategory, writerId, now, now, imgPath, imgPath, adsJSON)
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		newArticle, err := result.LastInsertId()
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		if _, err := tx.ExecContext(_context, `
+			INSERT INTO article_contributors (article_id, writer_id, role, position)
+			SELECT ?, writer_id, role, position FROM article_contributors WHERE article_id = ?`,
+			newArticle, articleId); err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return
+		}
+		articles = append(articles, int(newArticle))
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload, gin.H{
+		"message":    "edition duplicated successfully",
+		"id":         newId,
+		"categories": len(categories),
+		"ads":        ads,
+		"articleIds": articles,
+	})
+}
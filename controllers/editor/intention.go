@@ -0,0 +1,506 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMassIntentions is the secretariat's queue of ujud misa. It lists pending
+// intentions unless ?status= names another status or "all". mass is the Mass
+// the intention is assigned to as it stands in the schedule now, null when
+// that Mass no longer exists.
+func (c *EditorController) GetMassIntentions(ctx *gin.Context) {
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 50,
+		MaxLimit:     200,
+		Sorts:        map[string]string{"massDate": "i.mass_date", "createdAt": "i.created_at"},
+		DefaultSort:  "massDate",
+		Filters:      map[string]string{"massDate": "i.mass_date", "kind": "i.kind"},
+		Search:       []string{"i.for_name", "i.requester_name", "i.intention"},
+		IdColumn:     "i.id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	status := ctx.DefaultQuery("status", lib.INTENTION_PENDING)
+	if status != "all" {
+		if !slices.Contains(lib.INTENTION_STATUSES, status) {
+			c.res.AbortInvalidRequestBody(ctx, lib.ErrInvalidBody,
+				"status must be one of "+strings.Join(lib.INTENTION_STATUSES, ", ")+" or all", nil)
+			return
+		}
+		list.AddCondition("i.status = ?", status)
+	}
+
+	type intentionResponseModel struct {
+		Id            int        `json:"id"`
+		Kind          string     `json:"kind"`
+		KindLabel     string     `json:"kindLabel"`
+		ForName       string     `json:"forName"`
+		Intention     *string    `json:"intention"`
+		RequesterName string     `json:"requesterName"`
+		Phone         *string    `json:"phone"`
+		Email         *string    `json:"email"`
+		PublishName   bool       `json:"publishName"`
+		MassDate      string     `json:"massDate"`
+		ScheduleId    *int       `json:"scheduleId"`
+		ExceptionId   *int       `json:"exceptionId"`
+		Mass          *lib.Mass  `json:"mass"`
+		RequestedMass string     `json:"requestedMass"`
+		Status        string     `json:"status"`
+		AdminNotes    *string    `json:"adminNotes"`
+		Requester     string     `json:"requester"`
+		CreatedAt     *time.Time `json:"createdAt"`
+		ConfirmedAt   *time.Time `json:"confirmedAt"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context,
+		"SELECT COUNT(i.id) FROM mass_intentions i WHERE 1 = 1"+filter, filterArgs...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
+	rows, err := c.db.QueryContext(_context, `
+		SELECT i.id, i.kind, i.for_name, i.intention, i.requester_name, i.phone, i.email, i.publish_name,
+			i.mass_date, i.schedule_id, i.exception_id, i.requested_mass, i.status, i.admin_notes,
+			i.ip_hash, i.created_at, i.confirmed_at, `+list.SortColumn()+`
+		FROM mass_intentions i
+		WHERE 1 = 1`+where+`
+		ORDER BY `+list.OrderBy()+` `+paginate,
+		append(args, paginateArgs...)...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	intentions := []*intentionResponseModel{}
+	for rows.Next() {
+		var intention intentionResponseModel
+		var ipHash string
+		var createdAt, confirmedAt []uint8
+		var sortValue sql.NullString
+		if err := rows.Scan(
+			&intention.Id,
+			&intention.Kind,
+			&intention.ForName,
+			&intention.Intention,
+			&intention.RequesterName,
+			&intention.Phone,
+			&intention.Email,
+			&intention.PublishName,
+			&intention.MassDate,
+			&intention.ScheduleId,
+			&intention.ExceptionId,
+			&intention.RequestedMass,
+			&intention.Status,
+			&intention.AdminNotes,
+			&ipHash,
+			&createdAt,
+			&confirmedAt,
+			&sortValue,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		intention.KindLabel = lib.IntentionKindLabel(intention.Kind)
+		// enough to spot several requests from one client, useless otherwise
+		intention.Requester = ipHash[:min(len(ipHash), 12)]
+		intention.CreatedAt = lib.Base64ToTime(createdAt)
+		intention.ConfirmedAt = lib.Base64ToTime(confirmedAt)
+		list.Scanned(sortValue, intention.Id)
+		intentions = append(intentions, &intention)
+	}
+	intentions = intentions[:list.Trim()]
+
+	if len(intentions) > 0 {
+		dates := []string{}
+		for _, intention := range intentions {
+			dates = append(dates, intention.MassDate)
+		}
+		from, _ := time.ParseInLocation(time.DateOnly, slices.Min(dates), lib.ParishLocation())
+		to, _ := time.ParseInLocation(time.DateOnly, slices.Max(dates), lib.ParishLocation())
+		days, err := lib.GetMassSchedule(_context, c.db, from, to, 0)
+		if _context.Err() == context.DeadlineExceeded {
+			c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+			return
+		}
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+		for _, intention := range intentions {
+			intention.Mass = lib.FindMass(days, intention.MassDate, intention.ScheduleId, intention.ExceptionId)
+		}
+	}
+
+	c.res.SuccessWithMeta(ctx, nil, intentions, list.Meta(total))
+}
+
+// UpdateMassIntention corrects an intention before it is read out. The
+// secretariat may also withdraw the consent to show the name publicly.
+func (c *EditorController) UpdateMassIntention(ctx *gin.Context) {
+	intentionId, err := strconv.Atoi(ctx.Param("intentionId"))
+	if err != nil {
+		c.res.AbortInvalidIntention(ctx, err, err.Error(), nil)
+		return
+	}
+	type reqBody struct {
+		Kind        string  `json:"kind" binding:"required"`
+		ForName     string  `json:"forName" binding:"required"`
+		Intention   *string `json:"intention"`
+		PublishName bool    `json:"publishName"`
+		AdminNotes  *string `json:"adminNotes"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if !slices.Contains(lib.INTENTION_KINDS, payload.Kind) {
+		err := fmt.Errorf("kind must be one of %s", strings.Join(lib.INTENTION_KINDS, ", "))
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+	payload.ForName = strings.TrimSpace(payload.ForName)
+	if payload.ForName == "" || utf8.RuneCountInString(payload.ForName) > 128 {
+		err := errors.New("forName must be 1 to 128 characters")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+	payload.Intention, payload.AdminNotes = trimOptional(payload.Intention), trimOptional(payload.AdminNotes)
+	if payload.Intention != nil && utf8.RuneCountInString(*payload.Intention) > 500 {
+		err := errors.New("intention must be at most 500 characters")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+	if payload.AdminNotes != nil && utf8.RuneCountInString(*payload.AdminNotes) > 255 {
+		err := errors.New("adminNotes must be at most 255 characters")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, `
+		UPDATE mass_intentions
+		SET kind = ?, for_name = ?, intention = ?, publish_name = ?, admin_notes = ?, updated_at = ?, updated_by = ?
+		WHERE id = ?`,
+		payload.Kind, payload.ForName, payload.Intention, payload.PublishName, payload.AdminNotes,
+		time.Now().UTC(), lib.AdminId(ctx), intentionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if !c.intentionExists(ctx, _context, intentionId, payload) {
+			return
+		}
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "mass intention updated successfully", "id": intentionId})
+}
+
+// intentionExists tells an unchanged row from a missing one after an UPDATE,
+// aborting with 404 for the latter.
+func (c *EditorController) intentionExists(ctx *gin.Context, _context context.Context, intentionId int, reqData any) bool {
+	var exists bool
+	err := c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM mass_intentions WHERE id = ?)", intentionId).Scan(&exists)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, reqData)
+		return false
+	}
+	if !exists {
+		c.res.AbortIntentionNotFound(ctx, lib.ErrIntentionNotFound, "", reqData)
+		return false
+	}
+	return true
+}
+
+// celebratedMass looks up the Mass an intention goes to and aborts unless it
+// is celebrated.
+func (c *EditorController) celebratedMass(ctx *gin.Context, _context context.Context, date string, scheduleId, exceptionId *int, reqData any) (*lib.Mass, bool) {
+	mass, err := lib.GetMass(_context, c.db, date, scheduleId, exceptionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), reqData)
+		return nil, false
+	}
+	if err == sql.ErrNoRows {
+		err := fmt.Errorf("there is no such Mass on %s", date)
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "assign the intention to another Mass", http.StatusConflict, reqData)
+		return nil, false
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, reqData)
+		return nil, false
+	}
+	if mass.Cancelled {
+		err := fmt.Errorf("the Mass of %s is cancelled", mass.Describe())
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "assign the intention to another Mass", http.StatusConflict, reqData)
+		return nil, false
+	}
+	return mass, true
+}
+
+// AssignMassIntention moves an intention to another Mass of the schedule,
+// given by its date and scheduleId or, for a Mass added that day, exceptionId.
+func (c *EditorController) AssignMassIntention(ctx *gin.Context) {
+	intentionId, err := strconv.Atoi(ctx.Param("intentionId"))
+	if err != nil {
+		c.res.AbortInvalidIntention(ctx, err, err.Error(), nil)
+		return
+	}
+	type reqBody struct {
+		Date        string `json:"date" binding:"required"`
+		ScheduleId  *int   `json:"scheduleId"`
+		ExceptionId *int   `json:"exceptionId"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := lib.ValidMassRef(payload.Date, payload.ScheduleId, payload.ExceptionId); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	mass, ok := c.celebratedMass(ctx, _context, payload.Date, payload.ScheduleId, payload.ExceptionId, payload)
+	if !ok {
+		return
+	}
+	scheduleId, exceptionId := mass.ScheduleId, (*int)(nil)
+	if scheduleId == nil {
+		exceptionId = mass.ExceptionId
+	}
+
+	result, err := c.db.ExecContext(_context, `
+		UPDATE mass_intentions
+		SET mass_date = ?, schedule_id = ?, exception_id = ?, updated_at = ?, updated_by = ?
+		WHERE id = ?`,
+		mass.Date, scheduleId, exceptionId, time.Now().UTC(), lib.AdminId(ctx), intentionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if !c.intentionExists(ctx, _context, intentionId, payload) {
+			return
+		}
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message": "mass intention assigned successfully",
+		"id":      intentionId,
+		"mass":    mass,
+	})
+}
+
+// ConfirmMassIntention accepts an intention for the Mass it is assigned to,
+// which must still be celebrated.
+func (c *EditorController) ConfirmMassIntention(ctx *gin.Context) {
+	intentionId, err := strconv.Atoi(ctx.Param("intentionId"))
+	if err != nil {
+		c.res.AbortInvalidIntention(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var massDate string
+	var scheduleId, exceptionId *int
+	err = c.db.QueryRowContext(_context,
+		"SELECT mass_date, schedule_id, exception_id FROM mass_intentions WHERE id = ?",
+		intentionId).Scan(&massDate, &scheduleId, &exceptionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortIntentionNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if scheduleId == nil && exceptionId == nil {
+		err := errors.New("the Mass of this intention was removed from the schedule")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "assign the intention to another Mass", http.StatusConflict, nil)
+		return
+	}
+	mass, ok := c.celebratedMass(ctx, _context, massDate, scheduleId, exceptionId, nil)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	if _, err := c.db.ExecContext(_context, `
+		UPDATE mass_intentions
+		SET status = ?, confirmed_at = COALESCE(confirmed_at, ?), confirmed_by = COALESCE(confirmed_by, ?),
+			updated_at = ?, updated_by = ?
+		WHERE id = ?`,
+		lib.INTENTION_CONFIRMED, now, lib.AdminId(ctx), now, lib.AdminId(ctx), intentionId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
+		"message": "mass intention confirmed successfully",
+		"id":      intentionId,
+		"mass":    mass,
+	})
+}
+
+func (c *EditorController) RejectMassIntention(ctx *gin.Context) {
+	intentionId, err := strconv.Atoi(ctx.Param("intentionId"))
+	if err != nil {
+		c.res.AbortInvalidIntention(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, `
+		UPDATE mass_intentions
+		SET status = ?, confirmed_at = NULL, confirmed_by = NULL, updated_at = ?, updated_by = ?
+		WHERE id = ?`,
+		lib.INTENTION_REJECTED, time.Now().UTC(), lib.AdminId(ctx), intentionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if !c.intentionExists(ctx, _context, intentionId, nil) {
+			return
+		}
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"message": "mass intention rejected successfully", "id": intentionId})
+}
+
+// DeleteMassIntention erases an intention with the requester's contact
+// details, e.g. when they ask for it.
+func (c *EditorController) DeleteMassIntention(ctx *gin.Context) {
+	intentionId, err := strconv.Atoi(ctx.Param("intentionId"))
+	if err != nil {
+		c.res.AbortInvalidIntention(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, "DELETE FROM mass_intentions WHERE id = ?", intentionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortIntentionNotFound(ctx, lib.ErrIntentionNotFound, "", nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "mass intention deleted successfully", "id": intentionId})
+}
+
+// GetLectorListHTML and GetLectorListPDF print the confirmed intentions of
+// the Masses of ?date (YYYY-MM-DD, today by default) for the lectors, one
+// Mass per page. ?locationId= keeps a single church or chapel.
+func (c *EditorController) GetLectorListHTML(ctx *gin.Context) {
+	c.writeLectorList(ctx, "html")
+}
+
+func (c *EditorController) GetLectorListPDF(ctx *gin.Context) {
+	c.writeLectorList(ctx, "pdf")
+}
+
+func (c *EditorController) writeLectorList(ctx *gin.Context, format string) {
+	loc := lib.ParishLocation()
+	now := time.Now().In(loc)
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if v := ctx.Query("date"); v != "" {
+		t, err := time.ParseInLocation(time.DateOnly, v, loc)
+		if err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, "invalid date (use YYYY-MM-DD)", nil)
+			return
+		}
+		date = t
+	}
+	locationId := 0
+	if v := ctx.Query("locationId"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.res.AbortInvalidMassLocation(ctx, err, err.Error(), nil)
+			return
+		}
+		locationId = id
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	lectorList, err := lib.GetLectorList(_context, c.db, date, locationId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	c.res.WriteLectorList(ctx, lectorList, format)
+}
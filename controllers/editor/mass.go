@@ -0,0 +1,865 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+type massLocationBody struct {
+	Name    string  `json:"name" binding:"required"`
+	Slug    string  `json:"slug"`
+	Address *string `json:"address"`
+	Order   *int    `json:"order"`
+}
+
+func (b *massLocationBody) validate() error {
+	b.Name = strings.TrimSpace(b.Name)
+	b.Slug = strings.TrimSpace(b.Slug)
+	if b.Name == "" || utf8.RuneCountInString(b.Name) > 128 {
+		return errors.New("name must be 1 to 128 characters")
+	}
+	if b.Slug != "" && (sectionSlug(b.Slug) != b.Slug || len(b.Slug) > 64) {
+		return errors.New("slug may only contain lowercase letters, digits and dashes")
+	}
+	b.Address = trimOptional(b.Address)
+	if b.Address != nil && utf8.RuneCountInString(*b.Address) > 255 {
+		return errors.New("address must be at most 255 characters")
+	}
+	return nil
+}
+
+// massScheduleBody is a regular weekly Mass. Weekday counts from Sunday (0),
+// validFrom and validUntil (YYYY-MM-DD, inclusive) limit seasonal schedules.
+type massScheduleBody struct {
+	LocationId int     `json:"locationId" binding:"required"`
+	Weekday    *int    `json:"weekday" binding:"required"`
+	Time       string  `json:"time" binding:"required"`
+	Language   string  `json:"language"`
+	Label      *string `json:"label"`
+	Notes      *string `json:"notes"`
+	ValidFrom  *string `json:"validFrom"`
+	ValidUntil *string `json:"validUntil"`
+}
+
+func validDate(s *string, name string) error {
+	if s == nil {
+		return nil
+	}
+	if _, err := time.Parse(time.DateOnly, *s); err != nil {
+		return fmt.Errorf("invalid %s date (use YYYY-MM-DD)", name)
+	}
+	return nil
+}
+
+func validMassText(label, notes *string) error {
+	if label != nil && utf8.RuneCountInString(*label) > 128 {
+		return errors.New("label must be at most 128 characters")
+	}
+	if notes != nil && utf8.RuneCountInString(*notes) > 255 {
+		return errors.New("notes must be at most 255 characters")
+	}
+	return nil
+}
+
+func (b *massScheduleBody) validate() error {
+	if *b.Weekday < 0 || *b.Weekday > 6 {
+		return errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	clock, err := lib.ParseClock(strings.TrimSpace(b.Time))
+	if err != nil {
+		return err
+	}
+	b.Time = clock
+	b.Language = strings.TrimSpace(b.Language)
+	if b.Language == "" {
+		b.Language = lib.MASS_DEFAULT_LANGUAGE
+	}
+	if utf8.RuneCountInString(b.Language) > 32 {
+		return errors.New("language must be at most 32 characters")
+	}
+	b.Label, b.Notes = trimOptional(b.Label), trimOptional(b.Notes)
+	if err := validMassText(b.Label, b.Notes); err != nil {
+		return err
+	}
+	b.ValidFrom, b.ValidUntil = trimOptional(b.ValidFrom), trimOptional(b.ValidUntil)
+	if err := validDate(b.ValidFrom, "validFrom"); err != nil {
+		return err
+	}
+	if err := validDate(b.ValidUntil, "validUntil"); err != nil {
+		return err
+	}
+	if b.ValidFrom != nil && b.ValidUntil != nil && *b.ValidUntil < *b.ValidFrom {
+		return errors.New("validUntil must not be before validFrom")
+	}
+	return nil
+}
+
+// massExceptionBody changes the schedule of one date:
+//
+//	cancel  scheduleId, or every regular Mass at locationId, or the whole day
+//	change  scheduleId with a new time, locationId, language, label or notes
+//	add     a special Mass at locationId and time
+//
+// feast names the celebration behind it, e.g. "Hari Raya Natal".
+type massExceptionBody struct {
+	Date       string  `json:"date" binding:"required"`
+	Action     string  `json:"action" binding:"required"`
+	ScheduleId *int    `json:"scheduleId"`
+	LocationId *int    `json:"locationId"`
+	Time       *string `json:"time"`
+	Language   *string `json:"language"`
+	Label      *string `json:"label"`
+	Notes      *string `json:"notes"`
+	Feast      *string `json:"feast"`
+}
+
+func (b *massExceptionBody) validate() error {
+	if _, err := time.Parse(time.DateOnly, b.Date); err != nil {
+		return errors.New("invalid date (use YYYY-MM-DD)")
+	}
+	if !slices.Contains(lib.MASS_ACTIONS, b.Action) {
+		return fmt.Errorf("action must be one of %s", strings.Join(lib.MASS_ACTIONS, ", "))
+	}
+	if b.Time = trimOptional(b.Time); b.Time != nil {
+		clock, err := lib.ParseClock(*b.Time)
+		if err != nil {
+			return err
+		}
+		b.Time = &clock
+	}
+	b.Language, b.Label = trimOptional(b.Language), trimOptional(b.Label)
+	b.Notes, b.Feast = trimOptional(b.Notes), trimOptional(b.Feast)
+	if b.Language != nil && utf8.RuneCountInString(*b.Language) > 32 {
+		return errors.New("language must be at most 32 characters")
+	}
+	if err := validMassText(b.Label, b.Notes); err != nil {
+		return err
+	}
+	if b.Feast != nil && utf8.RuneCountInString(*b.Feast) > 128 {
+		return errors.New("feast must be at most 128 characters")
+	}
+
+	switch b.Action {
+	case lib.MASS_CANCEL:
+		if b.Time != nil || b.Language != nil || b.Label != nil {
+			return errors.New("a cancellation only takes scheduleId or locationId, notes and feast")
+		}
+		if b.ScheduleId != nil && b.LocationId != nil {
+			return errors.New("cancel either a scheduleId or a locationId")
+		}
+	case lib.MASS_CHANGE:
+		if b.ScheduleId == nil {
+			return errors.New("a change needs the scheduleId it changes")
+		}
+		if b.Time == nil && b.LocationId == nil && b.Language == nil && b.Label == nil && b.Notes == nil {
+			return errors.New("a change needs a new time, locationId, language, label or notes")
+		}
+	case lib.MASS_ADD:
+		if b.ScheduleId != nil {
+			return errors.New("an added Mass does not take a scheduleId")
+		}
+		if b.LocationId == nil || b.Time == nil {
+			return errors.New("an added Mass needs a locationId and a time")
+		}
+	}
+	return nil
+}
+
+// checkMassRefs makes sure the location and schedule an exception points to
+// exist, and that a changed or cancelled schedule is celebrated that day.
+func (c *EditorController) checkMassRefs(ctx *gin.Context, _context context.Context, payload *massExceptionBody) bool {
+	if payload.LocationId != nil {
+		var exists bool
+		err := c.db.QueryRowContext(_context,
+			"SELECT EXISTS(SELECT 1 FROM mass_locations WHERE id = ?)", *payload.LocationId).Scan(&exists)
+		if _context.Err() == context.DeadlineExceeded {
+			c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+			return false
+		}
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return false
+		}
+		if !exists {
+			c.res.AbortInvalidMassLocation(ctx, lib.ErrMassLocationNotFound,
+				fmt.Sprintf("location %d does not exist", *payload.LocationId), payload)
+			return false
+		}
+	}
+	if payload.ScheduleId != nil {
+		var weekday int
+		err := c.db.QueryRowContext(_context,
+			"SELECT weekday FROM mass_schedules WHERE id = ?", *payload.ScheduleId).Scan(&weekday)
+		if _context.Err() == context.DeadlineExceeded {
+			c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+			return false
+		}
+		if err == sql.ErrNoRows {
+			c.res.AbortInvalidMassSchedule(ctx, err,
+				fmt.Sprintf("schedule %d does not exist", *payload.ScheduleId), payload)
+			return false
+		}
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, payload)
+			return false
+		}
+		date, _ := time.Parse(time.DateOnly, payload.Date)
+		if int(date.Weekday()) != weekday {
+			err := fmt.Errorf("schedule %d is on %s, %s is a %s", *payload.ScheduleId,
+				lib.IndonesianWeekday(time.Weekday(weekday)), payload.Date, lib.IndonesianWeekday(date.Weekday()))
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+			return false
+		}
+	}
+	return true
+}
+
+func (c *EditorController) GetMassLocations(ctx *gin.Context) {
+	type locationResponseModel struct {
+		Id        int     `json:"id"`
+		Name      string  `json:"name"`
+		Slug      string  `json:"slug"`
+		Address   *string `json:"address"`
+		Order     int     `json:"order"`
+		Schedules int     `json:"schedules"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT l.id, l.name, l.slug, l.address, l.sort_order, COUNT(s.id)
+		FROM mass_locations l
+		LEFT JOIN mass_schedules s ON s.location_id = l.id
+		GROUP BY l.id, l.name, l.slug, l.address, l.sort_order
+		ORDER BY l.sort_order ASC, l.name ASC`)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	locations := []*locationResponseModel{}
+	for rows.Next() {
+		var location locationResponseModel
+		if err := rows.Scan(
+			&location.Id,
+			&location.Name,
+			&location.Slug,
+			&location.Address,
+			&location.Order,
+			&location.Schedules,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		locations = append(locations, &location)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, locations)
+}
+
+func (c *EditorController) massLocationSlugTaken(_context context.Context, slug string, exceptId int) (bool, error) {
+	var taken bool
+	err := c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM mass_locations WHERE slug = ? AND id <> ?)", slug, exceptId).Scan(&taken)
+	return taken, err
+}
+
+// CreateMassLocation adds a church or chapel, at the end of the list unless
+// an order is given. The slug is derived from the name unless given.
+func (c *EditorController) CreateMassLocation(ctx *gin.Context) {
+	var payload massLocationBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := payload.validate(); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+	if payload.Slug == "" {
+		payload.Slug = sectionSlug(payload.Name)
+	}
+	if payload.Slug == "" {
+		err := errors.New("missing slug")
+		c.res.AbortInvalidRequestBody(ctx, err, "the name has no letters or digits, give a slug", payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	taken, err := c.massLocationSlugTaken(_context, payload.Slug, 0)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if taken {
+		err := fmt.Errorf("slug %q is already used", payload.Slug)
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusConflict, payload)
+		return
+	}
+
+	result, err := c.db.ExecContext(_context, `
+		INSERT INTO mass_locations (name, slug, address, sort_order, created_at)
+		SELECT ?, ?, ?, COALESCE(?, MAX(sort_order) + 1, 0), ? FROM mass_locations`,
+		payload.Name, payload.Slug, payload.Address, payload.Order, time.Now().UTC())
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload, gin.H{
+		"message": "mass location created successfully",
+		"id":      id,
+		"slug":    payload.Slug,
+	})
+}
+
+// UpdateMassLocation renames or moves a location. The slug and the order are
+// kept when left out.
+func (c *EditorController) UpdateMassLocation(ctx *gin.Context) {
+	locationId, err := strconv.Atoi(ctx.Param("locationId"))
+	if err != nil {
+		c.res.AbortInvalidMassLocation(ctx, err, err.Error(), nil)
+		return
+	}
+	var payload massLocationBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := payload.validate(); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var slug string
+	err = c.db.QueryRowContext(_context, "SELECT slug FROM mass_locations WHERE id = ?", locationId).Scan(&slug)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortMassLocationNotFound(ctx, err, err.Error(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if payload.Slug == "" {
+		payload.Slug = slug
+	}
+	taken, err := c.massLocationSlugTaken(_context, payload.Slug, locationId)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if taken {
+		err := fmt.Errorf("slug %q is already used", payload.Slug)
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusConflict, payload)
+		return
+	}
+
+	if _, err := c.db.ExecContext(_context, `
+		UPDATE mass_locations SET name = ?, slug = ?, address = ?, sort_order = COALESCE(?, sort_order), updated_at = ?
+		WHERE id = ?`,
+		payload.Name, payload.Slug, payload.Address, payload.Order, time.Now().UTC(), locationId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message": "mass location updated successfully",
+		"id":      locationId,
+		"slug":    payload.Slug,
+	})
+}
+
+// DeleteMassLocation removes a location without regular Masses, its
+// exceptions go with it.
+func (c *EditorController) DeleteMassLocation(ctx *gin.Context) {
+	locationId, err := strconv.Atoi(ctx.Param("locationId"))
+	if err != nil {
+		c.res.AbortInvalidMassLocation(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var schedules int
+	err = c.db.QueryRowContext(_context, `
+		SELECT COUNT(s.id) FROM mass_locations l
+		LEFT JOIN mass_schedules s ON s.location_id = l.id
+		WHERE l.id = ?
+		GROUP BY l.id`, locationId).Scan(&schedules)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortMassLocationNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if schedules > 0 {
+		err := fmt.Errorf("location %d still has %d regular Masses", locationId, schedules)
+		c.res.AbortWithStatusJSON(ctx, err, "delete or move its schedules first", err.Error(), http.StatusConflict, nil)
+		return
+	}
+
+	if _, err := c.db.ExecContext(_context, "DELETE FROM mass_locations WHERE id = ?", locationId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "mass location deleted successfully", "id": locationId})
+}
+
+// GetMassSchedules lists the regular weekly Masses, past and future seasons
+// included. ?locationId= narrows it down.
+func (c *EditorController) GetMassSchedules(ctx *gin.Context) {
+	type scheduleResponseModel struct {
+		Id         int        `json:"id"`
+		LocationId int        `json:"locationId"`
+		Location   string     `json:"location"`
+		Weekday    int        `json:"weekday"`
+		Day        string     `json:"day"`
+		Time       string     `json:"time"`
+		Language   string     `json:"language"`
+		Label      *string    `json:"label"`
+		Notes      *string    `json:"notes"`
+		ValidFrom  *string    `json:"validFrom"`
+		ValidUntil *string    `json:"validUntil"`
+		UpdatedAt  *time.Time `json:"updatedAt"`
+		UpdatedBy  *int       `json:"updatedBy"`
+	}
+
+	query := `
+		SELECT s.id, s.location_id, l.name, s.weekday, s.start_time, s.language, s.label, s.notes,
+			s.valid_from, s.valid_until, COALESCE(s.updated_at, s.created_at), COALESCE(s.updated_by, s.created_by)
+		FROM mass_schedules s
+		JOIN mass_locations l ON l.id = s.location_id`
+	args := []any{}
+	if v := ctx.Query("locationId"); v != "" {
+		locationId, err := strconv.Atoi(v)
+		if err != nil {
+			c.res.AbortInvalidMassLocation(ctx, err, err.Error(), nil)
+			return
+		}
+		query += " WHERE s.location_id = ?"
+		args = append(args, locationId)
+	}
+	query += " ORDER BY l.sort_order ASC, s.weekday ASC, s.start_time ASC"
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(_context, query, args...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	schedules := []*scheduleResponseModel{}
+	for rows.Next() {
+		var schedule scheduleResponseModel
+		var startTime string
+		var updatedAt []uint8
+		if err := rows.Scan(
+			&schedule.Id,
+			&schedule.LocationId,
+			&schedule.Location,
+			&schedule.Weekday,
+			&startTime,
+			&schedule.Language,
+			&schedule.Label,
+			&schedule.Notes,
+			&schedule.ValidFrom,
+			&schedule.ValidUntil,
+			&updatedAt,
+			&schedule.UpdatedBy,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		schedule.Day = lib.IndonesianWeekday(time.Weekday(schedule.Weekday))
+		schedule.Time = lib.FormatJam(startTime)
+		schedule.UpdatedAt = lib.Base64ToTime(updatedAt)
+		schedules = append(schedules, &schedule)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, schedules)
+}
+
+func (c *EditorController) massLocationExists(ctx *gin.Context, _context context.Context, locationId int, reqData any) bool {
+	var exists bool
+	err := c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM mass_locations WHERE id = ?)", locationId).Scan(&exists)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), reqData)
+		return false
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, reqData)
+		return false
+	}
+	if !exists {
+		c.res.AbortInvalidMassLocation(ctx, lib.ErrMassLocationNotFound,
+			fmt.Sprintf("location %d does not exist", locationId), reqData)
+		return false
+	}
+	return true
+}
+
+func (c *EditorController) CreateMassSchedule(ctx *gin.Context) {
+	var payload massScheduleBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := payload.validate(); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if !c.massLocationExists(ctx, _context, payload.LocationId, payload) {
+		return
+	}
+	result, err := c.db.ExecContext(_context, `
+		INSERT INTO mass_schedules
+		(location_id, weekday, start_time, language, label, notes, valid_from, valid_until, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		payload.LocationId, *payload.Weekday, payload.Time, payload.Language, payload.Label, payload.Notes,
+		payload.ValidFrom, payload.ValidUntil, time.Now().UTC(), lib.AdminId(ctx))
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload, gin.H{"message": "mass schedule created successfully", "id": id})
+}
+
+// UpdateMassSchedule replaces a regular Mass. Exceptions made for it stay,
+// moving it to another weekday leaves them without effect.
+func (c *EditorController) UpdateMassSchedule(ctx *gin.Context) {
+	scheduleId, err := strconv.Atoi(ctx.Param("scheduleId"))
+	if err != nil {
+		c.res.AbortInvalidMassSchedule(ctx, err, err.Error(), nil)
+		return
+	}
+	var payload massScheduleBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := payload.validate(); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if !c.massLocationExists(ctx, _context, payload.LocationId, payload) {
+		return
+	}
+	var exists bool
+	if err := c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM mass_schedules WHERE id = ?)", scheduleId).Scan(&exists); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if !exists {
+		c.res.AbortMassScheduleNotFound(ctx, lib.ErrMassScheduleNotFound, "", payload)
+		return
+	}
+
+	if _, err := c.db.ExecContext(_context, `
+		UPDATE mass_schedules
+		SET location_id = ?, weekday = ?, start_time = ?, language = ?, label = ?, notes = ?,
+			valid_from = ?, valid_until = ?, updated_at = ?, updated_by = ?
+		WHERE id = ?`,
+		payload.LocationId, *payload.Weekday, payload.Time, payload.Language, payload.Label, payload.Notes,
+		payload.ValidFrom, payload.ValidUntil, time.Now().UTC(), lib.AdminId(ctx), scheduleId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "mass schedule updated successfully", "id": scheduleId})
+}
+
+// DeleteMassSchedule removes a regular Mass and its exceptions. To end a
+// schedule but keep its history, set validUntil instead.
+func (c *EditorController) DeleteMassSchedule(ctx *gin.Context) {
+	scheduleId, err := strconv.Atoi(ctx.Param("scheduleId"))
+	if err != nil {
+		c.res.AbortInvalidMassSchedule(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, "DELETE FROM mass_schedules WHERE id = ?", scheduleId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortMassScheduleNotFound(ctx, lib.ErrMassScheduleNotFound, "", nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "mass schedule deleted successfully", "id": scheduleId})
+}
+
+// GetMassExceptions lists the exceptions between ?from and ?to
+// (YYYY-MM-DD, inclusive), by default those from today on.
+func (c *EditorController) GetMassExceptions(ctx *gin.Context) {
+	from := ctx.DefaultQuery("from", time.Now().In(lib.ParishLocation()).Format(time.DateOnly))
+	to := ctx.Query("to")
+	if _, err := time.Parse(time.DateOnly, from); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid from date (use YYYY-MM-DD)", nil)
+		return
+	}
+	query := `
+		SELECT e.id, e.mass_date, e.action, e.schedule_id, e.location_id, l.name, e.start_time,
+			e.language, e.label, e.notes, e.feast
+		FROM mass_exceptions e
+		LEFT JOIN mass_schedules s ON s.id = e.schedule_id
+		LEFT JOIN mass_locations l ON l.id = COALESCE(e.location_id, s.location_id)
+		WHERE e.mass_date >= ?`
+	args := []any{from}
+	if to != "" {
+		if _, err := time.Parse(time.DateOnly, to); err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, "invalid to date (use YYYY-MM-DD)", nil)
+			return
+		}
+		query += " AND e.mass_date <= ?"
+		args = append(args, to)
+	}
+	query += " ORDER BY e.mass_date ASC, e.id ASC"
+
+	type exceptionResponseModel struct {
+		Id         int     `json:"id"`
+		Date       string  `json:"date"`
+		Action     string  `json:"action"`
+		ScheduleId *int    `json:"scheduleId"`
+		LocationId *int    `json:"locationId"`
+		Location   *string `json:"location"`
+		Time       *string `json:"time"`
+		Language   *string `json:"language"`
+		Label      *string `json:"label"`
+		Notes      *string `json:"notes"`
+		Feast      *string `json:"feast"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(_context, query, args...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	exceptions := []*exceptionResponseModel{}
+	for rows.Next() {
+		var exception exceptionResponseModel
+		if err := rows.Scan(
+			&exception.Id,
+			&exception.Date,
+			&exception.Action,
+			&exception.ScheduleId,
+			&exception.LocationId,
+			&exception.Location,
+			&exception.Time,
+			&exception.Language,
+			&exception.Label,
+			&exception.Notes,
+			&exception.Feast,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		if exception.Time != nil {
+			t := lib.FormatJam(*exception.Time)
+			exception.Time = &t
+		}
+		exceptions = append(exceptions, &exception)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, exceptions)
+}
+
+func (c *EditorController) CreateMassException(ctx *gin.Context) {
+	var payload massExceptionBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := payload.validate(); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if !c.checkMassRefs(ctx, _context, &payload) {
+		return
+	}
+	result, err := c.db.ExecContext(_context, `
+		INSERT INTO mass_exceptions
+		(mass_date, action, schedule_id, location_id, start_time, language, label, notes, feast, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		payload.Date, payload.Action, payload.ScheduleId, payload.LocationId, payload.Time,
+		payload.Language, payload.Label, payload.Notes, payload.Feast, time.Now().UTC(), lib.AdminId(ctx))
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload, gin.H{"message": "mass exception created successfully", "id": id})
+}
+
+func (c *EditorController) UpdateMassException(ctx *gin.Context) {
+	exceptionId, err := strconv.Atoi(ctx.Param("exceptionId"))
+	if err != nil {
+		c.res.AbortInvalidMassException(ctx, err, err.Error(), nil)
+		return
+	}
+	var payload massExceptionBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := payload.validate(); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var exists bool
+	err = c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM mass_exceptions WHERE id = ?)", exceptionId).Scan(&exists)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if !exists {
+		c.res.AbortMassExceptionNotFound(ctx, lib.ErrMassExceptionNotFound, "", payload)
+		return
+	}
+	if !c.checkMassRefs(ctx, _context, &payload) {
+		return
+	}
+
+	if _, err := c.db.ExecContext(_context, `
+		UPDATE mass_exceptions
+		SET mass_date = ?, action = ?, schedule_id = ?, location_id = ?, start_time = ?, language = ?,
+			label = ?, notes = ?, feast = ?, updated_at = ?, updated_by = ?
+		WHERE id = ?`,
+		payload.Date, payload.Action, payload.ScheduleId, payload.LocationId, payload.Time, payload.Language,
+		payload.Label, payload.Notes, payload.Feast, time.Now().UTC(), lib.AdminId(ctx), exceptionId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"message": "mass exception updated successfully", "id": exceptionId})
+}
+
+func (c *EditorController) DeleteMassException(ctx *gin.Context) {
+	exceptionId, err := strconv.Atoi(ctx.Param("exceptionId"))
+	if err != nil {
+		c.res.AbortInvalidMassException(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, "DELETE FROM mass_exceptions WHERE id = ?", exceptionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortMassExceptionNotFound(ctx, lib.ErrMassExceptionNotFound, "", nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "mass exception deleted successfully", "id": exceptionId})
+}
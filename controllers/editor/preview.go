@@ -0,0 +1,128 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+const DEFAULT_PREVIEW_HOURS = 24
+const MAX_PREVIEW_HOURS = 24 * 7
+
+func parsePreviewHours(ctx *gin.Context) (int, error) {
+	hours, err := strconv.Atoi(ctx.DefaultQuery("hours", strconv.Itoa(DEFAULT_PREVIEW_HOURS)))
+	if err != nil {
+		return 0, err
+	}
+	if hours < 1 || hours > MAX_PREVIEW_HOURS {
+		return 0, errors.New("hours must be between 1 and 168")
+	}
+	return hours, nil
+}
+
+func (c *EditorController) CreateArticlePreview(ctx *gin.Context) {
+	articleId := ctx.Param("articleId")
+	parsedArticleId, err := strconv.Atoi(articleId)
+	if err != nil {
+		c.res.AbortInvalidArticle(ctx, err, err.Error(), nil)
+		return
+	}
+	hours, err := parsePreviewHours(ctx)
+	if err != nil {
+		c.res.AbortWithStatusJSON(ctx, err, "invalid hours", err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var slug *string
+	var year, editionId int
+	err = c.db.QueryRowContext(_context, `
+		SELECT a.slug, a.edition_id, e.edition_year
+		FROM articles a
+		JOIN editions e ON e.id = a.edition_id
+		WHERE a.id = ?`, parsedArticleId).Scan(&slug, &editionId, &year)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortArticleNotFound(ctx, err, "", nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	token, expiresAt, err := lib.NewPreviewToken(lib.PreviewArticle, parsedArticleId,
+		time.Duration(hours)*time.Hour)
+	if err != nil {
+		c.res.AbortWithStatusJSON(ctx, err, "failed to sign preview token",
+			err.Error(), http.StatusInternalServerError, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, nil, gin.H{
+		"token":     token,
+		"expiresAt": expiresAt,
+		"articleId": parsedArticleId,
+		"editionId": editionId,
+		"year":      year,
+		"slug":      slug,
+	})
+}
+
+func (c *EditorController) CreateEditionPreview(ctx *gin.Context) {
+	editionId := ctx.Param("editionId")
+	parsedEditionId, err := strconv.Atoi(editionId)
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return
+	}
+	hours, err := parsePreviewHours(ctx)
+	if err != nil {
+		c.res.AbortWithStatusJSON(ctx, err, "invalid hours", err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var exists bool
+	err = c.db.QueryRowContext(_context,
+		"SELECT EXISTS(SELECT 1 FROM editions WHERE id = ?)", parsedEditionId).Scan(&exists)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if !exists {
+		c.res.AbortEditionNotFound(ctx, lib.ErrEditionNotFound, "", nil)
+		return
+	}
+
+	token, expiresAt, err := lib.NewPreviewToken(lib.PreviewEdition, parsedEditionId,
+		time.Duration(hours)*time.Hour)
+	if err != nil {
+		c.res.AbortWithStatusJSON(ctx, err, "failed to sign preview token",
+			err.Error(), http.StatusInternalServerError, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, nil, gin.H{
+		"token":     token,
+		"expiresAt": expiresAt,
+		"editionId": parsedEditionId,
+	})
+}
@@ -0,0 +1,88 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+)
+
+const readingStatsBatch = 100
+
+// BackfillReadingStats computes the word count, reading time and excerpt of
+// articles saved before SaveDraft kept them, recognised by a NULL excerpt.
+// It runs once at startup; run it in its own goroutine.
+func (c *EditorController) BackfillReadingStats() {
+	updated, lastId := 0, 0
+	for {
+		n, next, err := c.backfillReadingStats(lastId)
+		if err != nil {
+			log.Println("reading stats:", err.Error())
+			break
+		}
+		if next == lastId {
+			break
+		}
+		updated += n
+		lastId = next
+	}
+	if updated > 0 {
+		log.Printf("reading stats: filled in %d article(s)\n", updated)
+		// the table of contents shows the excerpt and reading time
+		lib.InvalidateEditionToc()
+	}
+}
+
+// backfillReadingStats fills in one batch of articles after lastId, returning
+// how many were updated and the last id seen.
+func (c *EditorController) backfillReadingStats(lastId int) (int, int, error) {
+	_context, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT id, content_json FROM articles
+		WHERE excerpt IS NULL AND id > ?
+		ORDER BY id ASC LIMIT ?`, lastId, readingStatsBatch)
+	if err != nil {
+		return 0, lastId, err
+	}
+	type article struct {
+		id          int
+		contentJSON sql.NullString
+	}
+	articles := []article{}
+	for rows.Next() {
+		var a article
+		if err := rows.Scan(&a.id, &a.contentJSON); err != nil {
+			rows.Close()
+			return 0, lastId, err
+		}
+		articles = append(articles, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, lastId, err
+	}
+
+	updated := 0
+	for _, a := range articles {
+		lastId = a.id
+		contents, err := lib.ParseContents(a.contentJSON.String)
+		if err != nil {
+			// left at zero, the next SaveDraft corrects it
+			log.Printf("reading stats: article %d has invalid content_json: %s\n", a.id, err.Error())
+			contents = &lib.ArticleContents{}
+		}
+		wordCount := contents.WordCount()
+		if _, err := c.db.ExecContext(_context, `
+			UPDATE articles SET word_count = ?, reading_time = ?, excerpt = ?
+			WHERE id = ? AND excerpt IS NULL`,
+			wordCount, lib.ReadingTime(wordCount), contents.Excerpt(lib.EXCERPT_LENGTH), a.id); err != nil {
+			return updated, lastId, err
+		}
+		updated++
+	}
+	return updated, lastId, nil
+}
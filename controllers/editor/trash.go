@@ -0,0 +1,630 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// trashPurge is the outcome of removing trashed rows for good. The rows are
+// gone once it is returned, a storage error only leaves orphaned objects.
+type trashPurge struct {
+	Rows       int
+	Objects    int
+	StorageErr error
+}
+
+func (p trashPurge) storageCleanup() string {
+	if p.StorageErr != nil {
+		return p.StorageErr.Error()
+	}
+	return "ok"
+}
+
+func purgeAt(deletedAt *time.Time, retention time.Duration) *time.Time {
+	if deletedAt == nil || retention <= 0 {
+		return nil
+	}
+	at := deletedAt.Add(retention)
+	return &at
+}
+
+func (c *EditorController) DeleteArticle(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("articleId"))
+	if err != nil {
+		c.res.AbortInvalidArticle(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context,
+		"UPDATE articles SET deleted_at = ?, deleted_by = ? WHERE id = ? AND deleted_at IS NULL",
+		time.Now().UTC(), lib.AdminId(ctx), id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortArticleNotFound(ctx, lib.ErrArticleNotFound, "", nil)
+		return
+	}
+
+	lib.InvalidateEditionToc()
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "article moved to trash", "id": id})
+}
+
+func (c *EditorController) DeleteBerita(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context,
+		"UPDATE announcements SET deleted_at = ?, deleted_by = ? WHERE id = ? AND deleted_at IS NULL",
+		time.Now().UTC(), lib.AdminId(ctx), id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		err := errors.New("berita not found")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusNotFound, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "berita moved to trash", "id": id})
+}
+
+func (c *EditorController) GetTrashedArticles(ctx *gin.Context) {
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		Sorts:        map[string]string{"deletedAt": "a.deleted_at", "title": "a.title"},
+		DefaultSort:  "-deletedAt",
+		Filters:      map[string]string{"editionId": "a.edition_id"},
+		Search:       []string{"a.title"},
+		IdColumn:     "a.id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type trashedArticle struct {
+		Id           int        `json:"id"`
+		Title        string     `json:"title"`
+		EditionId    int        `json:"editionId"`
+		EditionTitle string     `json:"editionTitle"`
+		Year         int        `json:"year"`
+		PublishedAt  *time.Time `json:"publishedAt"`
+		DeletedAt    *time.Time `json:"deletedAt"`
+		DeletedBy    *int       `json:"deletedBy"`
+		PurgeAt      *time.Time `json:"purgeAt"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context,
+		"SELECT COUNT(a.id) FROM articles a WHERE a.deleted_at IS NOT NULL"+filter, filterArgs...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
+	rows, err := c.db.QueryContext(_context, `
+		SELECT a.id, a.title, e.id, e.title, e.edition_year, a.published_date, a.deleted_at, a.deleted_by,
+			`+list.SortColumn()+`
+		FROM articles a
+		JOIN editions e ON e.id = a.edition_id
+		WHERE a.deleted_at IS NOT NULL`+where+`
+		ORDER BY `+list.OrderBy()+` `+paginate,
+		append(args, paginateArgs...)...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	retention := lib.TrashRetention()
+	articles := []*trashedArticle{}
+	for rows.Next() {
+		var article trashedArticle
+		var publishedAt, deletedAt []uint8
+		var sortValue sql.NullString
+		if err := rows.Scan(
+			&article.Id,
+			&article.Title,
+			&article.EditionId,
+			&article.EditionTitle,
+			&article.Year,
+			&publishedAt,
+			&deletedAt,
+			&article.DeletedBy,
+			&sortValue,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		article.PublishedAt = lib.Base64ToTime(publishedAt)
+		article.DeletedAt = lib.Base64ToTime(deletedAt)
+		article.PurgeAt = purgeAt(article.DeletedAt, retention)
+		list.Scanned(sortValue, article.Id)
+		articles = append(articles, &article)
+	}
+
+	c.res.SuccessWithMeta(ctx, nil, articles[:list.Trim()], list.Meta(total))
+}
+
+func (c *EditorController) GetTrashedBerita(ctx *gin.Context) {
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		Sorts:        map[string]string{"deletedAt": "deleted_at", "title": "title"},
+		DefaultSort:  "-deletedAt",
+		Filters:      map[string]string{"section": "section"},
+		Search:       []string{"title"},
+		IdColumn:     "id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type trashedBerita struct {
+		Id        int        `json:"id"`
+		Title     string     `json:"title"`
+		Section   string     `json:"section"`
+		ThumbImg  string     `json:"thumbImg"`
+		DeletedAt *time.Time `json:"deletedAt"`
+		DeletedBy *int       `json:"deletedBy"`
+		PurgeAt   *time.Time `json:"purgeAt"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context,
+		"SELECT COUNT(id) FROM announcements WHERE deleted_at IS NOT NULL"+filter, filterArgs...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
+	rows, err := c.db.QueryContext(_context, `
+		SELECT id, title, section, thumb_img, deleted_at, deleted_by, `+list.SortColumn()+`
+		FROM announcements
+		WHERE deleted_at IS NOT NULL`+where+`
+		ORDER BY `+list.OrderBy()+` `+paginate,
+		append(args, paginateArgs...)...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	retention := lib.TrashRetention()
+	news := []*trashedBerita{}
+	for rows.Next() {
+		var berita trashedBerita
+		var deletedAt []uint8
+		var sortValue sql.NullString
+		if err := rows.Scan(
+			&berita.Id,
+			&berita.Title,
+			&berita.Section,
+			&berita.ThumbImg,
+			&deletedAt,
+			&berita.DeletedBy,
+			&sortValue,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		berita.DeletedAt = lib.Base64ToTime(deletedAt)
+		berita.PurgeAt = purgeAt(berita.DeletedAt, retention)
+		list.Scanned(sortValue, berita.Id)
+		news = append(news, &berita)
+	}
+
+	c.res.SuccessWithMeta(ctx, nil, news[:list.Trim()], list.Meta(total))
+}
+
+func (c *EditorController) RestoreArticle(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("articleId"))
+	if err != nil {
+		c.res.AbortInvalidArticle(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context,
+		"UPDATE articles SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortArticleNotFound(ctx, lib.ErrArticleNotFound, "article is not in the trash", nil)
+		return
+	}
+
+	lib.InvalidateEditionToc()
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"message": "article restored successfully", "id": id})
+}
+
+func (c *EditorController) RestoreBerita(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, `
+		UPDATE announcements SET deleted_at = NULL, deleted_by = NULL, updated_at = ?, updated_by = ?
+		WHERE id = ? AND deleted_at IS NOT NULL`,
+		time.Now().UTC(), lib.AdminId(ctx), id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		err := errors.New("berita not found")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "berita is not in the trash", http.StatusNotFound, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"message": "berita restored successfully", "id": id})
+}
+
+// PurgeArticle deletes a trashed article for good, together with its images.
+// Articles have to be moved to the trash first.
+func (c *EditorController) PurgeArticle(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("articleId"))
+	if err != nil {
+		c.res.AbortInvalidArticle(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	purged, err := c.purgeArticles(_context, "a.id = ?", id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if purged.Rows == 0 {
+		c.abortNotInTrash(ctx, "articles", id)
+		return
+	}
+	if purged.StorageErr != nil {
+		log.Println("purge article: storage cleanup failed:", purged.StorageErr.Error())
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{
+		"message":        "article deleted permanently",
+		"id":             id,
+		"objectsDeleted": purged.Objects,
+		"storageCleanup": purged.storageCleanup(),
+	})
+}
+
+// PurgeBerita deletes a trashed berita for good, together with its images.
+func (c *EditorController) PurgeBerita(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	purged, err := c.purgeBerita(_context, "id = ?", id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if purged.Rows == 0 {
+		c.abortNotInTrash(ctx, "announcements", id)
+		return
+	}
+	if purged.StorageErr != nil {
+		log.Println("purge berita: storage cleanup failed:", purged.StorageErr.Error())
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{
+		"message":        "berita deleted permanently",
+		"id":             id,
+		"objectsDeleted": purged.Objects,
+		"storageCleanup": purged.storageCleanup(),
+	})
+}
+
+// abortNotInTrash tells a missing row (404) apart from one that still has to
+// be moved to the trash before it can be purged (409).
+func (c *EditorController) abortNotInTrash(ctx *gin.Context, table string, id int) {
+	kind := "berita"
+	if table == "articles" {
+		kind = "article"
+	}
+
+	var exists bool
+	err := c.db.QueryRowContext(ctx.Request.Context(),
+		"SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id = ?)", id).Scan(&exists)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if !exists {
+		err := fmt.Errorf("%s not found", kind)
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusNotFound, nil)
+		return
+	}
+	err = fmt.Errorf("%s is not in the trash", kind)
+	c.res.AbortWithStatusJSON(ctx, err, err.Error(),
+		"move it to the trash before deleting it permanently", http.StatusConflict, nil)
+}
+
+// PurgeTrash empties the trash of everything deleted more than ?olderThanDays
+// ago, by default the retention period. olderThanDays=0 empties it entirely.
+func (c *EditorController) PurgeTrash(ctx *gin.Context) {
+	age := lib.TrashRetention()
+	if v, ok := ctx.GetQuery("olderThanDays"); ok {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			c.res.AbortInvalidRequestBody(ctx, lib.ErrInvalidBody, "olderThanDays must be a non-negative number", nil)
+			return
+		}
+		age = time.Duration(days) * 24 * time.Hour
+	} else if age <= 0 {
+		c.res.AbortInvalidRequestBody(ctx, lib.ErrInvalidBody,
+			"automatic purging is off, olderThanDays is required", nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 2*time.Minute)
+	defer cancel()
+
+	articles, news, err := c.purgeExpiredTrash(_context, age)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	storageCleanup := articles.storageCleanup()
+	if news.StorageErr != nil {
+		storageCleanup = news.storageCleanup()
+	}
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{
+		"message":        "trash purged successfully",
+		"olderThan":      time.Now().UTC().Add(-age),
+		"articles":       articles.Rows,
+		"berita":         news.Rows,
+		"objectsDeleted": articles.Objects + news.Objects,
+		"storageCleanup": storageCleanup,
+	})
+}
+
+// RunTrashRetention purges expired trash at startup and once a day after
+// that. It blocks, run it in its own goroutine.
+func (c *EditorController) RunTrashRetention(retention time.Duration) {
+	if retention <= 0 {
+		log.Println("trash retention: automatic purging is off")
+		return
+	}
+
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for {
+		_context, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		articles, news, err := c.purgeExpiredTrash(_context, retention)
+		cancel()
+		if err != nil {
+			log.Println("trash retention:", err.Error())
+		} else if articles.Rows+news.Rows > 0 {
+			log.Printf("trash retention: purged %d article(s), %d berita, %d object(s)\n",
+				articles.Rows, news.Rows, articles.Objects+news.Objects)
+		}
+		for _, p := range []trashPurge{articles, news} {
+			if p.StorageErr != nil {
+				log.Println("trash retention: storage cleanup failed:", p.StorageErr.Error())
+			}
+		}
+		<-ticker.C
+	}
+}
+
+func (c *EditorController) purgeExpiredTrash(_context context.Context, age time.Duration) (trashPurge, trashPurge, error) {
+	cutoff := time.Now().UTC().Add(-age)
+	articles, err := c.purgeArticles(_context, "a.deleted_at < ?", cutoff)
+	if err != nil {
+		return articles, trashPurge{}, err
+	}
+	news, err := c.purgeBerita(_context, "deleted_at < ?", cutoff)
+	return articles, news, err
+}
+
+// purgeArticles deletes the trashed articles matching cond, then their
+// objects in the bucket. Comments, contributors and ad campaigns cascade.
+func (c *EditorController) purgeArticles(_context context.Context, cond string, args ...any) (trashPurge, error) {
+	var purged trashPurge
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		return purged, err
+	}
+	defer tx.Rollback()
+
+	// locked so a concurrent restore cannot lose its images
+	rows, err := tx.QueryContext(_context, `
+		SELECT a.id, e.edition_year, a.cover_img, a.thumb_img
+		FROM articles a
+		JOIN editions e ON e.id = a.edition_id
+		WHERE a.deleted_at IS NOT NULL AND `+cond+`
+		FOR UPDATE`, args...)
+	if err != nil {
+		return purged, err
+	}
+	ids := []any{}
+	objects := []string{}
+	prefixes := []string{}
+	for rows.Next() {
+		var id, year int
+		var coverImg, thumbImg sql.NullString
+		if err := rows.Scan(&id, &year, &coverImg, &thumbImg); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		ids = append(ids, id)
+		prefixes = append(prefixes, fmt.Sprintf("zaitun/articles/%d/%d/", year, id))
+		objects = append(objects,
+			services.ObjectPathFromURL(coverImg.String),
+			services.ObjectPathFromURL(thumbImg.String))
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return purged, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	if _, err := tx.ExecContext(_context,
+		"DELETE FROM articles WHERE id IN ("+placeholders+")", ids...); err != nil {
+		return purged, err
+	}
+	if err := tx.Commit(); err != nil {
+		return purged, err
+	}
+	purged.Rows = len(ids)
+	lib.InvalidateEditionToc()
+
+	purged.Objects, purged.StorageErr = services.DeleteObjects(_context, objects, prefixes)
+	return purged, nil
+}
+
+// purgeBerita deletes the trashed berita matching cond, then their objects in
+// the bucket. Revisions cascade.
+func (c *EditorController) purgeBerita(_context context.Context, cond string, args ...any) (trashPurge, error) {
+	var purged trashPurge
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		return purged, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(_context, `
+		SELECT id, thumb_img FROM announcements
+		WHERE deleted_at IS NOT NULL AND `+cond+`
+		FOR UPDATE`, args...)
+	if err != nil {
+		return purged, err
+	}
+	ids := []any{}
+	objects := []string{}
+	prefixes := []string{}
+	for rows.Next() {
+		var id int
+		var thumbImg sql.NullString
+		if err := rows.Scan(&id, &thumbImg); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		ids = append(ids, id)
+		prefixes = append(prefixes, fmt.Sprintf("berita/%d/", id))
+		objects = append(objects, services.ObjectPathFromURL(thumbImg.String))
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return purged, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	if _, err := tx.ExecContext(_context,
+		"DELETE FROM announcements WHERE id IN ("+placeholders+")", ids...); err != nil {
+		return purged, err
+	}
+	if err := tx.Commit(); err != nil {
+		return purged, err
+	}
+	purged.Rows = len(ids)
+
+	purged.Objects, purged.StorageErr = services.DeleteObjects(_context, objects, prefixes)
+	return purged, nil
+}
@@ -2,23 +2,109 @@ package editor
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
 	"github.com/gin-gonic/gin"
 )
 
+const UNKNOWN_WRITER = 1
+
+var SOCIAL_LINK_KEYS []string = []string{
+	"instagram",
+	"facebook",
+	"twitter",
+	"youtube",
+	"tiktok",
+	"website",
+}
+
+type WriterResponseModel struct {
+	Id          int               `json:"id"`
+	WriterName  string            `json:"writer_name"`
+	Bio         *string           `json:"bio"`
+	PhotoImg    *string           `json:"photoImg"`
+	Ministry    *string           `json:"ministry"`
+	Lingkungan  *string           `json:"lingkungan"`
+	SocialLinks map[string]string `json:"socialLinks"`
+	UpdatedAt   *time.Time        `json:"updatedAt"`
+}
+
+const writerColumns = `id, writer_name, bio, photo_img, ministry, lingkungan, social_links, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanWriter reads writerColumns, followed by any extra selected columns.
+func scanWriter(row rowScanner, extra ...any) (*WriterResponseModel, error) {
+	var w WriterResponseModel
+	var socialLinks []byte
+	var updatedAt []uint8
+	if err := row.Scan(append([]any{
+		&w.Id,
+		&w.WriterName,
+		&w.Bio,
+		&w.PhotoImg,
+		&w.Ministry,
+		&w.Lingkungan,
+		&socialLinks,
+		&updatedAt,
+	}, extra...)...); err != nil {
+		return nil, err
+	}
+	w.SocialLinks = map[string]string{}
+	if len(socialLinks) > 0 {
+		if err := json.Unmarshal(socialLinks, &w.SocialLinks); err != nil {
+			return nil, err
+		}
+	}
+	w.UpdatedAt = lib.Base64ToTime(updatedAt)
+	return &w, nil
+}
+
 func (c *EditorController) GetAllWriters(ctx *gin.Context) {
-	type Writer struct {
-		Id         int    `json:"id"`
-		WriterName string `json:"writer_name"`
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 50,
+		MaxLimit:     200,
+		Sorts:        map[string]string{"id": "id", "name": "writer_name"},
+		DefaultSort:  "id",
+		Filters:      map[string]string{"ministry": "ministry", "lingkungan": "lingkungan"},
+		Search:       []string{"writer_name"},
+		IdColumn:     "id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
 	}
 
 	_context, cancel := context.WithTimeout(ctx.Request.Context(), time.Second*10)
 	defer cancel()
 
-	rows, err := c.db.QueryContext(_context, `SELECT * FROM writers ORDER BY id`)
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context, `SELECT COUNT(id) FROM writers WHERE 1 = 1`+filter, filterArgs...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
+	rows, err := c.db.QueryContext(_context, `SELECT `+writerColumns+`, `+list.SortColumn()+` FROM writers
+		WHERE 1 = 1`+where+` ORDER BY `+list.OrderBy()+` `+paginate, append(args, paginateArgs...)...)
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, err, nil)
 		return
@@ -29,21 +115,51 @@ func (c *EditorController) GetAllWriters(ctx *gin.Context) {
 	}
 	defer rows.Close()
 
-	writers := []*Writer{}
+	writers := []*WriterResponseModel{}
 	for rows.Next() {
-		var w Writer
-		if err := rows.Scan(&w.Id, &w.WriterName); err != nil {
+		var sortValue sql.NullString
+		w, err := scanWriter(rows, &sortValue)
+		if err != nil {
 			c.res.AbortDatabaseError(ctx, err, nil)
 			return
 		}
-		writers = append(writers, &w)
+		list.Scanned(sortValue, w.Id)
+		writers = append(writers, w)
 	}
 	if err := rows.Err(); err != nil {
 		c.res.AbortDatabaseError(ctx, err, nil)
 		return
 	}
 
-	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"writers": writers})
+	c.res.SuccessWithMeta(ctx, nil, gin.H{"writers": writers[:list.Trim()]}, list.Meta(total))
+}
+
+func (c *EditorController) GetWriterById(ctx *gin.Context) {
+	writerId, err := strconv.Atoi(ctx.Param("writerId"))
+	if err != nil {
+		c.res.AbortInvalidWriter(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), time.Second*10)
+	defer cancel()
+
+	w, err := scanWriter(c.db.QueryRowContext(_context,
+		`SELECT `+writerColumns+` FROM writers WHERE id = ?`, writerId))
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortWriterNotFound(ctx, err, "", nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, w)
 }
 
 func (c *EditorController) CreateWriter(ctx *gin.Context) {
@@ -77,3 +193,239 @@ func (c *EditorController) CreateWriter(ctx *gin.Context) {
 
 	c.res.SuccessWithStatusJSON(ctx, http.StatusCreated, payload, gin.H{"message": "writer created successfully"})
 }
+
+func (c *EditorController) UpdateWriter(ctx *gin.Context) {
+	writerId, err := strconv.Atoi(ctx.Param("writerId"))
+	if err != nil {
+		c.res.AbortInvalidWriter(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		Writer      string            `json:"writer" binding:"required"`
+		Bio         *string           `json:"bio"`
+		Ministry    *string           `json:"ministry"`
+		Lingkungan  *string           `json:"lingkungan"`
+		SocialLinks map[string]string `json:"socialLinks"`
+	}
+
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if strings.TrimSpace(payload.Writer) == "" {
+		err := errors.New("missing writer name")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+	for key, link := range payload.SocialLinks {
+		if !slices.Contains(SOCIAL_LINK_KEYS, key) {
+			err := fmt.Errorf("unsupported social link %q", key)
+			c.res.AbortInvalidRequestBody(ctx, err,
+				fmt.Sprintf("socialLinks keys must be one of %s", strings.Join(SOCIAL_LINK_KEYS, ", ")), payload)
+			return
+		}
+		if strings.TrimSpace(link) == "" {
+			delete(payload.SocialLinks, key)
+		}
+	}
+	socialLinks, err := json.Marshal(payload.SocialLinks)
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), time.Second*10)
+	defer cancel()
+
+	now := time.Now().UTC()
+	result, err := c.db.ExecContext(_context, `
+		UPDATE writers
+		SET writer_name = ?, bio = ?, ministry = ?, lingkungan = ?, social_links = ?, updated_at = ?
+		WHERE id = ?`,
+		strings.TrimSpace(payload.Writer),
+		payload.Bio,
+		payload.Ministry,
+		payload.Lingkungan,
+		string(socialLinks),
+		now,
+		writerId,
+	)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortWriterNotFound(ctx, lib.ErrWriterNotFound, "", payload)
+		return
+	}
+	// the table of contents shows writer names
+	lib.InvalidateEditionToc()
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message":   "writer updated successfully",
+		"id":        writerId,
+		"updatedAt": now,
+	})
+}
+
+func (c *EditorController) DeleteWriter(ctx *gin.Context) {
+	writerId, err := strconv.Atoi(ctx.Param("writerId"))
+	if err != nil {
+		c.res.AbortInvalidWriter(ctx, err, err.Error(), nil)
+		return
+	}
+	if writerId == UNKNOWN_WRITER {
+		err := errors.New("cannot delete default writer")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusForbidden, nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), time.Second*10)
+	defer cancel()
+
+	var articleCount int
+	err = c.db.QueryRowContext(_context, `
+		SELECT (SELECT COUNT(id) FROM articles WHERE writer_id = ?)
+		+ (SELECT COUNT(DISTINCT article_id) FROM article_contributors WHERE writer_id = ?)`,
+		writerId, writerId).Scan(&articleCount)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if articleCount > 0 {
+		err := errors.New("writer still has articles")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(),
+			fmt.Sprintf("%d article(s) reference this writer, merge it into another writer instead", articleCount),
+			http.StatusConflict, nil)
+		return
+	}
+
+	result, err := c.db.ExecContext(_context, "DELETE FROM writers WHERE id = ?", writerId)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortWriterNotFound(ctx, lib.ErrWriterNotFound, "", nil)
+		return
+	}
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, gin.H{"message": "writer deleted successfully"})
+}
+
+// MergeWriters folds duplicate writer entries into :writerId. Their articles
+// and contributor credits are reassigned and the duplicates removed in one
+// transaction.
+func (c *EditorController) MergeWriters(ctx *gin.Context) {
+	writerId, err := strconv.Atoi(ctx.Param("writerId"))
+	if err != nil {
+		c.res.AbortInvalidWriter(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type reqBody struct {
+		DuplicateIds []int `json:"duplicateIds" binding:"required"`
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if len(payload.DuplicateIds) == 0 {
+		err := errors.New("no duplicate writers given")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+	for _, id := range payload.DuplicateIds {
+		if id == writerId || id == UNKNOWN_WRITER || id < 1 {
+			err := fmt.Errorf("cannot merge writer %d", id)
+			c.res.AbortInvalidWriter(ctx, err, err.Error(), payload)
+			return
+		}
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), time.Second*10)
+	defer cancel()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(payload.DuplicateIds)), ",")
+	ids := []any{}
+	for _, id := range payload.DuplicateIds {
+		ids = append(ids, id)
+	}
+
+	var found int
+	err = c.db.QueryRowContext(_context,
+		"SELECT COUNT(id) FROM writers WHERE id IN (?,"+placeholders+")",
+		append([]any{writerId}, ids...)...).Scan(&found)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if found != len(slices.Compact(slices.Sorted(slices.Values(payload.DuplicateIds))))+1 {
+		c.res.AbortWriterNotFound(ctx, lib.ErrWriterNotFound, "one or more writers do not exist", payload)
+		return
+	}
+
+	tx, err := c.db.BeginTx(_context, nil)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	defer tx.Rollback()
+
+	moved, err := tx.ExecContext(_context,
+		"UPDATE articles SET writer_id = ? WHERE writer_id IN ("+placeholders+")",
+		append([]any{writerId}, ids...)...)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	// a credit the target already holds for the same article and role is a
+	// duplicate, IGNORE leaves it behind to be deleted below
+	if _, err := tx.ExecContext(_context,
+		"UPDATE IGNORE article_contributors SET writer_id = ? WHERE writer_id IN ("+placeholders+")",
+		append([]any{writerId}, ids...)...); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if _, err := tx.ExecContext(_context,
+		"DELETE FROM article_contributors WHERE writer_id IN ("+placeholders+")", ids...); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if err := lib.DropPrimaryAuthorCredits(_context, tx, writerId); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if _, err := tx.ExecContext(_context,
+		"DELETE FROM writers WHERE id IN ("+placeholders+")", ids...); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	lib.InvalidateEditionToc()
+
+	movedArticles, _ := moved.RowsAffected()
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message":       "writers merged successfully",
+		"id":            writerId,
+		"movedArticles": movedArticles,
+	})
+}
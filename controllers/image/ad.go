@@ -0,0 +1,115 @@
+package image
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *ImageController) SaveAdCreativeImage(ctx *gin.Context) {
+	creativeId, err := strconv.Atoi(ctx.Param("creativeId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid creative id", nil)
+		return
+	}
+
+	type Request struct {
+		FileName    string `json:"fileName"`
+		ContentType string `json:"contentType"`
+	}
+	var payload Request
+	if err := ctx.BindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := ValidateImgRequests(payload.FileName, payload.ContentType); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var id int
+	err = c.db.QueryRowContext(_context, `SELECT id FROM ad_creatives WHERE id = ?`, creativeId).Scan(&id)
+	if err == sql.ErrNoRows {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "creative not found", payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	obj := fmt.Sprintf("ads/%d/%s", creativeId, payload.FileName)
+	signedUrl, err := services.GetSignedURL(_context, obj, payload.ContentType)
+	if err != nil {
+		c.res.AbortStorageError(ctx, err, payload)
+		return
+	}
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"url": signedUrl, "location": obj})
+}
+
+func (c *ImageController) UpdateAdCreativeImage(ctx *gin.Context) {
+	creativeId, err := strconv.Atoi(ctx.Param("creativeId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid creative id", nil)
+		return
+	}
+
+	type Request struct {
+		FileName string `json:"fileName"`
+	}
+	var payload Request
+	if err := ctx.BindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if strings.TrimSpace(payload.FileName) == "" {
+		c.res.AbortInvalidRequestBody(
+			ctx,
+			errors.New("empty filename"),
+			"empty filename",
+			nil,
+		)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+	now := time.Now().UTC()
+	result, err := c.db.ExecContext(_context, `
+		UPDATE ad_creatives
+		SET img = ?, updated_at = ?
+		WHERE id = ?`, payload.FileName, now, creativeId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "creative not found", payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message":   "image updated successfully",
+		"id":        creativeId,
+		"updatedAt": now,
+	})
+}
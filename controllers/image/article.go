@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/conf"
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
 	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/services"
 	"github.com/gin-gonic/gin"
 )
@@ -146,6 +147,8 @@ func (c *ImageController) UpdateArticleThumbnail(ctx *gin.Context) {
 		return
 	}
 
+	lib.InvalidateEditionToc()
+
 	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
 		"message": "thumbnail updated successfully",
 	})
@@ -390,6 +393,7 @@ func (c *ImageController) RenameArticleHeadline(ctx *gin.Context) {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
 		return
 	}
+	lib.InvalidateEditionToc()
 
 	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
 		"message":   "Filename updated successfully",
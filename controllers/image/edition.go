@@ -205,6 +205,7 @@ func (c *ImageController) RenameEditionCover(ctx *gin.Context) {
 			c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
 			return
 		}
+		lib.InvalidateEditionToc()
 
 		c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
 			"message": "Filename updated successfully",
@@ -328,6 +329,7 @@ func (c *ImageController) RenameEditionCover(ctx *gin.Context) {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
 		return
 	}
+	lib.InvalidateEditionToc()
 
 	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
 		"message":   "Filename updated successfully",
@@ -0,0 +1,119 @@
+package image
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *ImageController) SaveWriterPhoto(ctx *gin.Context) {
+	writerId, err := strconv.Atoi(ctx.Param("writerId"))
+	if err != nil {
+		c.res.AbortInvalidWriter(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type Request struct {
+		FileName    string `json:"fileName"`
+		ContentType string `json:"contentType"`
+	}
+	var payload Request
+	if err := ctx.BindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := ValidateImgRequests(payload.FileName, payload.ContentType); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), payload)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var id int
+	err = c.db.QueryRowContext(_context, `SELECT id FROM writers WHERE id = ?`, writerId).Scan(&id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortWriterNotFound(ctx, err, "", payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+
+	obj := fmt.Sprintf("zaitun/writers/%d/%s", writerId, payload.FileName)
+	signedUrl, err := services.GetSignedURL(_context, obj, payload.ContentType)
+	if err != nil {
+		c.res.AbortStorageError(ctx, err, payload)
+		return
+	}
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{"url": signedUrl, "location": obj})
+}
+
+func (c *ImageController) UpdateWriterPhoto(ctx *gin.Context) {
+	writerId, err := strconv.Atoi(ctx.Param("writerId"))
+	if err != nil {
+		c.res.AbortInvalidWriter(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type Request struct {
+		FileName string `json:"fileName"`
+	}
+	var payload Request
+	if err := ctx.BindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if strings.TrimSpace(payload.FileName) == "" {
+		c.res.AbortInvalidRequestBody(
+			ctx,
+			errors.New("empty filename"),
+			"empty filename",
+			nil,
+		)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+	now := time.Now().UTC()
+	result, err := c.db.ExecContext(_context, `
+		UPDATE writers
+		SET photo_img = ?, updated_at = ?
+		WHERE id = ?`, payload.FileName, now, writerId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), payload)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, payload)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortWriterNotFound(ctx, lib.ErrWriterNotFound, "", payload)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, payload, gin.H{
+		"message":   "photo updated successfully",
+		"id":        writerId,
+		"updatedAt": now,
+	})
+}
@@ -0,0 +1,51 @@
+package profile
+
+import (
+	"context"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+)
+
+type beritaAttachmentResponseModel struct {
+	Id          int     `json:"id"`
+	FileName    string  `json:"fileName"`
+	Url         string  `json:"url"`
+	ContentType string  `json:"contentType"`
+	Kind        string  `json:"kind"`
+	Size        *int64  `json:"size"`
+	Caption     *string `json:"caption"`
+}
+
+// beritaAttachments loads the uploaded attachments of a berita in the order
+// the editor chose. Images make up the gallery, documents are downloads.
+func (c *ProfileController) beritaAttachments(_context context.Context, beritaId int) ([]*beritaAttachmentResponseModel, error) {
+	rows, err := c.db.QueryContext(_context, `
+	SELECT id, object_path, file_name, content_type, size_bytes, caption
+	FROM announcement_attachments
+		WHERE announcement_id = ? AND uploaded_at IS NOT NULL
+		ORDER BY sort_order ASC, id ASC`, beritaId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*beritaAttachmentResponseModel{}
+	for rows.Next() {
+		var result beritaAttachmentResponseModel
+		var objectPath string
+		if err := rows.Scan(
+			&result.Id,
+			&objectPath,
+			&result.FileName,
+			&result.ContentType,
+			&result.Size,
+			&result.Caption,
+		); err != nil {
+			return nil, err
+		}
+		result.Url = lib.ObjectLocation(objectPath)
+		result.Kind = lib.AttachmentKind(result.ContentType)
+		attachments = append(attachments, &result)
+	}
+	return attachments, rows.Err()
+}
@@ -7,21 +7,174 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
 	"github.com/gin-gonic/gin"
 )
 
+const OVERVIEW_DEFAULT_LIMIT = 4
+const OVERVIEW_MAX_LIMIT = 20
+
+// GetOverviewBerita is the homepage view of the active berita, grouped by
+// section in section order. Within a section pinned berita come first, then
+// higher priority, then the most recently published. Each section shows up to
+// its own overview limit, or ?limit when it has none, and sections with a
+// limit of 0 are left out.
 func (c *ProfileController) GetOverviewBerita(ctx *gin.Context) {
+	limit := OVERVIEW_DEFAULT_LIMIT
+	if v := ctx.Query("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			c.res.AbortInvalidRequestBody(ctx, lib.ErrInvalidBody, "limit must be a positive number", nil)
+			return
+		}
+		limit = min(parsed, OVERVIEW_MAX_LIMIT)
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	pinned := "(a.pinned_at IS NOT NULL AND (a.pinned_until IS NULL OR a.pinned_until > now()))"
+	rows, err := c.db.QueryContext(_context, `
+	SELECT o.id, o.title, o.section, o.section_id, o.slug, o.color, o.thumb_img, o.descriptions,
+		o.event_start, o.event_end, o.location, o.rrule, o.pinned, o.pinned_until, o.section_total
+	FROM (
+		SELECT a.id, a.title, a.section, a.section_id, s.slug, s.color, s.sort_order, a.thumb_img,
+			a.descriptions, a.event_start, a.event_end, a.location, a.rrule, a.pinned_until,
+			`+pinned+` AS pinned,
+			COALESCE(s.overview_limit, ?) AS overview_limit,
+			ROW_NUMBER() OVER (PARTITION BY a.section_id
+				ORDER BY `+pinned+` DESC, a.priority DESC, a.publish_start DESC, a.id DESC) AS position,
+			COUNT(a.id) OVER (PARTITION BY a.section_id) AS section_total
+		FROM announcements a
+		LEFT JOIN announcement_sections s ON s.id = a.section_id
+			WHERE a.deleted_at is null AND a.publish_start <= now() AND a.publish_end >= now()
+	) o
+		WHERE o.position <= o.overview_limit
+		ORDER BY o.sort_order IS NULL, o.sort_order ASC, o.section_id ASC, o.position ASC`, limit)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	type Berita struct {
+		Id          int                       `json:"id"`
+		Title       string                    `json:"title"`
+		ThumbImg    string                    `json:"thumbImg"`
+		Desc        string                    `json:"descriptions"`
+		Pinned      bool                      `json:"pinned"`
+		PinnedUntil *time.Time                `json:"pinnedUntil"`
+		Event       *beritaEventResponseModel `json:"event"`
+	}
+	type Section struct {
+		Id     *int      `json:"id"`
+		Label  string    `json:"label"`
+		Slug   *string   `json:"slug"`
+		Color  *string   `json:"color"`
+		Total  int       `json:"total"`
+		Berita []*Berita `json:"berita"`
+	}
+
+	now := time.Now()
+	sections := []*Section{}
+	var current *Section
+	for rows.Next() {
+		var result Berita
+		var section Section
+		var eventStart, eventEnd, pinnedUntil []uint8
+		var location *string
+		var rrule sql.NullString
+		if err := rows.Scan(
+			&result.Id,
+			&result.Title,
+			&section.Label,
+			&section.Id,
+			&section.Slug,
+			&section.Color,
+			&result.ThumbImg,
+			&result.Desc,
+			&eventStart,
+			&eventEnd,
+			&location,
+			&rrule,
+			&result.Pinned,
+			&pinnedUntil,
+			&section.Total,
+		); err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+		if result.Pinned {
+			result.PinnedUntil = lib.Base64ToTime(pinnedUntil)
+		}
+		result.Event = beritaEvent(eventStart, eventEnd, location, rrule, now)
+
+		// rows of a section are adjacent, berita without a section come last
+		if current == nil || !sameSection(current.Id, section.Id) {
+			current = &section
+			current.Berita = []*Berita{}
+			sections = append(sections, current)
+		}
+		current.Berita = append(current.Berita, &result)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, sections)
+}
 
+func sameSection(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
 func (c *ProfileController) GetAllBerita(ctx *gin.Context) {
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		Sorts:        map[string]string{"publishStart": "a.publish_start", "title": "a.title"},
+		DefaultSort:  "-publishStart",
+		Filters:      map[string]string{"section": "s.slug"},
+		Search:       []string{"a.title"},
+		IdColumn:     "a.id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
 	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
 	defer cancel()
 
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context, `
+	SELECT COUNT(a.id) FROM announcements a
+	LEFT JOIN announcement_sections s ON s.id = a.section_id
+		WHERE a.deleted_at is null AND a.publish_start <= now() AND a.publish_end >= now()`+filter,
+		filterArgs...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
 	rows, err := c.db.QueryContext(_context, `
-	SELECT id, title, section, thumb_img, descriptions FROM announcements
-		WHERE deleted_at is null AND publish_start <= now() AND publish_end >= now()
-		ORDER BY publish_start DESC`)
+	SELECT a.id, a.title, a.section, s.slug, s.color, a.thumb_img, a.descriptions,
+		a.event_start, a.event_end, a.location, a.rrule, `+list.SortColumn()+`
+	FROM announcements a
+	LEFT JOIN announcement_sections s ON s.id = a.section_id
+		WHERE a.deleted_at is null AND a.publish_start <= now() AND a.publish_end >= now()`+where+`
+		ORDER BY `+list.OrderBy()+` `+paginate, append(args, paginateArgs...)...)
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
 		return
@@ -33,27 +186,43 @@ func (c *ProfileController) GetAllBerita(ctx *gin.Context) {
 	defer rows.Close()
 
 	type Berita struct {
-		Id       int    `json:"id"`
-		Title    string `json:"title"`
-		Section  string `json:"section"`
-		ThumbImg string `json:"thumbImg"`
-		Desc     string `json:"descriptions"`
+		Id           int                       `json:"id"`
+		Title        string                    `json:"title"`
+		Section      string                    `json:"section"`
+		SectionSlug  *string                   `json:"sectionSlug"`
+		SectionColor *string                   `json:"sectionColor"`
+		ThumbImg     string                    `json:"thumbImg"`
+		Desc         string                    `json:"descriptions"`
+		Event        *beritaEventResponseModel `json:"event"`
 	}
 
+	now := time.Now()
 	news := []*Berita{}
 	for rows.Next() {
 		var result Berita
+		var eventStart, eventEnd []uint8
+		var location *string
+		var rrule, sortValue sql.NullString
 		rows.Scan(
 			&result.Id,
 			&result.Title,
 			&result.Section,
+			&result.SectionSlug,
+			&result.SectionColor,
 			&result.ThumbImg,
 			&result.Desc,
+			&eventStart,
+			&eventEnd,
+			&location,
+			&rrule,
+			&sortValue,
 		)
+		result.Event = beritaEvent(eventStart, eventEnd, location, rrule, now)
+		list.Scanned(sortValue, result.Id)
 		news = append(news, &result)
 	}
 
-	c.res.SuccessWithStatusOKJSON(ctx, nil, news)
+	c.res.SuccessWithMeta(ctx, nil, news[:list.Trim()], list.Meta(total))
 }
 
 func (c *ProfileController) GetBeritaById(ctx *gin.Context) {
@@ -68,24 +237,40 @@ func (c *ProfileController) GetBeritaById(ctx *gin.Context) {
 	defer cancel()
 
 	type Berita struct {
-		Id       int    `json:"id"`
-		Title    string `json:"title"`
-		Section  string `json:"section"`
-		ThumbImg string `json:"thumbImg"`
-		Desc     string `json:"descriptions"`
-		Details  string `json:"details"`
+		Id           int                              `json:"id"`
+		Title        string                           `json:"title"`
+		Section      string                           `json:"section"`
+		SectionSlug  *string                          `json:"sectionSlug"`
+		SectionColor *string                          `json:"sectionColor"`
+		ThumbImg     string                           `json:"thumbImg"`
+		Desc         string                           `json:"descriptions"`
+		Details      string                           `json:"details"`
+		Event        *beritaEventResponseModel        `json:"event"`
+		Attachments  []*beritaAttachmentResponseModel `json:"attachments"`
 	}
 
 	var news Berita
+	var eventStart, eventEnd []uint8
+	var location *string
+	var rrule sql.NullString
 	err = c.db.QueryRowContext(_context, `
-	SELECT id, title, section, thumb_img, descriptions, details FROM announcements
-		WHERE id = ?`, parsedId).Scan(
+	SELECT a.id, a.title, a.section, s.slug, s.color, a.thumb_img, a.descriptions, a.details,
+		a.event_start, a.event_end, a.location, a.rrule
+	FROM announcements a
+	LEFT JOIN announcement_sections s ON s.id = a.section_id
+		WHERE a.id = ? AND a.deleted_at is null`, parsedId).Scan(
 		&news.Id,
 		&news.Title,
 		&news.Section,
+		&news.SectionSlug,
+		&news.SectionColor,
 		&news.ThumbImg,
 		&news.Desc,
 		&news.Details,
+		&eventStart,
+		&eventEnd,
+		&location,
+		&rrule,
 	)
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
@@ -100,5 +285,16 @@ func (c *ProfileController) GetBeritaById(ctx *gin.Context) {
 		return
 	}
 
+	news.Event = beritaEvent(eventStart, eventEnd, location, rrule, time.Now())
+	news.Attachments, err = c.beritaAttachments(_context, news.Id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
 	c.res.SuccessWithStatusOKJSON(ctx, nil, news)
 }
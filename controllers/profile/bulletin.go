@@ -0,0 +1,164 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// GetBulletins is the archive of published warta paroki, newest first.
+// ?year= narrows it down to the bulletins of one year.
+func (c *ProfileController) GetBulletins(ctx *gin.Context) {
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		Sorts:        map[string]string{"number": "number", "weekStart": "week_start"},
+		DefaultSort:  "-number",
+		Filters:      map[string]string{"year": "YEAR(week_start)"},
+		Search:       []string{"title"},
+		IdColumn:     "id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type bulletin struct {
+		Number      int        `json:"number"`
+		Title       string     `json:"title"`
+		WeekStart   string     `json:"weekStart"`
+		WeekEnd     string     `json:"weekEnd"`
+		Items       int        `json:"items"`
+		PublishedAt *time.Time `json:"publishedAt"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context,
+		"SELECT COUNT(id) FROM bulletins WHERE published_at IS NOT NULL"+filter, filterArgs...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
+	rows, err := c.db.QueryContext(_context, `
+	SELECT id, number, title, week_start, week_end,
+		(SELECT COUNT(i.id) FROM bulletin_items i WHERE i.bulletin_id = bulletins.id),
+		published_at, `+list.SortColumn()+`
+	FROM bulletins
+		WHERE published_at IS NOT NULL`+where+`
+		ORDER BY `+list.OrderBy()+` `+paginate, append(args, paginateArgs...)...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	bulletins := []*bulletin{}
+	for rows.Next() {
+		var result bulletin
+		var id int
+		var publishedAt []uint8
+		var sortValue sql.NullString
+		if err := rows.Scan(
+			&id,
+			&result.Number,
+			&result.Title,
+			&result.WeekStart,
+			&result.WeekEnd,
+			&result.Items,
+			&publishedAt,
+			&sortValue,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		result.PublishedAt = lib.Base64ToTime(publishedAt)
+		list.Scanned(sortValue, id)
+		bulletins = append(bulletins, &result)
+	}
+
+	c.res.SuccessWithMeta(ctx, nil, bulletins[:list.Trim()], list.Meta(total))
+}
+
+// publishedBulletin loads the published bulletin with the :number path
+// parameter, or aborts.
+func (c *ProfileController) publishedBulletin(ctx *gin.Context, _context context.Context) (*lib.Bulletin, bool) {
+	number, err := strconv.Atoi(ctx.Param("number"))
+	if err != nil {
+		c.res.AbortInvalidBulletin(ctx, err, err.Error(), nil)
+		return nil, false
+	}
+
+	var id int
+	err = c.db.QueryRowContext(_context,
+		"SELECT id FROM bulletins WHERE number = ? AND published_at IS NOT NULL", number).Scan(&id)
+	var bulletin *lib.Bulletin
+	if err == nil {
+		bulletin, err = lib.GetBulletin(_context, c.db, id)
+	}
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return nil, false
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortBulletinNotFound(ctx, err, err.Error(), nil)
+		return nil, false
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return nil, false
+	}
+	return bulletin, true
+}
+
+func (c *ProfileController) GetBulletinByNumber(ctx *gin.Context) {
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	bulletin, ok := c.publishedBulletin(ctx, _context)
+	if !ok {
+		return
+	}
+	c.res.SuccessWithStatusOKJSON(ctx, nil, bulletin)
+}
+
+// GetBulletinHTML and GetBulletinPDF download a published bulletin, the PDF
+// is the one printed for the Sunday Masses.
+func (c *ProfileController) GetBulletinHTML(ctx *gin.Context) {
+	c.writeBulletin(ctx, "html")
+}
+
+func (c *ProfileController) GetBulletinPDF(ctx *gin.Context) {
+	c.writeBulletin(ctx, "pdf")
+}
+
+func (c *ProfileController) writeBulletin(ctx *gin.Context, format string) {
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	bulletin, ok := c.publishedBulletin(ctx, _context)
+	if !ok {
+		return
+	}
+	ctx.Header("Cache-Control", "public, max-age=900")
+	c.res.WriteBulletin(ctx, bulletin, format)
+}
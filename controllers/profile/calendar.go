@@ -0,0 +1,170 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+const CALENDAR_DEFAULT_DAYS = 31
+const CALENDAR_MAX_DAYS = 366
+
+type beritaEventResponseModel struct {
+	Start     *time.Time      `json:"start"`
+	End       *time.Time      `json:"end"`
+	Location  *string         `json:"location"`
+	RRule     *string         `json:"rrule"`
+	Recurring bool            `json:"recurring"`
+	Next      *lib.Occurrence `json:"next"`
+}
+
+// beritaEvent describes the event of an announcement as of now, or returns
+// nil for announcements without one.
+func beritaEvent(eventStart, eventEnd []uint8, location *string, rrule sql.NullString, now time.Time) *beritaEventResponseModel {
+	start := lib.Base64ToTime(eventStart)
+	if start == nil {
+		return nil
+	}
+	event := beritaEventResponseModel{
+		Start:     start,
+		End:       lib.Base64ToTime(eventEnd),
+		Location:  location,
+		Recurring: rrule.Valid,
+	}
+	if rrule.Valid {
+		event.RRule = &rrule.String
+	}
+	next, err := lib.NextOccurrence(*event.Start, event.End, rrule.String, now)
+	if err != nil {
+		log.Println(err.Error())
+	}
+	event.Next = next
+	return &event
+}
+
+// GetBeritaCalendar lists the occurrences of announced events between ?from
+// and ?to (both YYYY-MM-DD in parish time, inclusive), by default the coming
+// month. Recurring events are expanded, ?section=<slug> narrows it down.
+func (c *ProfileController) GetBeritaCalendar(ctx *gin.Context) {
+	loc := lib.ParishLocation()
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, CALENDAR_DEFAULT_DAYS)
+	for _, param := range []struct {
+		name string
+		dest *time.Time
+		end  bool
+	}{{"from", &from, false}, {"to", &to, true}} {
+		v := ctx.Query(param.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.ParseInLocation(time.DateOnly, v, loc)
+		if err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, "invalid "+param.name+" date (use YYYY-MM-DD)", nil)
+			return
+		}
+		if param.end {
+			t = t.AddDate(0, 0, 1)
+		}
+		*param.dest = t
+	}
+	if !to.After(from) {
+		err := errors.New("to must not be before from")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if to.Sub(from) > CALENDAR_MAX_DAYS*24*time.Hour {
+		err := errors.New("the calendar spans at most 366 days")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type occurrenceResponseModel struct {
+		Id        int        `json:"id"`
+		Title     string     `json:"title"`
+		Section   string     `json:"section"`
+		ThumbImg  string     `json:"thumbImg"`
+		Desc      string     `json:"descriptions"`
+		Location  *string    `json:"location"`
+		Start     time.Time  `json:"start"`
+		End       *time.Time `json:"end"`
+		Recurring bool       `json:"recurring"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	query := `
+	SELECT id, title, section, thumb_img, descriptions, event_start, event_end, location, rrule
+	FROM announcements
+		WHERE deleted_at is null AND publish_start <= now() AND event_start IS NOT NULL AND event_start < ?
+		AND (rrule IS NOT NULL OR COALESCE(event_end, event_start) >= ?)`
+	args := []any{to.UTC(), from.UTC()}
+	if section := ctx.Query("section"); section != "" {
+		query += " AND section_id = (SELECT id FROM announcement_sections WHERE slug = ?)"
+		args = append(args, section)
+	}
+	rows, err := c.db.QueryContext(_context, query, args...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	occurrences := []*occurrenceResponseModel{}
+	for rows.Next() {
+		var berita occurrenceResponseModel
+		var eventStart, eventEnd []uint8
+		var rrule sql.NullString
+		if err := rows.Scan(
+			&berita.Id,
+			&berita.Title,
+			&berita.Section,
+			&berita.ThumbImg,
+			&berita.Desc,
+			&eventStart,
+			&eventEnd,
+			&berita.Location,
+			&rrule,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		start := lib.Base64ToTime(eventStart)
+		if start == nil {
+			continue
+		}
+		expanded, err := lib.EventOccurrences(*start, lib.Base64ToTime(eventEnd), rrule.String, from, to, 0)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		berita.Recurring = rrule.Valid
+		for _, o := range expanded {
+			occurrence := berita
+			occurrence.Start = o.Start
+			occurrence.End = o.End
+			occurrences = append(occurrences, &occurrence)
+		}
+	}
+	slices.SortStableFunc(occurrences, func(a, b *occurrenceResponseModel) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
+		"from":        from,
+		"to":          to,
+		"occurrences": occurrences,
+	})
+}
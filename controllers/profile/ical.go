@@ -0,0 +1,165 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+const ICAL_CALENDAR_NAME = "Kegiatan Paroki Kosambi Baru"
+
+// ICAL_PAST_DAYS keeps recently ended one-off events in the feeds, so they
+// do not vanish from calendars the moment they are over.
+const ICAL_PAST_DAYS = 90
+
+// beritaICalEvents loads the events of published announcements matching cond.
+// SEQUENCE counts the revisions so calendar apps pick up every edit.
+func (c *ProfileController) beritaICalEvents(_context context.Context, cond string, args ...any) ([]*lib.ICalEvent, error) {
+	rows, err := c.db.QueryContext(_context, `
+	SELECT a.id, a.title, a.section, a.descriptions, a.event_start, a.event_end, a.location, a.rrule,
+		COALESCE(a.updated_at, a.created_at),
+		(SELECT COUNT(r.id) FROM announcement_revisions r WHERE r.announcement_id = a.id)
+	FROM announcements a
+		WHERE a.deleted_at is null AND a.publish_start <= now() AND a.event_start IS NOT NULL AND `+cond+`
+		ORDER BY a.event_start ASC, a.id ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*lib.ICalEvent{}
+	for rows.Next() {
+		var event lib.ICalEvent
+		var id int
+		var eventStart, eventEnd, lastModified []uint8
+		var location, rrule sql.NullString
+		if err := rows.Scan(
+			&id,
+			&event.Summary,
+			&event.Categories,
+			&event.Description,
+			&eventStart,
+			&eventEnd,
+			&location,
+			&rrule,
+			&lastModified,
+			&event.Sequence,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		start := lib.Base64ToTime(eventStart)
+		if start == nil {
+			continue
+		}
+		event.UID = lib.BeritaEventUID(id)
+		event.Start = *start
+		event.End = lib.Base64ToTime(eventEnd)
+		event.Location = location.String
+		event.RRule = rrule.String
+		event.Url = fmt.Sprintf("/berita/%d", id)
+		if t := lib.Base64ToTime(lastModified); t != nil {
+			event.LastModified = *t
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+// GetKegiatanICal is the subscribable feed of every parish event.
+func (c *ProfileController) GetKegiatanICal(ctx *gin.Context) {
+	c.writeKegiatanICal(ctx, "")
+}
+
+// GetSectionICal is the feed of the events of one section, by slug, e.g.
+// /api/kegiatan/sections/omk/calendar.ics.
+func (c *ProfileController) GetSectionICal(ctx *gin.Context) {
+	section := strings.TrimSpace(ctx.Param("section"))
+	if section == "" {
+		c.res.AbortInvalidRequestBody(ctx, lib.ErrInvalidBody, "missing section", nil)
+		return
+	}
+	c.writeKegiatanICal(ctx, section)
+}
+
+func (c *ProfileController) writeKegiatanICal(ctx *gin.Context, section string) {
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	cond := "(a.rrule IS NOT NULL OR COALESCE(a.event_end, a.event_start) >= ?)"
+	args := []any{time.Now().UTC().AddDate(0, 0, -ICAL_PAST_DAYS)}
+	cal := lib.ICalendar{Name: ICAL_CALENDAR_NAME}
+	fileName := "kegiatan.ics"
+	if section != "" {
+		var label string
+		err := c.db.QueryRowContext(_context,
+			"SELECT label FROM announcement_sections WHERE slug = ?", section).Scan(&label)
+		if _context.Err() == context.DeadlineExceeded {
+			c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+			return
+		}
+		if err == sql.ErrNoRows {
+			c.res.AbortWithStatusJSON(ctx, err, lib.ErrSectionNotFound.Error(), err.Error(), http.StatusNotFound, nil)
+			return
+		}
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+		cond += " AND a.section_id = (SELECT id FROM announcement_sections WHERE slug = ?)"
+		args = append(args, section)
+		cal.Name += " - " + label
+		fileName = "kegiatan-" + section + ".ics"
+	}
+
+	events, err := c.beritaICalEvents(_context, cond, args...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	cal.Events = events
+
+	c.res.WriteICalendar(ctx, &cal, fileName)
+}
+
+// GetBeritaICal downloads the event of a single announcement.
+func (c *ProfileController) GetBeritaICal(ctx *gin.Context) {
+	beritaId, err := strconv.Atoi(ctx.Param("beritaId"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	events, err := c.beritaICalEvents(_context, "a.id = ?", beritaId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if len(events) == 0 {
+		err := errors.New("event not found")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "berita does not exist or has no event", http.StatusNotFound, nil)
+		return
+	}
+
+	c.res.WriteICalendar(ctx, &lib.ICalendar{Events: events}, fmt.Sprintf("berita-%d.ics", beritaId))
+}
@@ -0,0 +1,248 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 .()-]{6,24}$`)
+
+// PostMassIntention takes an ujud misa for a Mass of the schedule, which
+// waits for the secretariat to confirm it. The name it is offered for is
+// only shown on the website with publishName; the phone number and email
+// address are for the secretariat only.
+func (c *ProfileController) PostMassIntention(ctx *gin.Context) {
+	type reqBody struct {
+		Kind        string `json:"kind" binding:"required"`
+		ForName     string `json:"forName" binding:"required"`
+		Intention   string `json:"intention"`
+		Name        string `json:"name" binding:"required"`
+		Phone       string `json:"phone"`
+		Email       string `json:"email"`
+		PublishName bool   `json:"publishName"`
+		Date        string `json:"date" binding:"required"`
+		ScheduleId  *int   `json:"scheduleId"`
+		ExceptionId *int   `json:"exceptionId"`
+		lib.Honeypot
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	if !slices.Contains(lib.INTENTION_KINDS, payload.Kind) {
+		err := fmt.Errorf("kind must be one of %s", strings.Join(lib.INTENTION_KINDS, ", "))
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	forName := strings.TrimSpace(payload.ForName)
+	if forName == "" || utf8.RuneCountInString(forName) > 128 {
+		err := errors.New("forName must be 1 to 128 characters")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	var intention *string
+	if i := strings.TrimSpace(payload.Intention); i != "" {
+		if utf8.RuneCountInString(i) > 500 {
+			err := errors.New("intention must be at most 500 characters")
+			c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+			return
+		}
+		intention = &i
+	}
+	name := strings.TrimSpace(payload.Name)
+	if name == "" || utf8.RuneCountInString(name) > 64 {
+		err := errors.New("name must be 1 to 64 characters")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	var phone, email *string
+	if p := strings.TrimSpace(payload.Phone); p != "" {
+		if !phonePattern.MatchString(p) {
+			c.res.AbortInvalidRequestBody(ctx, lib.ErrInvalidBody, "invalid phone number", nil)
+			return
+		}
+		phone = &p
+	}
+	if e := strings.TrimSpace(payload.Email); e != "" {
+		addr, err := mail.ParseAddress(e)
+		if err != nil || addr.Address != e {
+			c.res.AbortInvalidRequestBody(ctx, lib.ErrInvalidBody, "invalid email address", nil)
+			return
+		}
+		e = strings.ToLower(e)
+		email = &e
+	}
+	if phone == nil && email == nil {
+		err := errors.New("give a phone number or an email address")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if err := lib.ValidMassRef(payload.Date, payload.ScheduleId, payload.ExceptionId); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	accepted := gin.H{"message": "mass intention submitted for confirmation"}
+	ipHash, ok := c.res.GuardSubmission(ctx, payload.Honeypot, lib.IntentionRateLimiter, lib.CLIENT_INTENTION, accepted)
+	if !ok {
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	mass, err := lib.GetMass(_context, c.db, payload.Date, payload.ScheduleId, payload.ExceptionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortInvalidMassSchedule(ctx, err, "there is no such Mass on "+payload.Date, nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if mass.Cancelled {
+		err := errors.New("this Mass is cancelled")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusConflict, nil)
+		return
+	}
+	now := time.Now()
+	if mass.Start.Sub(now) < lib.INTENTION_NOTICE {
+		err := fmt.Errorf("intentions are taken until %g hours before the Mass", lib.INTENTION_NOTICE.Hours())
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if mass.Start.After(now.AddDate(0, 0, lib.INTENTION_MAX_DAYS)) {
+		err := fmt.Errorf("intentions are taken at most %d days ahead", lib.INTENTION_MAX_DAYS)
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	// regular Masses are kept by their schedule, so a later change of time
+	// or place does not lose the intention
+	scheduleId, exceptionId := mass.ScheduleId, (*int)(nil)
+	if scheduleId == nil {
+		exceptionId = mass.ExceptionId
+	}
+
+	result, err := c.db.ExecContext(_context, `
+		INSERT INTO mass_intentions
+		(kind, for_name, intention, requester_name, phone, email, publish_name,
+			mass_date, schedule_id, exception_id, requested_mass, status, ip_hash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		payload.Kind, forName, intention, name, phone, email, payload.PublishName,
+		mass.Date, scheduleId, exceptionId, mass.Describe(), lib.INTENTION_PENDING, ipHash, now.UTC())
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	id, _ := result.LastInsertId()
+	accepted["id"] = id
+	accepted["mass"] = mass.Describe()
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, accepted)
+}
+
+// GetMassIntentions lists the confirmed intentions of the Masses of ?date
+// (YYYY-MM-DD, today by default). Only the kind and the name they are
+// offered for are shown, and the name only when the requester agreed to it.
+func (c *ProfileController) GetMassIntentions(ctx *gin.Context) {
+	loc := lib.ParishLocation()
+	now := time.Now().In(loc)
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if v := ctx.Query("date"); v != "" {
+		t, err := time.ParseInLocation(time.DateOnly, v, loc)
+		if err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, "invalid date (use YYYY-MM-DD)", nil)
+			return
+		}
+		date = t
+	}
+	day := date.Format(time.DateOnly)
+
+	type intentionResponseModel struct {
+		Kind      string `json:"kind"`
+		KindLabel string `json:"kindLabel"`
+		ForName   string `json:"forName"`
+	}
+	type massResponseModel struct {
+		*lib.Mass
+		Intentions []*intentionResponseModel `json:"intentions"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	days, err := lib.GetMassSchedule(_context, c.db, date, date, 0)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT kind, for_name, schedule_id, exception_id
+		FROM mass_intentions
+		WHERE mass_date = ? AND status = ? AND publish_name = TRUE
+		ORDER BY created_at ASC, id ASC`, day, lib.INTENTION_CONFIRMED)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	byMass := map[*lib.Mass][]*intentionResponseModel{}
+	for rows.Next() {
+		var intention intentionResponseModel
+		var scheduleId, exceptionId *int
+		if err := rows.Scan(&intention.Kind, &intention.ForName, &scheduleId, &exceptionId); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		mass := lib.FindMass(days, day, scheduleId, exceptionId)
+		if mass == nil || mass.Cancelled {
+			continue
+		}
+		intention.KindLabel = lib.IntentionKindLabel(intention.Kind)
+		byMass[mass] = append(byMass[mass], &intention)
+	}
+
+	masses := []*massResponseModel{}
+	for _, massDay := range days {
+		for _, mass := range massDay.Masses {
+			if intentions, ok := byMass[mass]; ok {
+				masses = append(masses, &massResponseModel{Mass: mass, Intentions: intentions})
+			}
+		}
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"date": day, "masses": masses})
+}
@@ -0,0 +1,177 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+const MASS_DEFAULT_DAYS = 7
+const MASS_MAX_DAYS = 92
+
+// GetMassLocations lists the churches and chapels with their regular weekly
+// Masses, the timetable printed on the parish website.
+func (c *ProfileController) GetMassLocations(ctx *gin.Context) {
+	type scheduleResponseModel struct {
+		Id         int     `json:"id"`
+		Weekday    int     `json:"weekday"`
+		Day        string  `json:"day"`
+		Time       string  `json:"time"`
+		Language   string  `json:"language"`
+		Label      *string `json:"label"`
+		Notes      *string `json:"notes"`
+		ValidFrom  *string `json:"validFrom"`
+		ValidUntil *string `json:"validUntil"`
+	}
+	type locationResponseModel struct {
+		*lib.MassLocation
+		Schedules []*scheduleResponseModel `json:"schedules"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	locations, err := lib.GetMassLocations(_context, c.db)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	result := []*locationResponseModel{}
+	byId := map[int]*locationResponseModel{}
+	for _, location := range locations {
+		l := &locationResponseModel{MassLocation: location, Schedules: []*scheduleResponseModel{}}
+		byId[location.Id] = l
+		result = append(result, l)
+	}
+
+	// schedules that ended are history, the ones still to start are shown
+	// with their validity so the site can announce them
+	today := time.Now().In(lib.ParishLocation()).Format(time.DateOnly)
+	rows, err := c.db.QueryContext(_context, `
+	SELECT id, location_id, weekday, start_time, language, label, notes, valid_from, valid_until
+	FROM mass_schedules
+		WHERE valid_until IS NULL OR valid_until >= ?
+		ORDER BY weekday = 0, weekday ASC, start_time ASC`, today)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schedule scheduleResponseModel
+		var locationId int
+		var startTime string
+		if err := rows.Scan(
+			&schedule.Id,
+			&locationId,
+			&schedule.Weekday,
+			&startTime,
+			&schedule.Language,
+			&schedule.Label,
+			&schedule.Notes,
+			&schedule.ValidFrom,
+			&schedule.ValidUntil,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		location, ok := byId[locationId]
+		if !ok {
+			continue
+		}
+		schedule.Day = lib.IndonesianWeekday(time.Weekday(schedule.Weekday))
+		schedule.Time = lib.FormatJam(startTime)
+		location.Schedules = append(location.Schedules, &schedule)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, result)
+}
+
+// GetMassSchedule returns the Masses actually celebrated between ?from and
+// ?to (YYYY-MM-DD, inclusive), by default the coming week: the weekly
+// schedule with cancellations, changes and special Masses applied.
+// ?location=<slug> keeps a single church or chapel.
+func (c *ProfileController) GetMassSchedule(ctx *gin.Context) {
+	loc := lib.ParishLocation()
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, MASS_DEFAULT_DAYS-1)
+	for _, param := range []struct {
+		name string
+		dest *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		v := ctx.Query(param.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.ParseInLocation(time.DateOnly, v, loc)
+		if err != nil {
+			c.res.AbortInvalidRequestBody(ctx, err, "invalid "+param.name+" date (use YYYY-MM-DD)", nil)
+			return
+		}
+		*param.dest = t
+	}
+	if ctx.Query("from") != "" && ctx.Query("to") == "" {
+		to = from.AddDate(0, 0, MASS_DEFAULT_DAYS-1)
+	}
+	if to.Before(from) {
+		err := errors.New("to must not be before from")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	if to.Sub(from) >= MASS_MAX_DAYS*24*time.Hour {
+		err := errors.New("the schedule spans at most 92 days")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	locationId := 0
+	if slug := ctx.Query("location"); slug != "" {
+		err := c.db.QueryRowContext(_context, "SELECT id FROM mass_locations WHERE slug = ?", slug).Scan(&locationId)
+		if _context.Err() == context.DeadlineExceeded {
+			c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+			return
+		}
+		if err == sql.ErrNoRows {
+			c.res.AbortMassLocationNotFound(ctx, err, err.Error(), nil)
+			return
+		}
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+	}
+
+	days, err := lib.GetMassSchedule(_context, c.db, from, to, locationId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
+		"from": from.Format(time.DateOnly),
+		"to":   to.Format(time.DateOnly),
+		"days": days,
+	})
+}
@@ -0,0 +1,54 @@
+package profile
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBeritaSections lists the berita sections in their editorial order with
+// how many announcements each one currently shows. Filter on the slug with
+// GetAllBerita?section=.
+func (c *ProfileController) GetBeritaSections(ctx *gin.Context) {
+	type sectionResponseModel struct {
+		Id     int     `json:"id"`
+		Label  string  `json:"label"`
+		Slug   string  `json:"slug"`
+		Color  *string `json:"color"`
+		Berita int     `json:"berita"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(_context, `
+	SELECT s.id, s.label, s.slug, s.color, COUNT(a.id)
+	FROM announcement_sections s
+	LEFT JOIN announcements a ON a.section_id = s.id
+		AND a.deleted_at is null AND a.publish_start <= now() AND a.publish_end >= now()
+		GROUP BY s.id, s.label, s.slug, s.color, s.sort_order
+		ORDER BY s.sort_order ASC, s.label ASC`)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	sections := []*sectionResponseModel{}
+	for rows.Next() {
+		var section sectionResponseModel
+		if err := rows.Scan(&section.Id, &section.Label, &section.Slug, &section.Color, &section.Berita); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		sections = append(sections, &section)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, sections)
+}
@@ -0,0 +1,58 @@
+package profile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// GetBeritaShareMeta describes an announcement for link previews. Expired
+// announcements keep their preview, links to them live on in chats.
+func (c *ProfileController) GetBeritaShareMeta(ctx *gin.Context) {
+	beritaId, err := strconv.Atoi(ctx.Param("beritaId"))
+	if err != nil {
+		c.res.AbortInvalidBerita(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var meta lib.ShareMeta
+	var thumbImg sql.NullString
+	var publishStart []uint8
+	err = c.db.QueryRowContext(_context, `
+	SELECT title, descriptions, thumb_img, publish_start FROM announcements
+		WHERE id = ? AND deleted_at is null AND publish_start <= now()`, beritaId).Scan(
+		&meta.Title,
+		&meta.Description,
+		&thumbImg,
+		&publishStart,
+	)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortWithStatusJSON(ctx, err, "berita not found", err.Error(), http.StatusNotFound, nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	meta.Image = thumbImg.String
+	meta.PublishedAt = lib.Base64ToTime(publishStart)
+	meta.Url = fmt.Sprintf("/berita/%d", beritaId)
+	meta.Type = "article"
+	meta.SiteName = lib.SHARE_SITE_PAROKI
+
+	c.res.WriteShareMeta(ctx, &meta)
+}
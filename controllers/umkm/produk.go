@@ -3,16 +3,14 @@ package umkm
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
 	"slices"
-	"strconv"
-	"strings"
 	"time"
 	"database/sql"
 
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
 	"github.com/gin-gonic/gin"
 )
 
@@ -33,97 +31,41 @@ var CATEGORY_KEYS []string = []string{
 	"kesehatan-pendidikan",
 }
 
-var SORT_AZ int = 0
-var SORT_ZA int = 1
-var SORT_PRICE_ASC int = 2
-var SORT_PRICE_DESC int = 3
-
-var SORT_KEYS []string = []string{
-	"az",
-	"za",
-	"price-asc",
-	"price-desc",
-}
-
-func sortProdukQuery(q string, sort string) string {
-	if sort == SORT_KEYS[SORT_ZA] {
-		return fmt.Sprintf("%s ORDER BY up.nama DESC", q)
-	}
-	if sort == SORT_KEYS[SORT_PRICE_ASC] {
-		return fmt.Sprintf("%s ORDER BY up.harga ASC", q)
-	}
-	if sort == SORT_KEYS[SORT_PRICE_DESC] {
-		return fmt.Sprintf("%s ORDER BY up.harga DESC", q)
-	}
-	return fmt.Sprintf("%s ORDER BY up.nama ASC", q)
-}
-
-func searchProdukQuery(q string, search string) (string, string) {
-	if search == "" {
-		return q, ""
-	}
-	if strings.Contains(q, "WHERE") {
-		return fmt.Sprintf("%s AND up.nama LIKE ?", q), "%" + search + "%"
-	}
-	return fmt.Sprintf("%s WHERE up.nama LIKE ?", q), "%" + search + "%"
-}
-
-func filterProductQuery(q string, category string) string {
-	if category == CATEGORY_KEYS[CATEGORY_KULINER] {
-		return fmt.Sprintf("%s WHERE ut.kategori=%d", q, CATEGORY_KULINER)
-	}
-	if category == CATEGORY_KEYS[CATEGORY_FASHION] {
-		return fmt.Sprintf("%s WHERE ut.kategori=%d", q, CATEGORY_FASHION)
-	}
-	if category == CATEGORY_KEYS[CATEGORY_JASA] {
-		return fmt.Sprintf("%s WHERE ut.kategori=%d", q, CATEGORY_JASA)
-	}
-	if category == CATEGORY_KEYS[CATEGORY_KERAJINAN] {
-		return fmt.Sprintf("%s WHERE ut.kategori=%d", q, CATEGORY_KERAJINAN)
-	}
-	if category == CATEGORY_KEYS[CATEGORY_TEKNOLOGI_KREATIVITAS] {
-		return fmt.Sprintf("%s WHERE ut.kategori=%d", q, CATEGORY_TEKNOLOGI_KREATIVITAS)
-	}
-	if category == CATEGORY_KEYS[CATEGORY_KESEHATAN_PENDIDIKAN] {
-		return fmt.Sprintf("%s WHERE ut.kategori=%d", q, CATEGORY_KESEHATAN_PENDIDIKAN)
-	}
-	return q
+// SORT_KEYS are the ?sort= values used by the UMKM pages before the shared
+// list helper, mapped onto its sort keys.
+var SORT_KEYS map[string]string = map[string]string{
+	"az":         "name",
+	"za":         "-name",
+	"price-asc":  "price",
+	"price-desc": "-price",
 }
 
 func (c *UMKMController) GetProduct(ctx *gin.Context) {
-	sort := ctx.Query("sort")
-	if sort == "" {
-		e := errors.New("invalid sort")
-		c.res.AbortWithStatusJSON(ctx, e, "invalid sort", e.Error(), http.StatusBadRequest, nil)
-		return
-	}
-
-	page := ctx.Query("page")
-	category := ctx.Query("category")
-	search := ctx.Query("q") 
-
-	if category == "" {
-		e := errors.New("invalid category")
-		c.res.AbortWithStatusJSON(ctx, e, "invalid category", e.Error(), http.StatusBadRequest, nil)
-		return
-	}
-
-	isValidCategory := slices.Index(CATEGORY_KEYS, category)
-	if isValidCategory == -1 {
+	category := ctx.DefaultQuery("category", CATEGORY_KEYS[0])
+	categoryIdx := slices.Index(CATEGORY_KEYS, category)
+	if categoryIdx == -1 {
 		e := errors.New("invalid category")
 		c.res.AbortWithStatusJSON(ctx, e, "invalid category", e.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	currPage, err := strconv.Atoi(page)
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 20,
+		MaxLimit:     50,
+		Sorts:        map[string]string{"name": "up.nama", "price": "up.harga"},
+		DefaultSort:  "name",
+		SortAliases:  SORT_KEYS,
+		LenientSort:  true,
+		Search:       []string{"up.nama"},
+		IdColumn:     "up.id",
+	})
 	if err != nil {
-		c.res.AbortWithStatusJSON(ctx, err, "invalid page", err.Error(), http.StatusBadRequest, nil)
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
 		return
 	}
-	if currPage < 1 {
-		e := errors.New("invalid page")
-		c.res.AbortWithStatusJSON(ctx, e, "invalid page", e.Error(), http.StatusBadRequest, nil)
-		return
+	// "all" is index 0
+	if categoryIdx > 0 {
+		list.AddCondition("ut.kategori = ?", categoryIdx)
 	}
 
 	type Product struct {
@@ -138,18 +80,12 @@ func (c *UMKMController) GetProduct(ctx *gin.Context) {
 	_context, cancel := context.WithTimeout(ctx.Request.Context(), 20*time.Second)
 	defer cancel()
 
-	
+	filter, filterArgs := list.Filter()
 	qCount := `SELECT count(up.id) FROM umkm_products up 
-        JOIN umkm_toko ut ON up.id_toko = ut.id`
-	qCount = filterProductQuery(qCount, category)
-	qCount, searchArg := searchProdukQuery(qCount, search)
+        JOIN umkm_toko ut ON up.id_toko = ut.id WHERE 1 = 1` + filter
 
 	var ctr int
-	if searchArg != "" {
-		err = c.db.QueryRowContext(_context, qCount, searchArg).Scan(&ctr)
-	} else {
-		err = c.db.QueryRowContext(_context, qCount).Scan(&ctr)
-	}
+	err = c.db.QueryRowContext(_context, qCount, filterArgs...).Scan(&ctr)
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
 		return
@@ -159,19 +95,13 @@ func (c *UMKMController) GetProduct(ctx *gin.Context) {
 		return
 	}
 
-	q := `SELECT up.id, up.nama, up.foto, up.harga, ut.nama, ut.kategori FROM umkm_products up 
-        JOIN umkm_toko ut ON up.id_toko = ut.id`
-	q = filterProductQuery(q, category)
-	q, searchArg = searchProdukQuery(q, search)
-	q = sortProdukQuery(q, sort)
-	q = fmt.Sprintf("%s LIMIT 20 OFFSET %d", q, (currPage-1)*20)
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
+	q := `SELECT up.id, up.nama, up.foto, up.harga, ut.nama, ut.kategori, ` + list.SortColumn() + ` FROM umkm_products up 
+        JOIN umkm_toko ut ON up.id_toko = ut.id WHERE 1 = 1` + where +
+		` ORDER BY ` + list.OrderBy() + ` ` + paginate
 
-	var produkRows *sql.Rows
-	if searchArg != "" {
-		produkRows, err = c.db.QueryContext(_context, q, searchArg)
-	} else {
-		produkRows, err = c.db.QueryContext(_context, q)
-	}
+	produkRows, err := c.db.QueryContext(_context, q, append(args, paginateArgs...)...)
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
 		return
@@ -180,28 +110,34 @@ func (c *UMKMController) GetProduct(ctx *gin.Context) {
 		c.res.AbortDatabaseError(ctx, err, nil)
 		return
 	}
+	defer produkRows.Close()
 
 	listProduk := []*Product{}
 	for produkRows.Next() {
 		var produk Product
 		var cat int
+		var sortValue sql.NullString
 		if err := produkRows.Scan(
 			&produk.ID, &produk.Name, &produk.Img,
-			&produk.Price, &produk.StoreName, &cat,
+			&produk.Price, &produk.StoreName, &cat, &sortValue,
 		); err != nil {
 			log.Println(err.Error())
+			continue
 		}
 		produk.Category = STORE_CATEGORY_MAPPING[cat]
+		list.Scanned(sortValue, *produk.ID)
 		listProduk = append(listProduk, &produk)
 	}
 
-	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
-		"produk":    listProduk,
-		"pageSize":  20,
-		"currPage":  currPage,
+	// the fields next to produk are what the UMKM pages read, pageCount
+	// keeps counting as before
+	c.res.SuccessWithMeta(ctx, nil, gin.H{
+		"produk":    listProduk[:list.Trim()],
+		"pageSize":  list.Limit,
+		"currPage":  list.Page,
 		"itemCount": ctr,
-		"pageCount": int(ctr/20) + 1,
-	})
+		"pageCount": ctr/list.Limit + 1,
+	}, list.Meta(ctr))
 }
 
 var MAXIMUM_OFFSET float32 = float32(433)
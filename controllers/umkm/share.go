@@ -0,0 +1,65 @@
+package umkm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// GetProductShareMeta describes a product for link previews, falling back to
+// the store logo when the product has no photo.
+func (c *UMKMController) GetProductShareMeta(ctx *gin.Context) {
+	productId, err := strconv.Atoi(ctx.Param("productId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid product id", nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var name, description, foto, toko, logo sql.NullString
+	err = c.db.QueryRowContext(_context, `
+		SELECT up.nama, up.deskripsi, up.foto, ut.nama, ut.logo
+		FROM umkm_products up
+		JOIN umkm_toko ut ON up.id_toko = ut.id
+		WHERE up.id = ?`, productId).Scan(&name, &description, &foto, &toko, &logo)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortWithStatusJSON(ctx, err, "produk tidak ditemukan", err.Error(), http.StatusNotFound, nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	meta := lib.ShareMeta{
+		Title:       name.String,
+		Description: description.String,
+		Image:       foto.String,
+		Url:         fmt.Sprintf("/umkm/products/%d", productId),
+		Type:        "product",
+		SiteName:    lib.SHARE_SITE_PAROKI,
+	}
+	if toko.String != "" {
+		meta.Title += " - " + toko.String
+	}
+	if meta.Description == "" {
+		meta.Description = fmt.Sprintf("Produk UMKM %s, Paroki Kosambi Baru", toko.String)
+	}
+	if meta.Image == "" {
+		meta.Image = logo.String
+	}
+
+	c.res.WriteShareMeta(ctx, &meta)
+}
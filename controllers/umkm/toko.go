@@ -2,41 +2,30 @@ package umkm
 
 import (
 	"context"
-	"fmt"
+	"database/sql"
 	"log"
-	"strconv"
 	"time"
 
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
 	"github.com/gin-gonic/gin"
 )
 
-func sortTokoQuery(q string, sort string) string {
-	if sort == "Z-A" {
-		return fmt.Sprintf("%s ORDER BY t.nama DESC", q)
-	}
-	return fmt.Sprintf("%s ORDER BY t.nama ASC", q)
-}
-
-func paginateQuery(q string, limit int, offset int) string {
-	return fmt.Sprintf("%s LIMIT %d OFFSET %d", q, limit, offset)
-}
-	
-
 func (c *UMKMController) GetToko(ctx *gin.Context) {
-
-	filter := ctx.Query("filter")
-	search := ctx.Query("q") 
-	sort := ctx.Query("sort")
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "15"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 50 {
-		limit = 15
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 15,
+		MaxLimit:     50,
+		Sorts:        map[string]string{"name": "t.nama"},
+		DefaultSort:  "name",
+		SortAliases:  map[string]string{"A-Z": "name", "Z-A": "-name"},
+		LenientSort:  true,
+		Filters:      map[string]string{"filter": "t.kategori"},
+		Search:       []string{"t.nama"},
+		IdColumn:     "t.id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
 	}
-	offset := (page - 1) * limit
 
 	type Toko struct {
 		ID              *int    `json:"id"`
@@ -55,43 +44,28 @@ func (c *UMKMController) GetToko(ctx *gin.Context) {
 	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
 	defer cancel()
 
+	filter, filterArgs := list.Filter()
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
+
 	baseQ := `SELECT t.id, t.nama, t.logo, t.kategori, t.jenis_produk, t.alamat,
 		t.kontak_whatsapp, t.kontak_instagram, t.kontak_facebook, t.kontak_telepon,
-		COUNT(p.id) AS jumlah_produk
+		COUNT(p.id) AS jumlah_produk, ` + list.SortColumn() + `
 		FROM umkm_toko t
-		LEFT JOIN umkm_products p ON p.id_toko = t.id`
-
-	countQ := `SELECT COUNT(DISTINCT t.id) FROM umkm_toko t`
-
-	whereAdded := false
-	if filter != "" {
-		kategori, _ := strconv.Atoi(filter)
-		baseQ = fmt.Sprintf("%s WHERE t.kategori = %d", baseQ, kategori)
-		countQ = fmt.Sprintf("%s WHERE t.kategori = %d", countQ, kategori)
-		whereAdded = true
-	}
-	if search != "" {
-		if whereAdded {
-			baseQ = fmt.Sprintf("%s AND t.nama LIKE '%%%s%%'", baseQ, search)
-			countQ = fmt.Sprintf("%s AND t.nama LIKE '%%%s%%'", countQ, search)
-		} else {
-			baseQ = fmt.Sprintf("%s WHERE t.nama LIKE '%%%s%%'", baseQ, search)
-			countQ = fmt.Sprintf("%s WHERE t.nama LIKE '%%%s%%'", countQ, search)
-		}
-	}
-
-	baseQ = fmt.Sprintf("%s GROUP BY t.id", baseQ)
+		LEFT JOIN umkm_products p ON p.id_toko = t.id
+		WHERE 1 = 1` + where + `
+		GROUP BY t.id
+		ORDER BY ` + list.OrderBy() + ` ` + paginate
 
-	baseQ = sortTokoQuery(baseQ, sort)
-	baseQ = paginateQuery(baseQ, limit, offset)
+	countQ := `SELECT COUNT(DISTINCT t.id) FROM umkm_toko t WHERE 1 = 1` + filter
 
 	var total int
-	if err := c.db.QueryRowContext(_context, countQ).Scan(&total); err != nil {
+	if err := c.db.QueryRowContext(_context, countQ, filterArgs...).Scan(&total); err != nil {
 		c.res.AbortDatabaseError(ctx, err, nil)
 		return
 	}
 
-	tokoRows, err := c.db.QueryContext(_context, baseQ)
+	tokoRows, err := c.db.QueryContext(_context, baseQ, append(args, paginateArgs...)...)
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
 		return
@@ -105,6 +79,7 @@ func (c *UMKMController) GetToko(ctx *gin.Context) {
 	listToko := []*Toko{}
 	for tokoRows.Next() {
 		var toko Toko
+		var sortValue sql.NullString
 		if err := tokoRows.Scan(
 			&toko.ID,
 			&toko.Nama,
@@ -117,20 +92,22 @@ func (c *UMKMController) GetToko(ctx *gin.Context) {
 			&toko.KontakFacebook,
 			&toko.KontakTelepon,
 			&toko.JumlahProduk,
+			&sortValue,
 		); err != nil {
 			log.Println(err.Error())
+			continue
 		}
+		list.Scanned(sortValue, *toko.ID)
 		listToko = append(listToko, &toko)
 	}
 
-	totalPages := (total + limit - 1) / limit
-
-	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{
-		"toko":       listToko,
+	// the fields next to toko are what the UMKM pages read
+	c.res.SuccessWithMeta(ctx, nil, gin.H{
+		"toko":       listToko[:list.Trim()],
 		"total":      total,
-		"page":       page,
-		"totalPages": totalPages,
-	})
+		"page":       list.Page,
+		"totalPages": (total + list.Limit - 1) / list.Limit,
+	}, list.Meta(total))
 }
 
 
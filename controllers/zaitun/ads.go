@@ -0,0 +1,163 @@
+package zaitun
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+type adSlot struct {
+	CampaignId *int    `json:"campaignId"`
+	Title      string  `json:"title"`
+	Img        string  `json:"img"`
+	Link       *string `json:"link"`
+}
+
+type articleAds struct {
+	Side  []*adSlot `json:"side"`
+	Below *adSlot   `json:"below"`
+}
+
+// legacyAds is the shape of articles.ads_json written before ad campaigns existed.
+type legacyAds struct {
+	Side  []string `json:"side"`
+	Below string   `json:"below"`
+}
+
+// getArticleAds returns the ads of active campaigns targeting the article, its
+// edition or every article, followed by the static images kept in ads_json.
+func (c *ZaitunController) getArticleAds(_context context.Context, articleId int, editionId int, adsJSON string) (*articleAds, error) {
+	ads := &articleAds{Side: []*adSlot{}}
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT cp.id, cp.placement, cr.title, cr.img, cr.link_url
+		FROM ad_campaigns cp
+		JOIN ad_creatives cr ON cr.id = cp.creative_id
+		WHERE cp.start_date <= now() AND cp.end_date >= now()
+		AND (cp.article_id = ?
+			OR (cp.article_id IS NULL AND cp.edition_id = ?)
+			OR (cp.article_id IS NULL AND cp.edition_id IS NULL))
+		ORDER BY cp.priority DESC, cp.id ASC`, articleId, editionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var slot adSlot
+		var campaignId int
+		var placement string
+		if err := rows.Scan(&campaignId, &placement, &slot.Title, &slot.Img, &slot.Link); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		slot.CampaignId = &campaignId
+		switch placement {
+		case "side":
+			ads.Side = append(ads.Side, &slot)
+		case "below":
+			if ads.Below == nil {
+				ads.Below = &slot
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var legacy legacyAds
+	if adsJSON != "" {
+		if err := json.Unmarshal([]byte(adsJSON), &legacy); err != nil {
+			log.Println(err.Error())
+		}
+	}
+	for _, img := range legacy.Side {
+		if img != "" {
+			ads.Side = append(ads.Side, &adSlot{Img: img})
+		}
+	}
+	if ads.Below == nil && legacy.Below != "" {
+		ads.Below = &adSlot{Img: legacy.Below}
+	}
+
+	return ads, nil
+}
+
+func (c *ZaitunController) RecordAdImpression(ctx *gin.Context) {
+	campaignId, err := strconv.Atoi(ctx.Param("campaignId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid campaign id", nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.db.ExecContext(_context, `
+		INSERT INTO ad_stats (campaign_id, day, impressions)
+		SELECT id, CURRENT_DATE(), 1 FROM ad_campaigns
+		WHERE id = ? AND start_date <= now() AND end_date >= now()
+		ON DUPLICATE KEY UPDATE impressions = impressions + 1`, campaignId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "campaign not found or inactive", nil)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// RecordAdClick counts the click and redirects the reader to the advertiser.
+func (c *ZaitunController) RecordAdClick(ctx *gin.Context) {
+	campaignId, err := strconv.Atoi(ctx.Param("campaignId"))
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, "invalid campaign id", nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// only running campaigns count clicks, like impressions
+	var link sql.NullString
+	err = c.db.QueryRowContext(_context, `
+		SELECT cr.link_url FROM ad_campaigns cp
+		JOIN ad_creatives cr ON cr.id = cp.creative_id
+		WHERE cp.id = ? AND cp.start_date <= now() AND cp.end_date >= now()`, campaignId).Scan(&link)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows || (err == nil && (!link.Valid || link.String == "")) {
+		c.res.AbortAdNotFound(ctx, lib.ErrAdNotFound, "campaign not found, inactive or without a link", nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	if _, err := c.db.ExecContext(_context, `
+		INSERT INTO ad_stats (campaign_id, day, clicks)
+		VALUES (?, CURRENT_DATE(), 1)
+		ON DUPLICATE KEY UPDATE clicks = clicks + 1`, campaignId); err != nil {
+		// still send the reader to the advertiser
+		log.Println(err.Error())
+	}
+
+	ctx.Redirect(http.StatusFound, link.String)
+}
@@ -0,0 +1,163 @@
+package zaitun
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// GetArchive lists published editions grouped by edition_year, newest first
+// unless ?sort=oldest. Paging applies to editions, ?year= narrows the list to
+// one year.
+func (c *ZaitunController) GetArchive(ctx *gin.Context) {
+	if year := ctx.Query("year"); year != "" {
+		if _, err := strconv.Atoi(year); err != nil {
+			c.res.AbortInvalidYear(ctx, err, err.Error(), nil)
+			return
+		}
+	}
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 12,
+		MaxLimit:     50,
+		// grouping needs the year to lead the order, editions of a year follow
+		// by publish date; one expression keeps the cursor a single value
+		Sorts:       map[string]string{"year": "CONCAT(e.edition_year, ' ', e.published_at)"},
+		DefaultSort: "-year",
+		SortAliases: map[string]string{"newest": "-year", "oldest": "year"},
+		Filters:     map[string]string{"year": "e.edition_year"},
+		IdColumn:    "e.id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type editionResponseModel struct {
+		Id           int        `json:"id"`
+		Title        string     `json:"title"`
+		PublishedAt  *time.Time `json:"publishedAt"`
+		CoverImg     *string    `json:"coverImg"`
+		ThumbnailImg *string    `json:"thumbImg"`
+		Articles     int        `json:"articleCount"`
+	}
+	type yearResponseModel struct {
+		Year     int                     `json:"year"`
+		Editions []*editionResponseModel `json:"editions"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context,
+		"SELECT COUNT(e.id) FROM editions e WHERE "+publishedEdition+filter, filterArgs...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
+	rows, err := c.db.QueryContext(_context, fmt.Sprintf(`
+		SELECT e.id, e.title, e.published_at, e.edition_year, e.cover_img, e.thumb_img, COUNT(a.id), %s
+		FROM editions e
+		LEFT JOIN articles a ON a.edition_id = e.id AND %s
+		WHERE %s%s
+		GROUP BY e.id
+		ORDER BY %s %s`, list.SortColumn(), publishedArticle, publishedEdition, where, list.OrderBy(), paginate),
+		append(args, paginateArgs...)...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	years := []*yearResponseModel{}
+	scanned := 0
+	for rows.Next() {
+		var edition editionResponseModel
+		var publishedAt []uint8
+		var year int
+		var sortValue sql.NullString
+		if err := rows.Scan(
+			&edition.Id,
+			&edition.Title,
+			&publishedAt,
+			&year,
+			&edition.CoverImg,
+			&edition.ThumbnailImg,
+			&edition.Articles,
+			&sortValue,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		list.Scanned(sortValue, edition.Id)
+		// the extra row fetched by Paginate only tells whether another page follows
+		if scanned++; scanned > list.Limit {
+			continue
+		}
+		edition.PublishedAt = lib.Base64ToTime(publishedAt)
+		// rows arrive sorted by year, so a new year always starts a new group
+		if len(years) == 0 || years[len(years)-1].Year != year {
+			years = append(years, &yearResponseModel{Year: year, Editions: []*editionResponseModel{}})
+		}
+		years[len(years)-1].Editions = append(years[len(years)-1].Editions, &edition)
+	}
+
+	c.res.SuccessWithMeta(ctx, nil, gin.H{"years": years}, list.Meta(total))
+}
+
+// GetArchiveYears lists the years that have published editions, for navigation.
+func (c *ZaitunController) GetArchiveYears(ctx *gin.Context) {
+	type yearResponseModel struct {
+		Year     int `json:"year"`
+		Editions int `json:"editionCount"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT e.edition_year, COUNT(e.id)
+		FROM editions e
+		WHERE `+publishedEdition+`
+		GROUP BY e.edition_year
+		ORDER BY e.edition_year DESC`)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	years := []*yearResponseModel{}
+	for rows.Next() {
+		var year yearResponseModel
+		if err := rows.Scan(&year.Year, &year.Editions); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		years = append(years, &year)
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"years": years})
+}
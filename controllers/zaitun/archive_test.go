@@ -0,0 +1,130 @@
+package zaitun
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// edition 4 is published in 2024 before edition 1 but has the higher id,
+// edition 5 is the only published edition of 2023
+var archiveSeed = `INSERT INTO editions (id, title, published_at, archived_at, edition_year, cover_img, thumb_img) VALUES
+	(4, 'Epifani', '2024-01-07 00:00:00', NULL, 2024, '', ''),
+	(5, 'Pentakosta', '2023-05-28 00:00:00', NULL, 2023, '', '')`
+
+// getArchive requests an archive page and returns its raw data block, the
+// edition ids in the order served and the list meta.
+func getArchive(t *testing.T, app *gin.Engine, query string) (map[string]json.RawMessage, []int, lib.ListMeta) {
+	t.Helper()
+	w := get(t, app, "/api/archive"+query)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/archive%s: status %d, body %s", query, w.Code, w.Body.String())
+	}
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+		Meta lib.ListMeta               `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	var years []struct {
+		Editions []idModel `json:"editions"`
+	}
+	if err := json.Unmarshal(body.Data["years"], &years); err != nil {
+		t.Fatal(err)
+	}
+	order := []int{}
+	for _, year := range years {
+		for _, edition := range year.Editions {
+			order = append(order, edition.Id)
+		}
+	}
+	return body.Data, order, body.Meta
+}
+
+func TestArchiveOrder(t *testing.T) {
+	app := newSeededRouter(t, archiveSeed)
+
+	for _, tc := range []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{EDITION_PUBLISHED, 4, 5}},
+		{"?sort=newest", []int{EDITION_PUBLISHED, 4, 5}},
+		{"?sort=oldest", []int{5, 4, EDITION_PUBLISHED}},
+		{"?year=2024", []int{EDITION_PUBLISHED, 4}},
+		{"?year=2024&sort=oldest", []int{4, EDITION_PUBLISHED}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			data, order, meta := getArchive(t, app, tc.query)
+			if !slices.Equal(order, tc.want) {
+				t.Errorf("got editions %v, want %v", order, tc.want)
+			}
+			if len(data) != 1 || data["years"] == nil {
+				t.Errorf("data has keys %v, want only years", slices.Sorted(maps.Keys(data)))
+			}
+			if meta.Total != len(tc.want) {
+				t.Errorf("meta.total %d, want %d", meta.Total, len(tc.want))
+			}
+		})
+	}
+}
+
+func TestArchiveCursor(t *testing.T) {
+	app := newSeededRouter(t, archiveSeed)
+
+	for _, sort := range []string{"newest", "oldest"} {
+		t.Run(sort, func(t *testing.T) {
+			_, want, _ := getArchive(t, app, "?sort="+sort)
+			got := []int{}
+			cursor := ""
+			for range want {
+				_, page, meta := getArchive(t, app, "?limit=1&sort="+sort+"&cursor="+url.QueryEscape(cursor))
+				got = append(got, page...)
+				if meta.NextCursor == nil {
+					break
+				}
+				cursor = *meta.NextCursor
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("paged through %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestArchiveVisibility(t *testing.T) {
+	app := newSeededRouter(t)
+
+	var archive struct {
+		Years []struct {
+			Year     int `json:"year"`
+			Editions []struct {
+				Id       int `json:"id"`
+				Articles int `json:"articleCount"`
+			} `json:"editions"`
+		} `json:"years"`
+	}
+	getData(t, app, "/api/archive", &archive)
+	if len(archive.Years) != 1 || len(archive.Years[0].Editions) != 1 ||
+		archive.Years[0].Editions[0].Id != EDITION_PUBLISHED || archive.Years[0].Editions[0].Articles != 1 {
+		t.Errorf("archive: got %+v, want edition 1 with one article", archive.Years)
+	}
+
+	var years struct {
+		Years []struct {
+			Year     int `json:"year"`
+			Editions int `json:"editionCount"`
+		} `json:"years"`
+	}
+	getData(t, app, "/api/archive/years", &years)
+	if len(years.Years) != 1 || years.Years[0].Year != 2024 || years.Years[0].Editions != 1 {
+		t.Errorf("archive years: got %+v, want 2024 with one edition", years.Years)
+	}
+}
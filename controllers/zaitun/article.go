@@ -10,6 +10,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// articleThumbText falls back to the excerpt SaveDraft computes from the
+// contents when editors leave thumb_text empty.
+const articleThumbText = `COALESCE(NULLIF(a.thumb_text, ''), a.excerpt, '')`
+
 func (c *ZaitunController) GetArticlesByCategory(ctx *gin.Context) {
 	category := ctx.Query("category")
 	if category == "" {
@@ -21,16 +25,51 @@ func (c *ZaitunController) GetArticlesByCategory(ctx *gin.Context) {
 		c.res.AbortInvalidCategory(ctx, err, err.Error(), nil)
 		return
 	}
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		Sorts:        map[string]string{"publishedAt": "a.published_date", "title": "a.title"},
+		DefaultSort:  "-publishedAt",
+		Filters:      map[string]string{"editionId": "a.edition_id", "writerId": "a.writer_id"},
+		Search:       []string{"a.title"},
+		IdColumn:     "a.id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
 
 	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
 	defer cancel()
 
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context, `
+	SELECT COUNT(a.id) FROM articles a
+	JOIN editions e ON e.id = a.edition_id
+	WHERE a.category_id = ? AND `+publishedArticle+filter,
+		append([]any{_catId}, filterArgs...)...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
 	rows, err := c.db.QueryContext(_context, `
-	SELECT a.id, a.title, slug, w.writer_name, published_date, a.thumb_img, a.thumb_text, a.edition_id, e.edition_year
+	SELECT a.id, a.title, slug, w.writer_name, published_date, a.thumb_img, `+articleThumbText+`,
+	a.word_count, a.reading_time, a.edition_id, e.edition_year,
+	`+list.SortColumn()+`
 	FROM articles as a
 	JOIN writers w ON w.id = a.writer_id
 	JOIN editions e ON e.id = a.edition_id
-	WHERE category_id = ? AND published_date IS NOT NULL`, _catId)
+	WHERE a.category_id = ? AND `+publishedArticle+where+`
+	ORDER BY `+list.OrderBy()+` `+paginate,
+		append(append([]any{_catId}, args...), paginateArgs...)...)
 	if _context.Err() == context.DeadlineExceeded {
 		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
 		return
@@ -42,15 +81,18 @@ func (c *ZaitunController) GetArticlesByCategory(ctx *gin.Context) {
 	defer rows.Close()
 
 	type articleResponseModel struct {
-		Id           int        `json:"id"`
-		Title        string     `json:"title"`
-		Slug         string     `json:"slug"`
-		Writer       string     `json:"writerName"`
-		PublisedDate *time.Time `json:"publishedAt"`
-		ThumbImg     string     `json:"thumbImg"`
-		ThumbText    string     `json:"thumbText"`
-		EditionId    int        `json:"editionId"`
-		EditionYear  int        `json:"year"`
+		Id           int                `json:"id"`
+		Title        string             `json:"title"`
+		Slug         string             `json:"slug"`
+		Writer       string             `json:"writerName"`
+		PublisedDate *time.Time         `json:"publishedAt"`
+		ThumbImg     string             `json:"thumbImg"`
+		ThumbText    string             `json:"thumbText"`
+		WordCount    int                `json:"wordCount"`
+		ReadingTime  int                `json:"readingTime"`
+		EditionId    int                `json:"editionId"`
+		EditionYear  int                `json:"year"`
+		Contributors []*lib.Contributor `json:"contributors"`
 	}
 
 	articles := []*articleResponseModel{}
@@ -58,6 +100,7 @@ func (c *ZaitunController) GetArticlesByCategory(ctx *gin.Context) {
 	for rows.Next() {
 		var result articleResponseModel
 		var publishedDate []uint8
+		var sortValue sql.NullString
 		rows.Scan(
 			&result.Id,
 			&result.Title,
@@ -66,14 +109,36 @@ func (c *ZaitunController) GetArticlesByCategory(ctx *gin.Context) {
 			&publishedDate,
 			&result.ThumbImg,
 			&result.ThumbText,
+			&result.WordCount,
+			&result.ReadingTime,
 			&result.EditionId,
 			&result.EditionYear,
+			&sortValue,
 		)
 		result.PublisedDate = lib.Base64ToTime(publishedDate)
+		list.Scanned(sortValue, result.Id)
 		articles = append(articles, &result)
 	}
+	articles = articles[:list.Trim()]
 
-	c.res.SuccessWithStatusOKJSON(ctx, nil, articles)
+	ids := []int{}
+	for _, article := range articles {
+		ids = append(ids, article.Id)
+	}
+	contributors, err := lib.GetContributorsOf(_context, c.db, ids)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	for _, article := range articles {
+		article.Contributors = contributors[article.Id]
+	}
+
+	c.res.SuccessWithMeta(ctx, nil, articles, list.Meta(total))
 }
 
 func (c *ZaitunController) GetArticleBySlug(ctx *gin.Context) {
@@ -91,34 +156,46 @@ func (c *ZaitunController) GetArticleBySlug(ctx *gin.Context) {
 		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
 		return
 	}
+	preview, ok := c.previewFromQuery(ctx)
+	if !ok {
+		return
+	}
 
+	// ads merges the running campaigns with the images of ads_json, adsJSON
+	// keeps the raw column for clients that still parse it
 	type articleResponseModel struct {
-		Id           int        `json:"id"`
-		Title        string     `json:"title"`
-		Slug         string     `json:"slug"`
-		Writer       string     `json:"writerName"`
-		PublisedDate *time.Time `json:"publishedAt"`
-		HeadlineImg  string     `json:"coverImg"`
-		ThumbImg     string     `json:"thumbImg"`
-		ThumbText    string     `json:"thumbText"`
-		Label        string     `json:"label"`
-		ContentJSON  string     `json:"contents"`
-		AdsJSON      string     `json:"ads"`
+		Id           int                `json:"id"`
+		Title        string             `json:"title"`
+		Slug         string             `json:"slug"`
+		Writer       string             `json:"writerName"`
+		Contributors []*lib.Contributor `json:"contributors"`
+		PublisedDate *time.Time         `json:"publishedAt"`
+		HeadlineImg  string             `json:"coverImg"`
+		ThumbImg     string             `json:"thumbImg"`
+		ThumbText    string             `json:"thumbText"`
+		WordCount    int                `json:"wordCount"`
+		ReadingTime  int                `json:"readingTime"`
+		Label        string             `json:"label"`
+		ContentJSON  string             `json:"contents"`
+		Ads          *articleAds        `json:"ads"`
+		AdsJSON      string             `json:"adsJSON"`
 	}
 
 	var article articleResponseModel
 	var publishedDate []uint8
 
+	visible, visibleArgs := visibleArticle(preview)
+	args := append([]any{slug, parsedYear, parsedEditionId}, visibleArgs...)
 	err = c.db.QueryRow(
 		`
 		SELECT a.id, a.title, slug, w.writer_name, published_date, a.cover_img, c.label,
-		content_json, ads_json, a.thumb_img, a.thumb_text
+		content_json, ads_json, a.thumb_img, `+articleThumbText+`, a.word_count, a.reading_time
 		FROM articles a
 		JOIN writers w ON w.id = a.writer_id
 		JOIN categories c ON c.id = a.category_id
 		JOIN editions e ON e.id = a.edition_id
-		WHERE slug=? AND e.edition_year=? AND a.edition_id=?`,
-		slug, parsedYear, parsedEditionId).Scan(
+		WHERE slug=? AND e.edition_year=? AND a.edition_id=? AND `+visible,
+		args...).Scan(
 		&article.Id,
 		&article.Title,
 		&article.Slug,
@@ -130,6 +207,8 @@ func (c *ZaitunController) GetArticleBySlug(ctx *gin.Context) {
 		&article.AdsJSON,
 		&article.ThumbImg,
 		&article.ThumbText,
+		&article.WordCount,
+		&article.ReadingTime,
 	)
 
 	article.PublisedDate = lib.Base64ToTime(publishedDate)
@@ -143,6 +222,18 @@ func (c *ZaitunController) GetArticleBySlug(ctx *gin.Context) {
 		return
 	}
 
+	article.Contributors, err = lib.GetArticleContributors(ctx.Request.Context(), c.db, article.Id)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	article.Ads, err = c.getArticleAds(ctx.Request.Context(), article.Id, parsedEditionId, article.AdsJSON)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
 	c.res.SuccessWithStatusOKJSON(ctx, nil, article)
 }
 
@@ -159,11 +250,12 @@ func (c *ZaitunController) GetTopArticles(ctx *gin.Context) {
 	}
 
 	rows, err := c.db.Query(`
-		SELECT a.id, a.title, slug, w.writer_name, published_date, a.thumb_img, a.thumb_text, a.edition_id, e.edition_year
+		SELECT a.id, a.title, slug, w.writer_name, published_date, a.thumb_img, `+articleThumbText+`,
+			a.word_count, a.reading_time, a.edition_id, e.edition_year
 		FROM articles as a
 		JOIN writers w ON w.id = a.writer_id
 		JOIN editions e ON e.id = a.edition_id
-		WHERE is_top_content = true AND published_date IS NOT NULL AND a.edition_id = ?`, parsedEditionId)
+		WHERE a.is_top_content = true AND a.edition_id = ? AND `+publishedArticle, parsedEditionId)
 	if err != nil {
 		c.res.AbortDatabaseError(ctx, err, nil)
 		return
@@ -171,15 +263,18 @@ func (c *ZaitunController) GetTopArticles(ctx *gin.Context) {
 	defer rows.Close()
 
 	type articleResponseModel struct {
-		Id           int        `json:"id"`
-		Title        string     `json:"title"`
-		Slug         string     `json:"slug"`
-		Writer       string     `json:"writerName"`
-		PublisedDate *time.Time `json:"publishedAt"`
-		ThumbImg     string     `json:"thumbImg"`
-		ThumbText    string     `json:"thumbText"`
-		EditionId    int        `json:"editionId"`
-		EditionYear  int        `json:"year"`
+		Id           int                `json:"id"`
+		Title        string             `json:"title"`
+		Slug         string             `json:"slug"`
+		Writer       string             `json:"writerName"`
+		PublisedDate *time.Time         `json:"publishedAt"`
+		ThumbImg     string             `json:"thumbImg"`
+		ThumbText    string             `json:"thumbText"`
+		WordCount    int                `json:"wordCount"`
+		ReadingTime  int                `json:"readingTime"`
+		EditionId    int                `json:"editionId"`
+		EditionYear  int                `json:"year"`
+		Contributors []*lib.Contributor `json:"contributors"`
 	}
 
 	articles := []*articleResponseModel{}
@@ -195,6 +290,8 @@ func (c *ZaitunController) GetTopArticles(ctx *gin.Context) {
 			&publishedDate,
 			&result.ThumbImg,
 			&result.ThumbText,
+			&result.WordCount,
+			&result.ReadingTime,
 			&result.EditionId,
 			&result.EditionYear,
 		)
@@ -202,5 +299,18 @@ func (c *ZaitunController) GetTopArticles(ctx *gin.Context) {
 		articles = append(articles, &result)
 	}
 
+	ids := []int{}
+	for _, article := range articles {
+		ids = append(ids, article.Id)
+	}
+	contributors, err := lib.GetContributorsOf(ctx.Request.Context(), c.db, ids)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	for _, article := range articles {
+		article.Contributors = contributors[article.Id]
+	}
+
 	c.res.SuccessWithStatusOKJSON(ctx, nil, articles)
 }
@@ -0,0 +1,254 @@
+package zaitun
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+const COMMENT_ANONYMOUS = "Anonim"
+const COMMENT_MAX_LENGTH = 2000
+
+type commentResponseModel struct {
+	Id         int                     `json:"id"`
+	ParentId   *int                    `json:"parentId"`
+	AuthorName string                  `json:"authorName"`
+	Body       string                  `json:"body"`
+	CreatedAt  *time.Time              `json:"createdAt"`
+	Replies    []*commentResponseModel `json:"replies"`
+}
+
+// publishedArticleId resolves the public address of an article, comments are
+// only shown on and accepted for published articles.
+func (c *ZaitunController) publishedArticleId(ctx *gin.Context, _context context.Context) (int, bool) {
+	year, err := strconv.Atoi(ctx.Param("year"))
+	if err != nil {
+		c.res.AbortInvalidYear(ctx, err, err.Error(), nil)
+		return 0, false
+	}
+	editionId, err := strconv.Atoi(ctx.Param("editionId"))
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return 0, false
+	}
+
+	var articleId int
+	err = c.db.QueryRowContext(_context, `
+		SELECT a.id FROM articles a
+		JOIN editions e ON e.id = a.edition_id
+		WHERE a.slug = ? AND e.edition_year = ? AND a.edition_id = ? AND `+publishedArticle,
+		ctx.Param("slug"), year, editionId).Scan(&articleId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return 0, false
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortArticleNotFound(ctx, err, err.Error(), nil)
+		return 0, false
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return 0, false
+	}
+	return articleId, true
+}
+
+// GetArticleComments returns the approved comments of an article as threads,
+// oldest first. Replies whose parent is not approved are left out.
+func (c *ZaitunController) GetArticleComments(ctx *gin.Context) {
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	articleId, ok := c.publishedArticleId(ctx, _context)
+	if !ok {
+		return
+	}
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT id, parent_id, author_name, body, created_at
+		FROM article_comments
+		WHERE article_id = ? AND status = ?
+		ORDER BY created_at ASC, id ASC`, articleId, lib.COMMENT_APPROVED)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	comments := []*commentResponseModel{}
+	byId := map[int]*commentResponseModel{}
+	total := 0
+	for rows.Next() {
+		comment := commentResponseModel{Replies: []*commentResponseModel{}}
+		var createdAt []uint8
+		if err := rows.Scan(
+			&comment.Id,
+			&comment.ParentId,
+			&comment.AuthorName,
+			&comment.Body,
+			&createdAt,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		comment.CreatedAt = lib.Base64ToTime(createdAt)
+
+		// parents are always older than their replies, so they are already mapped
+		if comment.ParentId == nil {
+			comments = append(comments, &comment)
+		} else if parent, ok := byId[*comment.ParentId]; ok {
+			parent.Replies = append(parent.Replies, &comment)
+		} else {
+			continue
+		}
+		byId[comment.Id] = &comment
+		total++
+	}
+
+	c.res.SuccessWithStatusOKJSON(ctx, nil, gin.H{"comments": comments, "total": total})
+}
+
+// PostArticleComment queues a reader comment for moderation. Readers may stay
+// anonymous; likely spam is queued as spam instead of pending. Request bodies
+// carry email addresses and are kept out of the logs.
+func (c *ZaitunController) PostArticleComment(ctx *gin.Context) {
+	type reqBody struct {
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Body     string `json:"body"`
+		ParentId *int   `json:"parentId"`
+		lib.Honeypot
+	}
+	var payload reqBody
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	name := strings.TrimSpace(payload.Name)
+	if name == "" {
+		name = COMMENT_ANONYMOUS
+	}
+	if utf8.RuneCountInString(name) > 64 {
+		err := errors.New("name must be at most 64 characters")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+	var email *string
+	if e := strings.TrimSpace(payload.Email); e != "" {
+		addr, err := mail.ParseAddress(e)
+		if err != nil || addr.Address != e {
+			c.res.AbortInvalidRequestBody(ctx, lib.ErrInvalidBody, "invalid email address", nil)
+			return
+		}
+		e = strings.ToLower(e)
+		email = &e
+	}
+	body := strings.TrimSpace(payload.Body)
+	if n := utf8.RuneCountInString(body); n < 2 || n > COMMENT_MAX_LENGTH {
+		err := errors.New("comment must be between 2 and 2000 characters")
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	accepted := gin.H{"message": "comment submitted for moderation"}
+	ipHash, ok := c.res.GuardSubmission(ctx, payload.Honeypot, lib.CommentRateLimiter, lib.CLIENT_COMMENT, accepted)
+	if !ok {
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	articleId, ok := c.publishedArticleId(ctx, _context)
+	if !ok {
+		return
+	}
+
+	var banned bool
+	err := c.db.QueryRowContext(_context, `
+		SELECT EXISTS(SELECT 1 FROM comment_bans
+		WHERE (kind = ? AND value = ?) OR (kind = ? AND value = ?))`,
+		lib.BAN_IP, ipHash, lib.BAN_EMAIL, email).Scan(&banned)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if banned {
+		err := errors.New("commenting is not allowed")
+		c.res.AbortWithStatusJSON(ctx, err, err.Error(), "", http.StatusForbidden, nil)
+		return
+	}
+
+	if payload.ParentId != nil {
+		var parentOk bool
+		err := c.db.QueryRowContext(_context, `
+			SELECT EXISTS(SELECT 1 FROM article_comments WHERE id = ? AND article_id = ? AND status = ?)`,
+			*payload.ParentId, articleId, lib.COMMENT_APPROVED).Scan(&parentOk)
+		if err != nil {
+			c.res.AbortDatabaseError(ctx, err, nil)
+			return
+		}
+		if !parentOk {
+			err := errors.New("parent comment does not exist on this article")
+			c.res.AbortInvalidComment(ctx, err, err.Error(), nil)
+			return
+		}
+	}
+
+	score, reasons := lib.CommentSpamScore(name, body)
+	var duplicate bool
+	err = c.db.QueryRowContext(_context, `
+		SELECT EXISTS(SELECT 1 FROM article_comments
+		WHERE ip_hash = ? AND body = ? AND created_at > ?)`,
+		ipHash, body, time.Now().UTC().Add(-24*time.Hour)).Scan(&duplicate)
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	if duplicate {
+		score += lib.COMMENT_SPAM_SCORE
+		reasons = append(reasons, "duplicate")
+	}
+	status := lib.COMMENT_PENDING
+	if score >= lib.COMMENT_SPAM_SCORE {
+		status = lib.COMMENT_SPAM
+	}
+
+	result, err := c.db.ExecContext(_context, `
+		INSERT INTO article_comments
+		(article_id, parent_id, author_name, author_email, body, status, spam_score, spam_reasons, ip_hash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		articleId, payload.ParentId, name, email, body, status, score,
+		strings.Join(reasons, ", "), ipHash, time.Now().UTC())
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	id, _ := result.LastInsertId()
+	accepted["id"] = id
+
+	c.res.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, accepted)
+}
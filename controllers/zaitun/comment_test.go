@@ -0,0 +1,43 @@
+package zaitun
+
+import (
+	"net/http"
+	"testing"
+)
+
+var commentSeed = `INSERT INTO article_comments (id, article_id, parent_id, author_name, body, status, ip_hash, created_at) VALUES
+	(1, 101, NULL, 'Ana', 'Berkah Dalem', 'approved', '', '2024-03-02 08:00:00'),
+	(2, 101, NULL, 'Budi', 'Menunggu', 'pending', '', '2024-03-02 09:00:00'),
+	(3, 101, 1, 'Citra', 'Amin', 'approved', '', '2024-03-02 10:00:00'),
+	(4, 101, 2, 'Dewi', 'Balasan', 'approved', '', '2024-03-02 11:00:00'),
+	(5, 101, NULL, 'Eko', 'Iklan', 'spam', '', '2024-03-02 12:00:00'),
+	(6, 103, NULL, 'Fajar', 'Arsip', 'approved', '', '2024-03-02 13:00:00')`
+
+func TestArticleCommentsVisibility(t *testing.T) {
+	app := newSeededRouter(t, commentSeed)
+
+	for article, slug := range slugs {
+		status := http.StatusNotFound
+		if article == ARTICLE_PUBLISHED {
+			status = http.StatusOK
+		}
+		path := "/api/articles" + slug + "/comments"
+		if w := get(t, app, path); w.Code != status {
+			t.Errorf("GET %s: status %d, want %d", path, w.Code, status)
+		}
+	}
+
+	// only approved comments show, and replies only under an approved parent
+	var comments struct {
+		Comments []struct {
+			idModel
+			Replies []idModel `json:"replies"`
+		} `json:"comments"`
+		Total int `json:"total"`
+	}
+	getData(t, app, "/api/articles"+slugs[ARTICLE_PUBLISHED]+"/comments", &comments)
+	if len(comments.Comments) != 1 || comments.Comments[0].Id != 1 || comments.Total != 2 {
+		t.Fatalf("comments: got %+v, want comment 1 alone, total 2", comments)
+	}
+	expectIds(t, "replies", ids(comments.Comments[0].Replies), 3)
+}
@@ -1,6 +1,7 @@
 package zaitun
 
 import (
+	"database/sql"
 	"log"
 	"strconv"
 	"time"
@@ -19,10 +20,16 @@ type EditionResponseModel struct {
 }
 
 func (c *ZaitunController) GetAllEditions(ctx *gin.Context) {
+	preview, ok := c.previewFromQuery(ctx)
+	if !ok {
+		return
+	}
+
+	visible, visibleArgs := visibleEdition(preview)
 	editions := []*EditionResponseModel{}
 	rows, err := c.db.Query(`
-		SELECT id, title, published_at, edition_year, cover_img, thumb_img
-		FROM editions WHERE published_at IS NOT NULL`)
+		SELECT e.id, e.title, e.published_at, e.edition_year, e.cover_img, e.thumb_img
+		FROM editions e WHERE `+visible, visibleArgs...)
 	if err != nil {
 		c.res.AbortDatabaseError(ctx, err, nil)
 		return
@@ -56,6 +63,10 @@ func (c *ZaitunController) GetEditionById(ctx *gin.Context) {
 		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
 		return
 	}
+	preview, ok := c.previewFromQuery(ctx)
+	if !ok {
+		return
+	}
 
 	type categoryResponseModel struct {
 		Id    int    `json:"id"`
@@ -72,15 +83,20 @@ func (c *ZaitunController) GetEditionById(ctx *gin.Context) {
 	}
 
 	var edition editionResponseModel
+	visible, visibleArgs := visibleEdition(preview)
 	err = c.db.QueryRow(`
-		SELECT id, title, edition_year, cover_img
-		FROM editions WHERE id = ?
-	`, _id).Scan(
+		SELECT e.id, e.title, e.edition_year, e.cover_img
+		FROM editions e WHERE e.id = ? AND `+visible,
+		append([]any{_id}, visibleArgs...)...).Scan(
 		&edition.Id,
 		&edition.Title,
 		&edition.EditionYear,
 		&edition.CoverIng,
 	)
+	if err == sql.ErrNoRows {
+		c.res.AbortEditionNotFound(ctx, err, err.Error(), nil)
+		return
+	}
 	if err != nil {
 		c.res.AbortDatabaseError(ctx, err, nil)
 		return
@@ -0,0 +1,55 @@
+package zaitun
+
+import "github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+
+// categoryGroup holds the articles of one category of an edition.
+type categoryGroup[T any] struct {
+	id    int
+	label string
+	order int
+	items []T
+}
+
+// editionGroups places the articles of an edition under its categories, in
+// the order the categories are added. Articles left in Uncategorized, or in a
+// category the edition does not have, are collected in a last group.
+type editionGroups[T any] struct {
+	groups []*categoryGroup[T]
+	byId   map[int]*categoryGroup[T]
+	others *categoryGroup[T]
+}
+
+func newEditionGroups[T any]() *editionGroups[T] {
+	return &editionGroups[T]{
+		byId:   map[int]*categoryGroup[T]{},
+		others: &categoryGroup[T]{id: lib.UNCATEGORIZED, label: "Lainnya", items: []T{}},
+	}
+}
+
+func (g *editionGroups[T]) addCategory(id int, label string, order int) {
+	if id == lib.UNCATEGORIZED {
+		return
+	}
+	group := &categoryGroup[T]{id: id, label: label, order: order, items: []T{}}
+	g.byId[id] = group
+	g.groups = append(g.groups, group)
+}
+
+func (g *editionGroups[T]) add(categoryId int, item T) {
+	group, ok := g.byId[categoryId]
+	if !ok {
+		group = g.others
+	}
+	group.items = append(group.items, item)
+}
+
+// list returns the groups in order, followed by the Uncategorized group when
+// it has any articles.
+func (g *editionGroups[T]) list() []*categoryGroup[T] {
+	groups := g.groups
+	if len(g.others.items) > 0 {
+		g.others.order = len(groups)
+		groups = append(groups[:len(groups):len(groups)], g.others)
+	}
+	return groups
+}
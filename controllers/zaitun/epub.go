@@ -0,0 +1,204 @@
+package zaitun
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/services"
+	"github.com/gin-gonic/gin"
+)
+
+// epubImageTypes are the image core media types every EPUB 3 reader supports,
+// with the file extension used for them.
+var epubImageTypes = map[string]string{
+	"image/jpeg":    "jpg",
+	"image/png":     "png",
+	"image/gif":     "gif",
+	"image/webp":    "webp",
+	"image/svg+xml": "svg",
+}
+
+// epubImageType returns the media type of an image for the package manifest.
+// Objects uploaded without an image Content-Type are sniffed instead; ok is
+// false when the image is not a core media type and must be left out.
+func epubImageType(contentType string, data []byte) (string, bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if _, ok := epubImageTypes[mediaType]; ok {
+		return mediaType, true
+	}
+	mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	_, ok := epubImageTypes[mediaType]
+	return mediaType, ok
+}
+
+func (c *ZaitunController) GetEditionEpub(ctx *gin.Context) {
+	editionId := ctx.Param("editionId")
+	_id, err := strconv.Atoi(editionId)
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	var title, coverImg string
+	var year int
+	var publishedAt []uint8
+	err = c.db.QueryRowContext(_context, `
+		SELECT e.title, e.edition_year, e.cover_img, e.published_at
+		FROM editions e WHERE e.id = ? AND `+publishedEdition, _id).Scan(
+		&title, &year, &coverImg, &publishedAt)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortEditionNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	book := &lib.EpubBook{
+		Identifier: fmt.Sprintf("urn:zaitun:edition:%d", _id),
+		Title:      fmt.Sprintf("Zaitun %s (%d)", title, year),
+		Language:   "id",
+		Publisher:  "Paroki Kosambi Baru",
+		Modified:   time.Now().UTC(),
+	}
+	if t := lib.Base64ToTime(publishedAt); t != nil {
+		book.Modified = *t
+	}
+
+	// the cover and every image share one storage client
+	var objects services.ObjectReader
+	defer objects.Close()
+
+	if obj := services.ObjectPathFromURL(coverImg); obj != "" {
+		data, contentType, err := objects.Read(_context, obj)
+		if err != nil {
+			log.Println("epub: failed to read cover:", err.Error())
+		} else if mediaType, ok := epubImageType(contentType, data); !ok {
+			log.Printf("epub: cover %s is not an EPUB image (%s)\n", obj, mediaType)
+		} else {
+			book.Cover = &lib.EpubResource{
+				Href:      "images/cover." + epubImageTypes[mediaType],
+				MediaType: mediaType,
+				Data:      data,
+			}
+		}
+	}
+
+	catRows, err := c.db.QueryContext(_context, `
+		SELECT c.id, c.label FROM categories c
+		WHERE c.edition_id = ? ORDER BY c.order ASC`, _id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer catRows.Close()
+
+	groups := newEditionGroups[*lib.EpubChapter]()
+	for catRows.Next() {
+		var catId int
+		var label string
+		if err := catRows.Scan(&catId, &label); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		groups.addCategory(catId, label, 0)
+	}
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT a.title, w.writer_name, a.category_id, a.content_json
+		FROM articles a
+		JOIN writers w ON w.id = a.writer_id
+		JOIN editions e ON e.id = a.edition_id
+		WHERE a.edition_id = ? AND `+publishedArticle+`
+		ORDER BY a.published_date ASC`, _id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	images := map[string]string{}
+	for rows.Next() {
+		var chapter lib.EpubChapter
+		var catId int
+		var contentJSON sql.NullString
+		if err := rows.Scan(&chapter.Title, &chapter.Byline, &catId, &contentJSON); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+
+		contents, err := lib.ParseContents(contentJSON.String)
+		if err != nil {
+			log.Println("epub: invalid content_json:", err.Error())
+			contents = &lib.ArticleContents{}
+		}
+		chapter.Body = contents.ToXHTML(func(src string) string {
+			if href, ok := images[src]; ok {
+				return href
+			}
+			obj := services.ObjectPathFromURL(src)
+			if obj == "" {
+				return ""
+			}
+			data, contentType, err := objects.Read(_context, obj)
+			if err != nil {
+				log.Println("epub: failed to read image:", err.Error())
+				return ""
+			}
+			mediaType, ok := epubImageType(contentType, data)
+			if !ok {
+				log.Printf("epub: image %s is not an EPUB image (%s)\n", obj, mediaType)
+				images[src] = ""
+				return ""
+			}
+			href := fmt.Sprintf("images/img-%03d.%s", len(book.Resources)+1, epubImageTypes[mediaType])
+			book.Resources = append(book.Resources, &lib.EpubResource{
+				Href:      href,
+				MediaType: mediaType,
+				Data:      data,
+			})
+			images[src] = href
+			return href
+		})
+
+		groups.add(catId, &chapter)
+	}
+	for _, group := range groups.list() {
+		book.Sections = append(book.Sections, &lib.EpubSection{Title: group.label, Chapters: group.items})
+	}
+
+	var buf bytes.Buffer
+	if err := book.Write(&buf); err != nil {
+		c.res.AbortWithStatusJSON(ctx, err, "failed to build epub",
+			err.Error(), http.StatusInternalServerError, nil)
+		return
+	}
+
+	fileName := fmt.Sprintf("zaitun-%d-%d.epub", year, _id)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	c.res.SuccessWithData(ctx, "application/epub+zip", buf.Bytes(), fileName)
+}
@@ -0,0 +1,85 @@
+package zaitun
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+)
+
+func TestEpubImageType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	jpeg := []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00")
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		want        string
+		ok          bool
+	}{
+		{"stored type", "image/png", png, "image/png", true},
+		{"stored type with parameters", "image/jpeg; charset=binary", jpeg, "image/jpeg", true},
+		{"svg is kept", "image/svg+xml", []byte("<svg/>"), "image/svg+xml", true},
+		{"missing type is sniffed", "", png, "image/png", true},
+		{"generic type is sniffed", "application/octet-stream", jpeg, "image/jpeg", true},
+		{"non-core image type is sniffed", "image/bmp", png, "image/png", true},
+		{"not an image", "application/octet-stream", []byte("%PDF-1.7"), "application/pdf", false},
+		{"not a core image", "image/bmp", []byte("BM\x00\x00"), "image/bmp", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := epubImageType(test.contentType, test.data)
+			if got != test.want || ok != test.ok {
+				t.Errorf("epubImageType(%q) = %q, %v, want %q, %v", test.contentType, got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestEditionEpubVisibility(t *testing.T) {
+	app := newSeededRouter(t)
+
+	// exports never honour preview tokens
+	for edition, status := range map[int]int{
+		EDITION_PUBLISHED: http.StatusOK,
+		EDITION_DRAFT:     http.StatusNotFound,
+		EDITION_ARCHIVED:  http.StatusNotFound,
+	} {
+		for _, query := range []string{"", previewQuery(t, lib.PreviewEdition, edition)} {
+			path := fmt.Sprintf("/api/editions/%d/epub%s", edition, query)
+			if w := get(t, app, path); w.Code != status {
+				t.Errorf("GET %s: status %d, want %d", path, w.Code, status)
+			}
+		}
+	}
+
+	w := get(t, app, "/api/editions/1/epub")
+	if w.Code != http.StatusOK {
+		t.Fatalf("epub: status %d", w.Code)
+	}
+	book, err := zip.NewReader(strings.NewReader(w.Body.String()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chapters strings.Builder
+	for _, f := range book.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(&chapters, r)
+		r.Close()
+	}
+	for _, title := range []string{"Draf", "Arsip", "Sampah"} {
+		if strings.Contains(chapters.String(), "<h1>"+title+"</h1>") {
+			t.Errorf("epub contains hidden article %q", title)
+		}
+	}
+	if !strings.Contains(chapters.String(), "Terbit") {
+		t.Error("epub is missing the published article")
+	}
+}
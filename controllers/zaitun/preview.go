@@ -0,0 +1,21 @@
+package zaitun
+
+import (
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// previewFromQuery reads the optional ?preview= token. A missing token yields
+// nil claims; an invalid one aborts the request and returns false.
+func (c *ZaitunController) previewFromQuery(ctx *gin.Context) (*lib.PreviewClaims, bool) {
+	token := ctx.Query("preview")
+	if token == "" {
+		return nil, true
+	}
+	claims, err := lib.ParsePreviewToken(token)
+	if err != nil {
+		c.res.AbortInvalidPreview(ctx, err, err.Error(), nil)
+		return nil, false
+	}
+	return claims, true
+}
@@ -0,0 +1,155 @@
+package zaitun
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// GetArticleShareMeta describes a published article for link previews, see
+// lib.WriteShareMeta.
+func (c *ZaitunController) GetArticleShareMeta(ctx *gin.Context) {
+	year, err := strconv.Atoi(ctx.Param("year"))
+	if err != nil {
+		c.res.AbortInvalidYear(ctx, err, err.Error(), nil)
+		return
+	}
+	editionId, err := strconv.Atoi(ctx.Param("editionId"))
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return
+	}
+	slug := ctx.Param("slug")
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var meta lib.ShareMeta
+	var coverImg, thumbImg sql.NullString
+	var publishedDate []uint8
+	err = c.db.QueryRowContext(_context, `
+		SELECT a.title, `+articleThumbText+`, a.cover_img, a.thumb_img, a.published_date, w.writer_name
+		FROM articles a
+		JOIN writers w ON w.id = a.writer_id
+		JOIN editions e ON e.id = a.edition_id
+		WHERE a.slug = ? AND e.edition_year = ? AND a.edition_id = ? AND `+publishedArticle,
+		slug, year, editionId).Scan(
+		&meta.Title,
+		&meta.Description,
+		&coverImg,
+		&thumbImg,
+		&publishedDate,
+		&meta.Author,
+	)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortArticleNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	meta.Image = coverImg.String
+	if meta.Image == "" {
+		meta.Image = thumbImg.String
+	}
+	meta.PublishedAt = lib.Base64ToTime(publishedDate)
+	meta.Url = fmt.Sprintf("/zaitun/articles/%d/%d/%s", year, editionId, slug)
+	meta.Type = "article"
+	meta.SiteName = lib.SHARE_SITE_ZAITUN
+
+	c.res.WriteShareMeta(ctx, &meta)
+}
+
+// GetEditionShareMeta describes a published edition, listing its first
+// articles as the description.
+func (c *ZaitunController) GetEditionShareMeta(ctx *gin.Context) {
+	editionId, err := strconv.Atoi(ctx.Param("editionId"))
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var meta lib.ShareMeta
+	var year int
+	var coverImg, thumbImg sql.NullString
+	var publishedAt []uint8
+	err = c.db.QueryRowContext(_context, `
+		SELECT e.title, e.edition_year, e.cover_img, e.thumb_img, e.published_at
+		FROM editions e
+		WHERE e.id = ? AND `+publishedEdition, editionId).Scan(
+		&meta.Title,
+		&year,
+		&coverImg,
+		&thumbImg,
+		&publishedAt,
+	)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortEditionNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	rows, err := c.db.QueryContext(_context, `
+		SELECT a.title FROM articles a
+		JOIN editions e ON e.id = a.edition_id
+		WHERE a.edition_id = ? AND `+publishedArticle+`
+		ORDER BY a.is_top_content DESC, a.published_date ASC
+		LIMIT 5`, editionId)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	titles := []string{}
+	for rows.Next() {
+		var title string
+		if err := rows.Scan(&title); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		titles = append(titles, title)
+	}
+
+	meta.Description = fmt.Sprintf("Zaitun edisi %s (%d)", meta.Title, year)
+	if len(titles) > 0 {
+		meta.Description += ": " + strings.Join(titles, ", ")
+	}
+	meta.Image = coverImg.String
+	if meta.Image == "" {
+		meta.Image = thumbImg.String
+	}
+	meta.PublishedAt = lib.Base64ToTime(publishedAt)
+	meta.Url = fmt.Sprintf("/zaitun/editions/%d", editionId)
+	meta.Type = "website"
+	meta.SiteName = lib.SHARE_SITE_ZAITUN
+
+	c.res.WriteShareMeta(ctx, &meta)
+}
@@ -0,0 +1,54 @@
+package zaitun
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+)
+
+func TestShareMetaVisibility(t *testing.T) {
+	app := newSeededRouter(t)
+
+	// link previews never honour preview tokens
+	for article, slug := range slugs {
+		status := http.StatusNotFound
+		if article == ARTICLE_PUBLISHED {
+			status = http.StatusOK
+		}
+		for _, query := range []string{"", previewQuery(t, lib.PreviewArticle, article)} {
+			path := "/share/zaitun/articles" + slug + query
+			if w := get(t, app, path); w.Code != status {
+				t.Errorf("GET %s: status %d, want %d", path, w.Code, status)
+			}
+		}
+	}
+	for edition, status := range map[int]int{
+		EDITION_PUBLISHED: http.StatusOK,
+		EDITION_DRAFT:     http.StatusNotFound,
+		EDITION_ARCHIVED:  http.StatusNotFound,
+	} {
+		for _, query := range []string{"", previewQuery(t, lib.PreviewEdition, edition)} {
+			path := fmt.Sprintf("/share/zaitun/editions/%d%s", edition, query)
+			if w := get(t, app, path); w.Code != status {
+				t.Errorf("GET %s: status %d, want %d", path, w.Code, status)
+			}
+		}
+	}
+
+	// the edition description lists its published articles only
+	page := get(t, app, "/share/zaitun/editions/1").Body.String()
+	if !strings.Contains(page, `<meta property="og:title" content="Paskah">`) {
+		t.Errorf("edition page is missing its og:title:\n%s", page)
+	}
+	if !strings.Contains(page, "Terbit") {
+		t.Error("edition description is missing the published article")
+	}
+	for _, title := range []string{"Draf", "Arsip", "Sampah"} {
+		if strings.Contains(page, title) {
+			t.Errorf("edition description names hidden article %q", title)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package zaitun
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+type tocArticle struct {
+	Id           int                `json:"id"`
+	Title        string             `json:"title"`
+	Slug         string             `json:"slug"`
+	Writer       string             `json:"writerName"`
+	PublisedDate *time.Time         `json:"publishedAt"`
+	ThumbImg     string             `json:"thumbImg"`
+	ThumbText    string             `json:"thumbText"`
+	WordCount    int                `json:"wordCount"`
+	ReadingTime  int                `json:"readingTime"`
+	Contributors []*lib.Contributor `json:"contributors"`
+}
+
+type tocCategory struct {
+	Id       *int          `json:"id"`
+	Label    string        `json:"label"`
+	Order    int           `json:"order"`
+	Articles []*tocArticle `json:"articles"`
+}
+
+type editionToc struct {
+	Id          int            `json:"id"`
+	Title       string         `json:"title"`
+	EditionYear int            `json:"year"`
+	CoverImg    string         `json:"coverImg"`
+	Categories  []*tocCategory `json:"categories"`
+}
+
+// GetEditionToc returns the categories of an edition in order with their
+// published articles nested, so the edition page needs a single request.
+func (c *ZaitunController) GetEditionToc(ctx *gin.Context) {
+	editionId := ctx.Param("editionId")
+	_id, err := strconv.Atoi(editionId)
+	if err != nil {
+		c.res.AbortInvalidEdition(ctx, err, err.Error(), nil)
+		return
+	}
+	preview, ok := c.previewFromQuery(ctx)
+	if !ok {
+		return
+	}
+
+	// previews see unpublished content, keep them out of the cache
+	cacheKey := strconv.Itoa(_id)
+	if preview == nil {
+		if toc, ok := lib.EditionTocCache.Get(cacheKey); ok {
+			c.res.SuccessWithStatusOKJSON(ctx, nil, toc)
+			return
+		}
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var toc editionToc
+	visible, visibleArgs := visibleEdition(preview)
+	err = c.db.QueryRowContext(_context, `
+		SELECT e.id, e.title, e.edition_year, e.cover_img
+		FROM editions e WHERE e.id = ? AND `+visible,
+		append([]any{_id}, visibleArgs...)...).Scan(
+		&toc.Id,
+		&toc.Title,
+		&toc.EditionYear,
+		&toc.CoverImg,
+	)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortEditionNotFound(ctx, err, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	catRows, err := c.db.QueryContext(_context, `
+		SELECT c.id, c.label, c.order FROM categories c
+		WHERE c.edition_id = ? ORDER BY c.order ASC`, _id)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer catRows.Close()
+
+	groups := newEditionGroups[*tocArticle]()
+	for catRows.Next() {
+		var catId, order int
+		var label string
+		if err := catRows.Scan(&catId, &label, &order); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		groups.addCategory(catId, label, order)
+	}
+
+	visible, visibleArgs = visibleArticle(preview)
+	rows, err := c.db.QueryContext(_context, `
+		SELECT a.id, a.title, a.slug, w.writer_name, a.published_date, a.thumb_img, `+articleThumbText+`,
+			a.word_count, a.reading_time, a.category_id
+		FROM articles a
+		JOIN writers w ON w.id = a.writer_id
+		JOIN editions e ON e.id = a.edition_id
+		WHERE a.edition_id = ? AND `+visible+`
+		ORDER BY a.published_date ASC, a.id ASC`,
+		append([]any{_id}, visibleArgs...)...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	articles := []*tocArticle{}
+	for rows.Next() {
+		var article tocArticle
+		var publishedDate []uint8
+		var slug sql.NullString
+		var catId int
+		if err := rows.Scan(
+			&article.Id,
+			&article.Title,
+			&slug,
+			&article.Writer,
+			&publishedDate,
+			&article.ThumbImg,
+			&article.ThumbText,
+			&article.WordCount,
+			&article.ReadingTime,
+			&catId,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		article.Slug = slug.String
+		article.PublisedDate = lib.Base64ToTime(publishedDate)
+		groups.add(catId, &article)
+		articles = append(articles, &article)
+	}
+
+	ids := []int{}
+	for _, article := range articles {
+		ids = append(ids, article.Id)
+	}
+	contributors, err := lib.GetContributorsOf(_context, c.db, ids)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	for _, article := range articles {
+		article.Contributors = contributors[article.Id]
+	}
+
+	toc.Categories = []*tocCategory{}
+	for _, group := range groups.list() {
+		category := &tocCategory{Label: group.label, Order: group.order, Articles: group.items}
+		if group.id != lib.UNCATEGORIZED {
+			id := group.id
+			category.Id = &id
+		}
+		toc.Categories = append(toc.Categories, category)
+	}
+
+	if preview == nil {
+		lib.EditionTocCache.Set(cacheKey, &toc)
+	}
+	c.res.SuccessWithStatusOKJSON(ctx, nil, &toc)
+}
@@ -0,0 +1,22 @@
+package zaitun
+
+import (
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+)
+
+// Every public Zaitun query filters through these clauses so drafts, archived
+// or trashed articles and unpublished editions stay hidden. Queries must alias
+// articles as `a` and editions as `e`. A valid preview token only widens
+// visibility to the previewed article or edition, never to the trash.
+
+const publishedArticle = `a.published_date IS NOT NULL AND a.archived_date IS NULL AND a.deleted_at IS NULL AND e.published_at IS NOT NULL`
+const publishedEdition = `e.published_at IS NOT NULL`
+
+func visibleArticle(preview *lib.PreviewClaims) (string, []any) {
+	return "((" + publishedArticle + ") OR (a.deleted_at IS NULL AND (a.id = ? OR a.edition_id = ?)))",
+		[]any{preview.ArticleId(), preview.EditionId()}
+}
+
+func visibleEdition(preview *lib.PreviewClaims) (string, []any) {
+	return "(" + publishedEdition + " OR e.id = ?)", []any{preview.EditionId()}
+}
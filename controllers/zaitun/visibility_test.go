@@ -0,0 +1,369 @@
+package zaitun
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	gmssql "github.com/dolthub/go-mysql-server/sql"
+	"github.com/gin-gonic/gin"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// The base tables predate the migrations folder, these are the columns the
+// public Zaitun queries read before any migration runs.
+var baseSchema = []string{
+	`CREATE TABLE writers (
+		id INT NOT NULL AUTO_INCREMENT,
+		writer_name VARCHAR(255) NOT NULL,
+		PRIMARY KEY (id))`,
+	`CREATE TABLE editions (
+		id INT NOT NULL AUTO_INCREMENT,
+		title VARCHAR(255) NOT NULL,
+		published_at DATETIME NULL,
+		edition_year INT NULL,
+		cover_img VARCHAR(255) NULL,
+		thumb_img VARCHAR(255) NULL,
+		PRIMARY KEY (id))`,
+	"CREATE TABLE categories (" +
+		"id INT NOT NULL AUTO_INCREMENT," +
+		"label VARCHAR(255) NOT NULL," +
+		"`order` INT NOT NULL DEFAULT 0," +
+		"edition_id INT NULL," +
+		"PRIMARY KEY (id))",
+	`CREATE TABLE articles (
+		id INT NOT NULL AUTO_INCREMENT,
+		title VARCHAR(255) NOT NULL,
+		slug VARCHAR(255) NULL,
+		writer_id INT NOT NULL,
+		category_id INT NOT NULL DEFAULT 1,
+		edition_id INT NOT NULL,
+		published_date DATETIME NULL,
+		archived_date DATETIME NULL,
+		is_top_content BOOLEAN NOT NULL DEFAULT FALSE,
+		cover_img VARCHAR(255) NOT NULL DEFAULT '',
+		thumb_img VARCHAR(255) NOT NULL DEFAULT '',
+		thumb_text VARCHAR(512) NULL,
+		content_json TEXT NULL,
+		ads_json TEXT NULL,
+		PRIMARY KEY (id))`,
+	// only so 008_soft_delete.sql applies
+	`CREATE TABLE announcements (
+		id INT NOT NULL AUTO_INCREMENT,
+		PRIMARY KEY (id))`,
+}
+
+// migrations that touch the tables above
+var zaitunMigrations = []string{
+	"001_writer_profiles.sql",
+	"002_article_contributors.sql",
+	"003_ads.sql",
+	"004_edition_lifecycle.sql",
+	"005_article_reading_stats.sql",
+	"006_article_comments.sql",
+	"008_soft_delete.sql",
+}
+
+// Seeded rows: edition 1 is published with one visible article and one of
+// each kind of hidden article, edition 2 is still a draft and edition 3 was
+// archived, which unpublishes it.
+const (
+	EDITION_PUBLISHED = 1
+	EDITION_DRAFT     = 2
+	EDITION_ARCHIVED  = 3
+
+	ARTICLE_PUBLISHED     = 101
+	ARTICLE_DRAFT         = 102
+	ARTICLE_ARCHIVED      = 103
+	ARTICLE_TRASHED       = 104
+	ARTICLE_DRAFT_EDITION = 201
+	ARTICLE_ARCHIVED_ED   = 301
+)
+
+var seed = []string{
+	`INSERT INTO writers (id, writer_name) VALUES (1, 'Penulis')`,
+	`INSERT INTO editions (id, title, published_at, archived_at, edition_year, cover_img, thumb_img) VALUES
+		(1, 'Paskah', '2024-03-01 00:00:00', NULL, 2024, '', ''),
+		(2, 'Natal', NULL, NULL, 2024, '', ''),
+		(3, 'Adven', NULL, '2024-02-01 00:00:00', 2023, '', '')`,
+	"INSERT INTO categories (id, label, `order`, edition_id) VALUES (10, 'Renungan', 1, 1), (20, 'Renungan', 1, 2), (30, 'Renungan', 1, 3)",
+	`INSERT INTO articles (id, title, slug, writer_id, category_id, edition_id, published_date, archived_date, deleted_at, is_top_content, content_json, ads_json) VALUES
+		(101, 'Terbit', 'terbit', 1, 10, 1, '2024-03-01 00:00:00', NULL, NULL, TRUE, '{"blocks":[]}', ''),
+		(102, 'Draf', 'draf', 1, 10, 1, NULL, NULL, NULL, TRUE, '{"blocks":[]}', ''),
+		(103, 'Arsip', 'arsip', 1, 10, 1, '2024-03-01 00:00:00', '2024-04-01 00:00:00', NULL, TRUE, '{"blocks":[]}', ''),
+		(104, 'Sampah', 'sampah', 1, 10, 1, '2024-03-01 00:00:00', NULL, '2024-04-01 00:00:00', TRUE, '{"blocks":[]}', ''),
+		(201, 'Edisi Draf', 'edisi-draf', 1, 20, 2, '2024-03-01 00:00:00', NULL, NULL, TRUE, '{"blocks":[]}', ''),
+		(301, 'Edisi Arsip', 'edisi-arsip', 1, 30, 3, '2023-12-01 00:00:00', NULL, NULL, TRUE, '{"blocks":[]}', '')`,
+}
+
+var slugs = map[int]string{
+	ARTICLE_PUBLISHED:     "/2024/1/terbit",
+	ARTICLE_DRAFT:         "/2024/1/draf",
+	ARTICLE_ARCHIVED:      "/2024/1/arsip",
+	ARTICLE_TRASHED:       "/2024/1/sampah",
+	ARTICLE_DRAFT_EDITION: "/2024/2/edisi-draf",
+	ARTICLE_ARCHIVED_ED:   "/2023/3/edisi-arsip",
+}
+
+// newSeededRouter serves the public Zaitun routes from an in-memory MySQL
+// server holding the seeded rows, followed by any extra statements.
+func newSeededRouter(t *testing.T, extra ...string) *gin.Engine {
+	t.Helper()
+
+	database := memory.NewDatabase("zaitun")
+	// foreign keys need the referenced primary keys to be indexed
+	database.EnablePrimaryKeyIndexes()
+	provider := memory.NewDBProvider(database)
+	srv, err := server.NewServer(server.Config{Protocol: "tcp", Address: "127.0.0.1:0"},
+		sqle.NewDefault(provider), gmssql.NewContext, memory.NewSessionBuilder(provider), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	t.Cleanup(func() { srv.Close() })
+
+	db, err := sql.Open("mysql", "root@tcp("+srv.Listener.Addr().String()+")/zaitun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	statements := slices.Clone(baseSchema)
+	for _, name := range zaitunMigrations {
+		migration, err := os.ReadFile(filepath.Join("..", "..", "migrations", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, statement := range strings.Split(stripComments(string(migration)), ";") {
+			if strings.TrimSpace(statement) != "" {
+				statements = append(statements, statement)
+			}
+		}
+	}
+	for _, statement := range append(append(statements, seed...), extra...) {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("%v\n%s", err, statement)
+		}
+	}
+
+	// cached tables of contents would outlive the database of another test
+	lib.InvalidateEditionToc()
+	t.Cleanup(lib.EditionTocCache.Clear)
+
+	c := NewZaitunController(db, lib.NewResponses(lib.NewLogger()))
+	gin.SetMode(gin.TestMode)
+	app := gin.New()
+	app.GET("/api/editions", c.GetAllEditions)
+	app.GET("/api/archive", c.GetArchive)
+	app.GET("/api/archive/years", c.GetArchiveYears)
+	app.GET("/api/editions/:editionId", c.GetEditionById)
+	app.GET("/api/editions/:editionId/toc", c.GetEditionToc)
+	app.GET("/api/editions/:editionId/epub", c.GetEditionEpub)
+	app.GET("/api/articles", c.GetArticlesByCategory)
+	app.GET("/api/articles/:year/:editionId/:slug", c.GetArticleBySlug)
+	app.GET("/api/articles/top", c.GetTopArticles)
+	app.GET("/api/articles/:year/:editionId/:slug/comments", c.GetArticleComments)
+	app.GET("/api/writers/:writerId", c.GetWriterById)
+	app.GET("/share/zaitun/articles/:year/:editionId/:slug", c.GetArticleShareMeta)
+	app.GET("/share/zaitun/editions/:editionId", c.GetEditionShareMeta)
+	return app
+}
+
+func stripComments(migration string) string {
+	lines := []string{}
+	for _, line := range strings.Split(migration, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func get(t *testing.T, app *gin.Engine, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+// getData requests path and decodes the data block of a 200 response.
+func getData(t *testing.T, app *gin.Engine, path string, data any) {
+	t.Helper()
+	w := get(t, app, path)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d, body %s", path, w.Code, w.Body.String())
+	}
+	body := struct {
+		Data any `json:"data"`
+	}{data}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+}
+
+func previewQuery(t *testing.T, kind string, id int) string {
+	t.Helper()
+	token, _, err := lib.NewPreviewToken(kind, id, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "?preview=" + token
+}
+
+type idModel struct {
+	Id int `json:"id"`
+}
+
+func ids(items []idModel) []int {
+	result := []int{}
+	for _, item := range items {
+		result = append(result, item.Id)
+	}
+	slices.Sort(result)
+	return result
+}
+
+func expectIds(t *testing.T, what string, got []int, want ...int) {
+	t.Helper()
+	if want == nil {
+		want = []int{}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("%s: got ids %v, want %v", what, got, want)
+	}
+}
+
+func TestArticleBySlugVisibility(t *testing.T) {
+	app := newSeededRouter(t)
+
+	for _, tc := range []struct {
+		name    string
+		article int
+		query   string
+		status  int
+	}{
+		{"published", ARTICLE_PUBLISHED, "", http.StatusOK},
+		{"draft", ARTICLE_DRAFT, "", http.StatusNotFound},
+		{"archived", ARTICLE_ARCHIVED, "", http.StatusNotFound},
+		{"trashed", ARTICLE_TRASHED, "", http.StatusNotFound},
+		{"unpublished edition", ARTICLE_DRAFT_EDITION, "", http.StatusNotFound},
+		{"archived edition", ARTICLE_ARCHIVED_ED, "", http.StatusNotFound},
+		{"draft with its preview", ARTICLE_DRAFT, previewQuery(t, lib.PreviewArticle, ARTICLE_DRAFT), http.StatusOK},
+		{"archived with its preview", ARTICLE_ARCHIVED, previewQuery(t, lib.PreviewArticle, ARTICLE_ARCHIVED), http.StatusOK},
+		{"draft with another article's preview", ARTICLE_DRAFT, previewQuery(t, lib.PreviewArticle, ARTICLE_ARCHIVED), http.StatusNotFound},
+		{"trashed with its preview", ARTICLE_TRASHED, previewQuery(t, lib.PreviewArticle, ARTICLE_TRASHED), http.StatusNotFound},
+		{"unpublished edition with its preview", ARTICLE_DRAFT_EDITION, previewQuery(t, lib.PreviewEdition, EDITION_DRAFT), http.StatusOK},
+		{"unpublished edition with another edition's preview", ARTICLE_DRAFT_EDITION, previewQuery(t, lib.PreviewEdition, EDITION_ARCHIVED), http.StatusNotFound},
+		{"invalid preview", ARTICLE_PUBLISHED, "?preview=invalid", http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := get(t, app, "/api/articles"+slugs[tc.article]+tc.query)
+			if w.Code != tc.status {
+				t.Errorf("status %d, want %d: %s", w.Code, tc.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestArticleListsVisibility(t *testing.T) {
+	app := newSeededRouter(t)
+
+	var byCategory []idModel
+	getData(t, app, "/api/articles?category=10", &byCategory)
+	expectIds(t, "category", ids(byCategory), ARTICLE_PUBLISHED)
+
+	for _, category := range []string{"20", "30"} {
+		var hidden []idModel
+		getData(t, app, "/api/articles?category="+category, &hidden)
+		expectIds(t, "category "+category, ids(hidden))
+	}
+
+	var writer struct {
+		Articles []idModel `json:"articles"`
+	}
+	getData(t, app, "/api/writers/1", &writer)
+	expectIds(t, "writer", ids(writer.Articles), ARTICLE_PUBLISHED)
+
+	for edition, want := range map[string][]int{
+		"1": {ARTICLE_PUBLISHED},
+		"2": nil,
+		"3": nil,
+	} {
+		var top []idModel
+		getData(t, app, "/api/articles/top?editionId="+edition, &top)
+		expectIds(t, "top articles of edition "+edition, ids(top), want...)
+	}
+}
+
+func TestEditionsVisibility(t *testing.T) {
+	app := newSeededRouter(t)
+
+	var editions []idModel
+	getData(t, app, "/api/editions", &editions)
+	expectIds(t, "editions", ids(editions), EDITION_PUBLISHED)
+
+	editions = nil
+	getData(t, app, "/api/editions"+previewQuery(t, lib.PreviewEdition, EDITION_DRAFT), &editions)
+	expectIds(t, "editions with preview", ids(editions), EDITION_PUBLISHED, EDITION_DRAFT)
+
+	for _, tc := range []struct {
+		edition string
+		query   string
+		status  int
+	}{
+		{"1", "", http.StatusOK},
+		{"2", "", http.StatusNotFound},
+		{"3", "", http.StatusNotFound},
+		{"2", previewQuery(t, lib.PreviewEdition, EDITION_DRAFT), http.StatusOK},
+		{"3", previewQuery(t, lib.PreviewEdition, EDITION_DRAFT), http.StatusNotFound},
+		// an article preview does not open its edition
+		{"2", previewQuery(t, lib.PreviewArticle, ARTICLE_DRAFT_EDITION), http.StatusNotFound},
+	} {
+		for _, path := range []string{
+			"/api/editions/" + tc.edition + tc.query,
+			"/api/editions/" + tc.edition + "/toc" + tc.query,
+		} {
+			if w := get(t, app, path); w.Code != tc.status {
+				t.Errorf("GET %s: status %d, want %d", path, w.Code, tc.status)
+			}
+		}
+	}
+}
+
+func TestEditionContentsVisibility(t *testing.T) {
+	app := newSeededRouter(t)
+
+	tocIds := func(path string) []int {
+		var toc struct {
+			Categories []struct {
+				Articles []idModel `json:"articles"`
+			} `json:"categories"`
+		}
+		getData(t, app, path, &toc)
+		articles := []idModel{}
+		for _, category := range toc.Categories {
+			articles = append(articles, category.Articles...)
+		}
+		return ids(articles)
+	}
+	expectIds(t, "toc", tocIds("/api/editions/1/toc"), ARTICLE_PUBLISHED)
+	expectIds(t, "toc with article preview",
+		tocIds("/api/editions/1/toc"+previewQuery(t, lib.PreviewArticle, ARTICLE_DRAFT)),
+		ARTICLE_PUBLISHED, ARTICLE_DRAFT)
+	expectIds(t, "toc with edition preview",
+		tocIds("/api/editions/2/toc"+previewQuery(t, lib.PreviewEdition, EDITION_DRAFT)),
+		ARTICLE_DRAFT_EDITION)
+	// the preview above must not have been cached for everyone
+	expectIds(t, "toc after preview", tocIds("/api/editions/1/toc"), ARTICLE_PUBLISHED)
+}
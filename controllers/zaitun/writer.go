@@ -0,0 +1,179 @@
+package zaitun
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *ZaitunController) GetWriterById(ctx *gin.Context) {
+	writerId, err := strconv.Atoi(ctx.Param("writerId"))
+	if err != nil {
+		c.res.AbortInvalidWriter(ctx, err, err.Error(), nil)
+		return
+	}
+	list, err := lib.ParseListQuery(ctx, lib.ListSpec{
+		DefaultLimit: 10,
+		MaxLimit:     50,
+		Sorts:        map[string]string{"publishedAt": "a.published_date", "title": "a.title"},
+		DefaultSort:  "-publishedAt",
+		Filters:      map[string]string{"editionId": "a.edition_id"},
+		Search:       []string{"a.title"},
+		IdColumn:     "a.id",
+	})
+	if err != nil {
+		c.res.AbortInvalidRequestBody(ctx, err, err.Error(), nil)
+		return
+	}
+
+	type writerResponseModel struct {
+		Id          int               `json:"id"`
+		Name        string            `json:"name"`
+		Bio         *string           `json:"bio"`
+		PhotoImg    *string           `json:"photoImg"`
+		Ministry    *string           `json:"ministry"`
+		Lingkungan  *string           `json:"lingkungan"`
+		SocialLinks map[string]string `json:"socialLinks"`
+	}
+
+	type articleResponseModel struct {
+		Id           int                `json:"id"`
+		Title        string             `json:"title"`
+		Slug         string             `json:"slug"`
+		PublisedDate *time.Time         `json:"publishedAt"`
+		ThumbImg     string             `json:"thumbImg"`
+		ThumbText    string             `json:"thumbText"`
+		WordCount    int                `json:"wordCount"`
+		ReadingTime  int                `json:"readingTime"`
+		EditionId    int                `json:"editionId"`
+		EditionYear  int                `json:"year"`
+		Contributors []*lib.Contributor `json:"contributors"`
+	}
+
+	_context, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var writer writerResponseModel
+	var socialLinks []byte
+	err = c.db.QueryRowContext(_context, `
+		SELECT id, writer_name, bio, photo_img, ministry, lingkungan, social_links
+		FROM writers WHERE id = ?`, writerId).Scan(
+		&writer.Id,
+		&writer.Name,
+		&writer.Bio,
+		&writer.PhotoImg,
+		&writer.Ministry,
+		&writer.Lingkungan,
+		&socialLinks,
+	)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.res.AbortWriterNotFound(ctx, err, "", nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	writer.SocialLinks = map[string]string{}
+	if len(socialLinks) > 0 {
+		if err := json.Unmarshal(socialLinks, &writer.SocialLinks); err != nil {
+			log.Println(err.Error())
+		}
+	}
+
+	filter, filterArgs := list.Filter()
+	var total int
+	err = c.db.QueryRowContext(_context, `
+		SELECT COUNT(a.id) FROM articles a
+		JOIN editions e ON e.id = a.edition_id
+		WHERE a.writer_id = ? AND `+publishedArticle+filter,
+		append([]any{writerId}, filterArgs...)...).Scan(&total)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+
+	where, args := list.Where()
+	paginate, paginateArgs := list.Paginate()
+	rows, err := c.db.QueryContext(_context, `
+		SELECT a.id, a.title, a.slug, a.published_date, a.thumb_img, `+articleThumbText+`,
+			a.word_count, a.reading_time, a.edition_id, e.edition_year,
+			`+list.SortColumn()+`
+		FROM articles a
+		JOIN editions e ON e.id = a.edition_id
+		WHERE a.writer_id = ? AND `+publishedArticle+where+`
+		ORDER BY `+list.OrderBy()+` `+paginate,
+		append(append([]any{writerId}, args...), paginateArgs...)...)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	defer rows.Close()
+
+	articles := []*articleResponseModel{}
+	for rows.Next() {
+		var result articleResponseModel
+		var publishedDate []uint8
+		var sortValue sql.NullString
+		if err := rows.Scan(
+			&result.Id,
+			&result.Title,
+			&result.Slug,
+			&publishedDate,
+			&result.ThumbImg,
+			&result.ThumbText,
+			&result.WordCount,
+			&result.ReadingTime,
+			&result.EditionId,
+			&result.EditionYear,
+			&sortValue,
+		); err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		result.PublisedDate = lib.Base64ToTime(publishedDate)
+		list.Scanned(sortValue, result.Id)
+		articles = append(articles, &result)
+	}
+	articles = articles[:list.Trim()]
+
+	ids := []int{}
+	for _, article := range articles {
+		ids = append(ids, article.Id)
+	}
+	contributors, err := lib.GetContributorsOf(_context, c.db, ids)
+	if _context.Err() == context.DeadlineExceeded {
+		c.res.AbortDatabaseTimeout(ctx, _context.Err(), nil)
+		return
+	}
+	if err != nil {
+		c.res.AbortDatabaseError(ctx, err, nil)
+		return
+	}
+	for _, article := range articles {
+		article.Contributors = contributors[article.Id]
+	}
+
+	c.res.SuccessWithMeta(ctx, nil, gin.H{
+		"writer":   writer,
+		"articles": articles,
+	}, list.Meta(total))
+}
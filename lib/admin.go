@@ -0,0 +1,38 @@
+package lib
+
+import (
+	"strings"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/conf"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AdminId returns the id of the signed-in admin for audit columns such as
+// updated_by, or nil when the request carries no valid admin token. Routes
+// behind auth.AuthMiddleware already have it in the context.
+func AdminId(ctx *gin.Context) *int {
+	claim, ok := ctx.Get("adminId")
+	if !ok {
+		tokenString, found := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !found {
+			return nil
+		}
+		claims := jwt.MapClaims{}
+		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+			return []byte(conf.JWT_SECRET), nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		if err != nil || !token.Valid {
+			return nil
+		}
+		claim = claims["id"]
+	}
+
+	// JSON numbers in MapClaims decode as float64
+	id, ok := claim.(float64)
+	if !ok {
+		return nil
+	}
+	adminId := int(id)
+	return &adminId
+}
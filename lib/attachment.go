@@ -0,0 +1,75 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// BERITA_MAX_ATTACHMENTS caps the files attached to a single berita.
+const BERITA_MAX_ATTACHMENTS = 20
+
+const ATTACHMENT_MAX_FILENAME = 200
+const ATTACHMENT_MAX_CAPTION = 500
+
+type attachmentType struct {
+	Kind       string
+	Extensions []string
+	MaxBytes   int64
+}
+
+// attachmentTypes are the files a berita may carry, by MIME type. Scanned
+// forms are larger than photos, hence the higher limit for PDF.
+var attachmentTypes = map[string]attachmentType{
+	"application/pdf": {"document", []string{".pdf"}, 10 << 20},
+	"image/jpeg":      {"image", []string{".jpg", ".jpeg"}, 5 << 20},
+	"image/png":       {"image", []string{".png"}, 5 << 20},
+	"image/webp":      {"image", []string{".webp"}, 5 << 20},
+}
+
+var invalidFileNameChars = regexp.MustCompile(`[\/\\?%*:|"<>^]`)
+
+// AttachmentKind tells "image" and "document" attachments apart, the site
+// shows images as a gallery and documents as download links.
+func AttachmentKind(contentType string) string {
+	if t, ok := attachmentTypes[contentType]; ok {
+		return t.Kind
+	}
+	return "document"
+}
+
+// AttachmentMaxBytes is the largest upload accepted for a content type.
+func AttachmentMaxBytes(contentType string) int64 {
+	return attachmentTypes[contentType].MaxBytes
+}
+
+// ValidateAttachment checks an attachment before its upload URL is signed.
+// size is the size the client is about to upload, in bytes.
+func ValidateAttachment(fileName string, contentType string, size int64) error {
+	if strings.TrimSpace(fileName) == "" {
+		return errors.New("empty filename")
+	}
+	if len(fileName) > ATTACHMENT_MAX_FILENAME {
+		return fmt.Errorf("filename must be at most %d characters", ATTACHMENT_MAX_FILENAME)
+	}
+	if invalidFileNameChars.MatchString(fileName) {
+		return errors.New("invalid characters")
+	}
+	t, ok := attachmentTypes[strings.ToLower(contentType)]
+	if !ok {
+		return errors.New("invalid content type (use PDF, JPEG, PNG or WebP)")
+	}
+	if !slices.Contains(t.Extensions, strings.ToLower(path.Ext(fileName))) {
+		return errors.New("file extension does not match content type")
+	}
+	if size <= 0 {
+		return errors.New("size must be a positive number of bytes")
+	}
+	if size > t.MaxBytes {
+		return fmt.Errorf("file too large, %s files may be at most %d MB", t.Kind, t.MaxBytes>>20)
+	}
+	return nil
+}
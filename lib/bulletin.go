@@ -0,0 +1,351 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const BULLETIN_NAME = "Warta Paroki Kosambi Baru"
+const BULLETIN_PUBLISHER = "Paroki Kosambi Baru"
+
+var indonesianDays = []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+var indonesianMonths = []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+// Bulletin is a warta paroki: a numbered snapshot of the berita active in one
+// week. Drafts have no number yet, it is given when they are first published.
+type Bulletin struct {
+	Id          int             `json:"id"`
+	Number      *int            `json:"number"`
+	Title       string          `json:"title"`
+	WeekStart   string          `json:"weekStart"`
+	WeekEnd     string          `json:"weekEnd"`
+	Intro       *string         `json:"intro"`
+	Published   bool            `json:"published"`
+	PublishedAt *time.Time      `json:"publishedAt"`
+	CreatedAt   *time.Time      `json:"createdAt"`
+	UpdatedAt   *time.Time      `json:"updatedAt"`
+	Items       []*BulletinItem `json:"items"`
+}
+
+// BulletinItem is a copy of a berita as it was when the bulletin was made,
+// later edits to the berita do not change printed bulletins.
+type BulletinItem struct {
+	Id           int        `json:"id"`
+	BeritaId     *int       `json:"beritaId"`
+	Section      string     `json:"section"`
+	Title        string     `json:"title"`
+	Descriptions string     `json:"descriptions"`
+	EventStart   *time.Time `json:"eventStart"`
+	EventEnd     *time.Time `json:"eventEnd"`
+	Location     *string    `json:"location"`
+	Note         *string    `json:"note"`
+	SortOrder    int        `json:"sortOrder"`
+}
+
+// BulletinWeek returns the Sunday starting the week of day and the Saturday
+// ending it, in parish time. Bulletins are handed out at the Sunday Masses.
+func BulletinWeek(day time.Time) (time.Time, time.Time) {
+	loc := ParishLocation()
+	day = day.In(loc)
+	start := time.Date(day.Year(), day.Month(), day.Day()-int(day.Weekday()), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 6)
+}
+
+func IndonesianWeekday(day time.Weekday) string {
+	return indonesianDays[day]
+}
+
+// FormatTanggal writes a date the Indonesian way, e.g. "Minggu, 18 Oktober 2026".
+func FormatTanggal(t time.Time) string {
+	t = t.In(ParishLocation())
+	return fmt.Sprintf("%s, %d %s %d", indonesianDays[t.Weekday()], t.Day(), indonesianMonths[t.Month()-1], t.Year())
+}
+
+// FormatJadwal describes when an event takes place, e.g.
+// "Minggu, 18 Oktober 2026 pukul 09.00-11.00".
+func FormatJadwal(start time.Time, end *time.Time) string {
+	loc := ParishLocation()
+	start = start.In(loc)
+	s := FormatTanggal(start) + " pukul " + start.Format("15.04")
+	if end == nil || !end.After(start) {
+		return s
+	}
+	e := end.In(loc)
+	if e.Year() == start.Year() && e.YearDay() == start.YearDay() {
+		return s + "-" + e.Format("15.04")
+	}
+	return s + " s.d. " + FormatTanggal(e) + " pukul " + e.Format("15.04")
+}
+
+// Period is the week the bulletin covers, e.g. "18 - 24 Oktober 2026".
+func (b *Bulletin) Period() string {
+	start, err := time.Parse(time.DateOnly, b.WeekStart)
+	if err != nil {
+		return b.WeekStart
+	}
+	end, err := time.Parse(time.DateOnly, b.WeekEnd)
+	if err != nil {
+		return b.WeekStart
+	}
+	switch {
+	case start.Year() != end.Year():
+		return fmt.Sprintf("%d %s %d - %d %s %d", start.Day(), indonesianMonths[start.Month()-1], start.Year(),
+			end.Day(), indonesianMonths[end.Month()-1], end.Year())
+	case start.Month() != end.Month():
+		return fmt.Sprintf("%d %s - %d %s %d", start.Day(), indonesianMonths[start.Month()-1],
+			end.Day(), indonesianMonths[end.Month()-1], end.Year())
+	}
+	return fmt.Sprintf("%d - %d %s %d", start.Day(), end.Day(), indonesianMonths[end.Month()-1], end.Year())
+}
+
+// Heading is the bulletin title with its number, "Warta Paroki No. 12".
+func (b *Bulletin) Heading() string {
+	if b.Number == nil {
+		return b.Title + " (draft)"
+	}
+	return fmt.Sprintf("%s No. %d", b.Title, *b.Number)
+}
+
+// GetBulletin loads a bulletin with its items in their saved order. It
+// returns sql.ErrNoRows when there is no such bulletin.
+func GetBulletin(ctx context.Context, db *sql.DB, bulletinId int) (*Bulletin, error) {
+	var bulletin Bulletin
+	var publishedAt, createdAt, updatedAt []uint8
+	err := db.QueryRowContext(ctx, `
+		SELECT id, number, title, week_start, week_end, intro, published_at, created_at, updated_at
+		FROM bulletins
+		WHERE id = ?`, bulletinId).Scan(
+		&bulletin.Id,
+		&bulletin.Number,
+		&bulletin.Title,
+		&bulletin.WeekStart,
+		&bulletin.WeekEnd,
+		&bulletin.Intro,
+		&publishedAt,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	bulletin.PublishedAt = Base64ToTime(publishedAt)
+	bulletin.Published = bulletin.PublishedAt != nil
+	bulletin.CreatedAt = Base64ToTime(createdAt)
+	bulletin.UpdatedAt = Base64ToTime(updatedAt)
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, announcement_id, section, title, descriptions, event_start, event_end, location, note, sort_order
+		FROM bulletin_items
+		WHERE bulletin_id = ?
+		ORDER BY sort_order ASC, id ASC`, bulletinId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bulletin.Items = []*BulletinItem{}
+	for rows.Next() {
+		var item BulletinItem
+		var eventStart, eventEnd []uint8
+		if err := rows.Scan(
+			&item.Id,
+			&item.BeritaId,
+			&item.Section,
+			&item.Title,
+			&item.Descriptions,
+			&eventStart,
+			&eventEnd,
+			&item.Location,
+			&item.Note,
+			&item.SortOrder,
+		); err != nil {
+			return nil, err
+		}
+		item.EventStart = Base64ToTime(eventStart)
+		item.EventEnd = Base64ToTime(eventEnd)
+		bulletin.Items = append(bulletin.Items, &item)
+	}
+	return &bulletin, rows.Err()
+}
+
+type bulletinSection struct {
+	Title string
+	Items []*bulletinItemView
+}
+
+type bulletinItemView struct {
+	Title        string
+	Descriptions string
+	Jadwal       string
+	Location     string
+	Note         string
+	Url          string
+}
+
+// sections groups consecutive items of the same section, editors decide the
+// order so a section may show up twice.
+func (b *Bulletin) sections(origin string) []*bulletinSection {
+	sections := []*bulletinSection{}
+	var current *bulletinSection
+	for _, item := range b.Items {
+		if current == nil || current.Title != item.Section {
+			current = &bulletinSection{Title: item.Section}
+			sections = append(sections, current)
+		}
+		view := bulletinItemView{Title: item.Title, Descriptions: item.Descriptions}
+		if item.EventStart != nil {
+			view.Jadwal = FormatJadwal(*item.EventStart, item.EventEnd)
+		}
+		if item.Location != nil {
+			view.Location = *item.Location
+		}
+		if item.Note != nil {
+			view.Note = *item.Note
+		}
+		if item.BeritaId != nil && origin != "" {
+			view.Url = fmt.Sprintf("%s/berita/%d", origin, *item.BeritaId)
+		}
+		current.Items = append(current.Items, &view)
+	}
+	return sections
+}
+
+var bulletinTemplate = template.Must(template.New("bulletin").Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Heading}}</title>
+<style>
+body { font-family: Georgia, serif; max-width: 48em; margin: 2em auto; padding: 0 1em; line-height: 1.5; color: #222; }
+header { text-align: center; border-bottom: 2px solid #222; margin-bottom: 1.5em; }
+header h1 { margin-bottom: 0; }
+header p { margin-top: 0.3em; }
+h2 { font-size: 1.2em; text-transform: uppercase; letter-spacing: 0.05em; border-bottom: 1px solid #999; }
+article { margin-bottom: 1.2em; }
+article h3 { font-size: 1.05em; margin-bottom: 0.2em; }
+p.jadwal { margin: 0.2em 0; font-weight: bold; }
+p.note { margin: 0.3em 0; font-style: italic; }
+.intro { font-style: italic; }
+@media print { body { margin: 0; } a { color: inherit; text-decoration: none; } }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Heading}}</h1>
+<p>{{.Period}}</p>
+</header>
+{{with .Intro}}<p class="intro">{{.}}</p>
+{{end}}{{range .Sections}}<section>
+{{with .Title}}<h2>{{.}}</h2>
+{{end}}{{range .Items}}<article>
+<h3>{{if .Url}}<a href="{{.Url}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</h3>
+{{with .Jadwal}}<p class="jadwal">{{.}}</p>
+{{end}}{{with .Location}}<p class="jadwal">{{.}}</p>
+{{end}}{{with .Descriptions}}<p>{{.}}</p>
+{{end}}{{with .Note}}<p class="note">{{.}}</p>
+{{end}}</article>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML renders the bulletin as a standalone page, links to the berita
+// are made absolute with origin.
+func (b *Bulletin) WriteHTML(w io.Writer, origin string) error {
+	intro := ""
+	if b.Intro != nil {
+		intro = *b.Intro
+	}
+	return bulletinTemplate.Execute(w, map[string]any{
+		"Heading":  b.Heading(),
+		"Period":   b.Period(),
+		"Intro":    intro,
+		"Sections": b.sections(origin),
+	})
+}
+
+// WritePDF renders the bulletin for printing.
+func (b *Bulletin) WritePDF(w io.Writer) error {
+	doc := PdfDocument{
+		Title:  b.Heading(),
+		Author: BULLETIN_PUBLISHER,
+		Footer: b.Heading() + " - " + b.Period(),
+	}
+	if b.PublishedAt != nil {
+		doc.Created = *b.PublishedAt
+	}
+
+	doc.Centered(b.Heading(), PdfBold, 18)
+	doc.Centered(b.Period(), PdfRegular, 11)
+	doc.Space(4)
+	doc.Rule()
+	if b.Intro != nil && strings.TrimSpace(*b.Intro) != "" {
+		doc.Space(6)
+		doc.Text(*b.Intro, PdfItalic, 10.5, 0)
+	}
+	for _, section := range b.sections("") {
+		if section.Title != "" {
+			doc.Space(10)
+			// keep the heading with the first lines of its first item
+			doc.KeepTogether(60)
+			doc.Text(strings.ToUpper(section.Title), PdfBold, 12, 0)
+			doc.Rule()
+		}
+		for _, item := range section.Items {
+			doc.Space(6)
+			doc.KeepTogether(40)
+			doc.Text(item.Title, PdfBold, 11, 0)
+			if item.Jadwal != "" {
+				doc.Text(item.Jadwal, PdfRegular, 10, 10)
+			}
+			if item.Location != "" {
+				doc.Text("Tempat: "+item.Location, PdfRegular, 10, 10)
+			}
+			if item.Descriptions != "" {
+				doc.Text(item.Descriptions, PdfRegular, 10, 10)
+			}
+			if item.Note != "" {
+				doc.Text(item.Note, PdfItalic, 10, 10)
+			}
+		}
+	}
+	return doc.Write(w)
+}
+
+// WriteBulletin sends a bulletin as "html" or "pdf".
+func (r *Responses) WriteBulletin(ctx *gin.Context, b *Bulletin, format string) {
+	var buf bytes.Buffer
+	var err error
+	var contentType string
+	switch format {
+	case "pdf":
+		err = b.WritePDF(&buf)
+		contentType = "application/pdf"
+	default:
+		format = "html"
+		err = b.WriteHTML(&buf, SiteOrigin())
+		contentType = "text/html; charset=utf-8"
+	}
+	if err != nil {
+		r.AbortWithStatusJSON(ctx, err, "failed to build bulletin", err.Error(), http.StatusInternalServerError, nil)
+		return
+	}
+
+	fileName := fmt.Sprintf("warta-paroki-%s.%s", b.WeekStart, format)
+	if b.Number != nil {
+		fileName = fmt.Sprintf("warta-paroki-%d.%s", *b.Number, format)
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileName))
+	r.SuccessWithData(ctx, contentType, buf.Bytes(), fileName)
+}
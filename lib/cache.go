@@ -0,0 +1,66 @@
+package lib
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheItem struct {
+	value     any
+	expiresAt time.Time
+}
+
+// Cache is a small in-memory key/value store with a fixed time to live.
+// Entries are dropped lazily on read or explicitly through Delete and Clear.
+type Cache struct {
+	mu    sync.RWMutex
+	ttl   time.Duration
+	items map[string]cacheItem
+}
+
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, items: map[string]cacheItem{}}
+}
+
+func (c *Cache) Get(key string) (any, bool) {
+	c.mu.RLock()
+	item, ok := c.items[key]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(item.expiresAt) {
+		c.Delete(key)
+		return nil, false
+	}
+	return item.value, true
+}
+
+func (c *Cache) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = cacheItem{value, time.Now().Add(c.ttl)}
+}
+
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
+
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = map[string]cacheItem{}
+}
+
+// EditionTocCache holds public edition table of contents keyed by edition id.
+// Handlers that change anything it shows call InvalidateEditionToc.
+var EditionTocCache = NewCache(15 * time.Minute)
+
+// InvalidateEditionToc drops every cached table of contents. Articles move
+// between editions and writers appear in many, so a single edition is never
+// enough to know what is stale.
+func InvalidateEditionToc() {
+	EditionTocCache.Clear()
+}
@@ -0,0 +1,73 @@
+package lib
+
+import (
+	"sync"
+	"time"
+)
+
+const PARISH_TIMEZONE = "Asia/Jakarta"
+
+// NEXT_OCCURRENCE_HORIZON is how far ahead NextOccurrence looks.
+const NEXT_OCCURRENCE_HORIZON = 2 * 366 * 24 * time.Hour
+
+var parishLocation = sync.OnceValue(func() *time.Location {
+	loc, err := time.LoadLocation(PARISH_TIMEZONE)
+	if err != nil {
+		// no tzdata on the host, WIB has no daylight saving anyway
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+})
+
+// ParishLocation is the time zone parish events happen in. Recurrence rules
+// are expanded in it, so a weekly 19:00 practice stays at 19:00.
+func ParishLocation() *time.Location {
+	return parishLocation()
+}
+
+type Occurrence struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end"`
+}
+
+// EventOccurrences expands an event into its occurrences overlapping
+// [from, to), at most limit of them when limit > 0. Events without a
+// recurrence rule occur once.
+func EventOccurrences(start time.Time, end *time.Time, rrule string, from, to time.Time, limit int) ([]*Occurrence, error) {
+	var duration time.Duration
+	if end != nil && end.After(start) {
+		duration = end.Sub(start)
+	}
+
+	starts := []time.Time{start}
+	if rrule != "" {
+		rule, err := ParseRRule(rrule)
+		if err != nil {
+			return nil, err
+		}
+		starts = rule.Occurrences(start.In(ParishLocation()), duration, from, to, limit)
+	} else if !start.Before(to) || (duration == 0 && start.Before(from)) || (duration > 0 && !start.Add(duration).After(from)) {
+		starts = nil
+	}
+
+	occurrences := []*Occurrence{}
+	for _, s := range starts {
+		occurrence := Occurrence{Start: s.UTC()}
+		if end != nil {
+			e := s.Add(duration).UTC()
+			occurrence.End = &e
+		}
+		occurrences = append(occurrences, &occurrence)
+	}
+	return occurrences, nil
+}
+
+// NextOccurrence is the first occurrence of an event that has not ended by
+// now, or nil when there is none left.
+func NextOccurrence(start time.Time, end *time.Time, rrule string, now time.Time) (*Occurrence, error) {
+	occurrences, err := EventOccurrences(start, end, rrule, now, now.Add(NEXT_OCCURRENCE_HORIZON), 1)
+	if err != nil || len(occurrences) == 0 {
+		return nil, err
+	}
+	return occurrences[0], nil
+}
@@ -0,0 +1,75 @@
+package lib
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/conf"
+	"github.com/gin-gonic/gin"
+)
+
+// Every feature hashes client addresses with its own purpose, so the hashes
+// stored by one cannot be matched against another's.
+const CLIENT_COMMENT = "comment-ip"
+const CLIENT_INTENTION = "intention-ip"
+
+// TrustedProxies are the proxies whose X-Forwarded-For gin believes when it
+// works out ctx.ClientIP(), from TRUSTED_PROXIES in the environment (comma
+// separated addresses or CIDRs). None by default: rate limits and bans are
+// keyed on the client address, and any client can write that header.
+func TrustedProxies() []string {
+	proxies := []string{}
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// ClientIPHeader names the header the hosting platform puts the real client
+// address in (e.g. CF-Connecting-IP), from CLIENT_IP_HEADER in the
+// environment. Only set it when every request reaches us through that
+// platform, it is trusted as is.
+func ClientIPHeader() string {
+	return strings.TrimSpace(os.Getenv("CLIENT_IP_HEADER"))
+}
+
+// HashClientIP keeps client addresses out of the database while still
+// letting moderators ban and rate limit them.
+func HashClientIP(purpose string, ip string) string {
+	key := hmac.New(sha256.New, []byte(conf.JWT_SECRET))
+	key.Write([]byte(purpose))
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Honeypot is embedded in the bodies of public forms. The field is hidden
+// from people and only filled in by bots.
+type Honeypot struct {
+	Website string `json:"website"`
+}
+
+// GuardSubmission screens a public form submission before anything is
+// stored. Bots that filled the honeypot get accepted as if the submission
+// went through, so they learn nothing, and clients over the limit get 429.
+// It returns the client hash for purpose. Public form bodies carry contact
+// details, handlers keep them out of the logs by passing nil request data.
+func (r *Responses) GuardSubmission(ctx *gin.Context, honeypot Honeypot, limiter *RateLimiter,
+	purpose string, accepted gin.H) (string, bool) {
+	if honeypot.Website != "" {
+		r.SuccessWithStatusJSON(ctx, http.StatusAccepted, nil, accepted)
+		return "", false
+	}
+	ipHash := HashClientIP(purpose, ctx.ClientIP())
+	if !limiter.Allow(ipHash) {
+		r.AbortTooManyRequests(ctx, ErrTooManyRequests, "", nil)
+		return "", false
+	}
+	return ipHash, true
+}
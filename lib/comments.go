@@ -0,0 +1,95 @@
+package lib
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const COMMENT_PENDING = "pending"
+const COMMENT_APPROVED = "approved"
+const COMMENT_REJECTED = "rejected"
+const COMMENT_SPAM = "spam"
+
+var COMMENT_STATUSES = []string{COMMENT_PENDING, COMMENT_APPROVED, COMMENT_REJECTED, COMMENT_SPAM}
+
+const BAN_EMAIL = "email"
+const BAN_IP = "ip"
+
+// Comments scoring at least COMMENT_SPAM_SCORE go to the queue as spam
+// instead of pending.
+const COMMENT_SPAM_SCORE = 3
+
+var commentLink = regexp.MustCompile(`(?i)(https?://|www\.)\S+`)
+
+var commentSpamWords = []string{
+	"casino", "slot gacor", "judi", "togel", "poker", "viagra", "crypto",
+	"bitcoin", "pinjol", "pinjaman online", "backlink",
+}
+
+// CommentSpamScore rates a comment with cheap heuristics and returns the
+// reasons that contributed, for moderators.
+func CommentSpamScore(name string, body string) (int, []string) {
+	score := 0
+	reasons := []string{}
+
+	links := len(commentLink.FindAllString(body, -1))
+	if links > 0 {
+		score++
+		reasons = append(reasons, "link")
+	}
+	if links > 2 {
+		score += 2
+		reasons = append(reasons, "many links")
+	}
+	if commentLink.MatchString(name) {
+		score += 3
+		reasons = append(reasons, "link in name")
+	}
+
+	text := strings.ToLower(name + " " + body)
+	for _, word := range commentSpamWords {
+		if strings.Contains(text, word) {
+			score += 3
+			reasons = append(reasons, "keyword: "+word)
+			break
+		}
+	}
+
+	if longestRun(body) >= 8 {
+		score++
+		reasons = append(reasons, "repeated characters")
+	}
+
+	letters, upper := 0, 0
+	for _, r := range body {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters >= 20 && upper*10 >= letters*7 {
+		score++
+		reasons = append(reasons, "shouting")
+	}
+
+	return score, reasons
+}
+
+// longestRun is the length of the longest run of one repeated character.
+func longestRun(s string) int {
+	longest, run := 0, 0
+	var prev rune
+	for i, r := range []rune(s) {
+		if i > 0 && r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		prev = r
+		longest = max(longest, run)
+	}
+	return longest
+}
@@ -2,14 +2,41 @@ package lib
 
 import "errors"
 
+// UNCATEGORIZED is the category shared by every edition. It cannot be
+// renamed, moved or removed, and public pages list its articles last.
+const UNCATEGORIZED = 1
+
 var ErrArticleNotFound error = errors.New("article not found")
 var ErrEditionNotFound error = errors.New("edition not found")
+var ErrWriterNotFound error = errors.New("writer not found")
+var ErrCategoryNotFound error = errors.New("category not found")
+var ErrAdNotFound error = errors.New("ad not found")
+var ErrCommentNotFound error = errors.New("comment not found")
+var ErrSectionNotFound error = errors.New("section not found")
+var ErrAttachmentNotFound error = errors.New("attachment not found")
+var ErrBulletinNotFound error = errors.New("bulletin not found")
+var ErrMassLocationNotFound error = errors.New("mass location not found")
+var ErrMassScheduleNotFound error = errors.New("mass schedule not found")
+var ErrMassExceptionNotFound error = errors.New("mass exception not found")
+var ErrIntentionNotFound error = errors.New("mass intention not found")
 var ErrInvalidArticle error = errors.New("invalid article id")
 var ErrInvalidCategory error = errors.New("invalid category id")
 var ErrInvalidEdition error = errors.New("invalid edition id")
+var ErrInvalidWriter error = errors.New("invalid writer id")
 var ErrInvalidYear error = errors.New("invalid year")
 var ErrInvalidBody error = errors.New("invalid request body")
 var ErrInvalidBerita error = errors.New("invalid berita id")
+var ErrInvalidPreview error = errors.New("invalid preview token")
+var ErrInvalidComment error = errors.New("invalid comment id")
+var ErrInvalidSection error = errors.New("invalid section id")
+var ErrInvalidAttachment error = errors.New("invalid attachment id")
+var ErrInvalidBulletin error = errors.New("invalid bulletin id")
+var ErrInvalidMassLocation error = errors.New("invalid mass location id")
+var ErrInvalidMassSchedule error = errors.New("invalid mass schedule id")
+var ErrInvalidMassException error = errors.New("invalid mass exception id")
+var ErrInvalidIntention error = errors.New("invalid mass intention id")
+var ErrTooManyRequests error = errors.New("too many requests, try again later")
+var ErrInvalidRRule error = errors.New("invalid recurrence rule")
 
 var ErrDatabase error = errors.New("mysql: database error")
 var ErrTimeout error = errors.New("mysql: connection timeout")
@@ -0,0 +1,353 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+const READING_WORDS_PER_MINUTE = 200
+const EXCERPT_LENGTH = 200
+
+// ArticleContents mirrors the editor.js document stored in articles.content_json
+type ArticleContents struct {
+	Time    int64          `json:"time"`
+	Blocks  []ContentBlock `json:"blocks"`
+	Version string         `json:"version"`
+}
+
+type ContentBlock struct {
+	Id   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type listItem struct {
+	Content string     `json:"content"`
+	Items   []listItem `json:"items"`
+}
+
+func (l *listItem) UnmarshalJSON(b []byte) error {
+	// editor.js list v1 stores plain strings, nested-list stores objects
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		l.Content = s
+		return nil
+	}
+	type alias listItem
+	var a alias
+	if err := json.Unmarshal(b, &a); err != nil {
+		return err
+	}
+	*l = listItem(a)
+	return nil
+}
+
+type blockData struct {
+	Text    string     `json:"text"`
+	Level   int        `json:"level"`
+	Style   string     `json:"style"`
+	Items   []listItem `json:"items"`
+	Caption string     `json:"caption"`
+	File    *struct {
+		Url string `json:"url"`
+	} `json:"file"`
+	Url          string     `json:"url"`
+	Content      [][]string `json:"content"`
+	WithHeadings bool       `json:"withHeadings"`
+}
+
+func ParseContents(raw string) (*ArticleContents, error) {
+	var contents ArticleContents
+	if strings.TrimSpace(raw) == "" {
+		return &contents, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &contents); err != nil {
+		return nil, err
+	}
+	return &contents, nil
+}
+
+// ImageURLs returns the source of every image block in document order
+func (a *ArticleContents) ImageURLs() []string {
+	urls := []string{}
+	for _, b := range a.Blocks {
+		if b.Type != "image" && b.Type != "simpleImage" {
+			continue
+		}
+		var d blockData
+		if err := json.Unmarshal(b.Data, &d); err != nil {
+			continue
+		}
+		if src := d.imageSrc(); src != "" {
+			urls = append(urls, src)
+		}
+	}
+	return urls
+}
+
+// ToXHTML renders the blocks as well-formed XHTML body markup. imgSrc maps an
+// image block source to the src written out; returning "" drops the image.
+func (a *ArticleContents) ToXHTML(imgSrc func(string) string) string {
+	var sb strings.Builder
+	for _, b := range a.Blocks {
+		var d blockData
+		if err := json.Unmarshal(b.Data, &d); err != nil {
+			continue
+		}
+		switch b.Type {
+		case "paragraph":
+			fmt.Fprintf(&sb, "<p>%s</p>\n", sanitizeInline(d.Text))
+		case "header":
+			level := d.Level
+			if level < 1 || level > 6 {
+				level = 2
+			}
+			fmt.Fprintf(&sb, "<h%d>%s</h%d>\n", level, sanitizeInline(d.Text), level)
+		case "list":
+			writeList(&sb, d.Style, d.Items)
+		case "quote":
+			fmt.Fprintf(&sb, "<blockquote><p>%s</p>", sanitizeInline(d.Text))
+			if d.Caption != "" {
+				fmt.Fprintf(&sb, "<footer>%s</footer>", sanitizeInline(d.Caption))
+			}
+			sb.WriteString("</blockquote>\n")
+		case "delimiter":
+			sb.WriteString("<hr/>\n")
+		case "table":
+			writeTable(&sb, d.Content, d.WithHeadings)
+		case "image", "simpleImage":
+			src := d.imageSrc()
+			if imgSrc != nil {
+				src = imgSrc(src)
+			}
+			if src == "" {
+				continue
+			}
+			alt := html.EscapeString(stripTags(d.Caption))
+			fmt.Fprintf(&sb, "<figure><img src=\"%s\" alt=\"%s\"/>", html.EscapeString(src), alt)
+			if d.Caption != "" {
+				fmt.Fprintf(&sb, "<figcaption>%s</figcaption>", sanitizeInline(d.Caption))
+			}
+			sb.WriteString("</figure>\n")
+		}
+	}
+	return sb.String()
+}
+
+// PlainText returns the readable text of the document, one block per line
+func (a *ArticleContents) PlainText() string {
+	lines := []string{}
+	for _, b := range a.Blocks {
+		var d blockData
+		if err := json.Unmarshal(b.Data, &d); err != nil {
+			continue
+		}
+		switch b.Type {
+		case "paragraph", "header", "quote":
+			lines = append(lines, stripTags(d.Text))
+		case "list":
+			lines = append(lines, listText(d.Items)...)
+		case "table":
+			for _, row := range d.Content {
+				for _, cell := range row {
+					lines = append(lines, stripTags(cell))
+				}
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WordCount counts the words of PlainText
+func (a *ArticleContents) WordCount() int {
+	return len(strings.Fields(a.PlainText()))
+}
+
+// ReadingTime estimates the minutes needed to read words, rounded up
+func ReadingTime(words int) int {
+	return (words + READING_WORDS_PER_MINUTE - 1) / READING_WORDS_PER_MINUTE
+}
+
+// Excerpt returns the opening paragraphs cut at a word boundary to at most
+// limit characters, for articles saved without a thumb_text.
+func (a *ArticleContents) Excerpt(limit int) string {
+	paragraphs := []string{}
+	for _, b := range a.Blocks {
+		if b.Type != "paragraph" {
+			continue
+		}
+		var d blockData
+		if err := json.Unmarshal(b.Data, &d); err != nil {
+			continue
+		}
+		if text := stripTags(d.Text); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	text := strings.Join(paragraphs, " ")
+	if text == "" {
+		text = a.PlainText()
+	}
+	return truncateWords(text, limit)
+}
+
+// Summarize strips markup from s and shortens it like Excerpt.
+func Summarize(s string, limit int) string {
+	return truncateWords(stripTags(s), limit)
+}
+
+// truncateWords collapses whitespace and cuts text at a word boundary to at
+// most limit characters.
+func truncateWords(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	cut := string(runes[:limit])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
+
+func (d *blockData) imageSrc() string {
+	if d.File != nil && d.File.Url != "" {
+		return d.File.Url
+	}
+	return d.Url
+}
+
+func writeList(sb *strings.Builder, style string, items []listItem) {
+	tag := "ul"
+	if style == "ordered" {
+		tag = "ol"
+	}
+	fmt.Fprintf(sb, "<%s>", tag)
+	for _, item := range items {
+		fmt.Fprintf(sb, "<li>%s", sanitizeInline(item.Content))
+		if len(item.Items) > 0 {
+			writeList(sb, style, item.Items)
+		}
+		sb.WriteString("</li>")
+	}
+	fmt.Fprintf(sb, "</%s>\n", tag)
+}
+
+func writeTable(sb *strings.Builder, rows [][]string, withHeadings bool) {
+	sb.WriteString("<table>")
+	for i, row := range rows {
+		cell := "td"
+		if i == 0 && withHeadings {
+			cell = "th"
+		}
+		sb.WriteString("<tr>")
+		for _, c := range row {
+			fmt.Fprintf(sb, "<%s>%s</%s>", cell, sanitizeInline(c), cell)
+		}
+		sb.WriteString("</tr>")
+	}
+	sb.WriteString("</table>\n")
+}
+
+func listText(items []listItem) []string {
+	lines := []string{}
+	for _, item := range items {
+		lines = append(lines, stripTags(item.Content))
+		lines = append(lines, listText(item.Items)...)
+	}
+	return lines
+}
+
+var allowedInlineTags = map[string]bool{
+	"b": true, "strong": true, "i": true, "em": true, "u": true,
+	"s": true, "mark": true, "code": true, "sub": true, "sup": true, "a": true,
+}
+
+// sanitizeInline keeps the inline formatting editor.js produces and re-emits it
+// as well-formed XHTML, dropping every other tag and attribute.
+func sanitizeInline(s string) string {
+	var sb strings.Builder
+	open := []string{}
+	z := nethtml.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		switch tt {
+		case nethtml.ErrorToken:
+			if z.Err() != io.EOF {
+				return html.EscapeString(stripTags(s))
+			}
+			for i := len(open) - 1; i >= 0; i-- {
+				fmt.Fprintf(&sb, "</%s>", open[i])
+			}
+			return sb.String()
+		case nethtml.TextToken:
+			sb.WriteString(html.EscapeString(string(z.Text())))
+		case nethtml.SelfClosingTagToken, nethtml.StartTagToken:
+			tok := z.Token()
+			if tok.Data == "br" {
+				sb.WriteString("<br/>")
+				continue
+			}
+			if !allowedInlineTags[tok.Data] || tt == nethtml.SelfClosingTagToken {
+				continue
+			}
+			if tok.Data == "a" {
+				href := ""
+				for _, attr := range tok.Attr {
+					if attr.Key == "href" {
+						href = attr.Val
+					}
+				}
+				if !strings.HasPrefix(href, "http://") && !strings.HasPrefix(href, "https://") {
+					href = "#"
+				}
+				fmt.Fprintf(&sb, "<a href=\"%s\">", html.EscapeString(href))
+			} else {
+				fmt.Fprintf(&sb, "<%s>", tok.Data)
+			}
+			open = append(open, tok.Data)
+		case nethtml.EndTagToken:
+			tok := z.Token()
+			// only close tags that are currently open, innermost first
+			idx := -1
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == tok.Data {
+					idx = i
+					break
+				}
+			}
+			if idx == -1 {
+				continue
+			}
+			for i := len(open) - 1; i >= idx; i-- {
+				fmt.Fprintf(&sb, "</%s>", open[i])
+			}
+			open = open[:idx]
+		}
+	}
+}
+
+func stripTags(s string) string {
+	var sb strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		switch tt {
+		case nethtml.ErrorToken:
+			return strings.TrimSpace(strings.ReplaceAll(sb.String(), "\u00a0", " "))
+		case nethtml.TextToken:
+			sb.Write(z.Text())
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if tok := z.Token(); tok.Data == "br" {
+				sb.WriteString(" ")
+			}
+		}
+	}
+}
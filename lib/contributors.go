@@ -0,0 +1,109 @@
+package lib
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+const ROLE_AUTHOR = "author"
+
+var CONTRIBUTOR_ROLES []string = []string{
+	ROLE_AUTHOR,
+	"photographer",
+	"translator",
+	"editor",
+}
+
+type Contributor struct {
+	WriterId int    `json:"writerId"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+}
+
+// GetArticleContributors lists the primary author (articles.writer_id) first,
+// followed by the article_contributors rows in their saved order.
+func GetArticleContributors(ctx context.Context, db *sql.DB, articleId int) ([]*Contributor, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT w.id, w.writer_name, ? AS role, 0 AS position
+		FROM articles a
+		JOIN writers w ON w.id = a.writer_id
+		WHERE a.id = ?
+		UNION ALL
+		SELECT w.id, w.writer_name, ac.role, ac.position + 1 AS position
+		FROM article_contributors ac
+		JOIN writers w ON w.id = ac.writer_id
+		WHERE ac.article_id = ?
+		ORDER BY position ASC`, ROLE_AUTHOR, articleId, articleId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contributors := []*Contributor{}
+	for rows.Next() {
+		var contributor Contributor
+		var position int
+		if err := rows.Scan(&contributor.WriterId, &contributor.Name, &contributor.Role, &position); err != nil {
+			return nil, err
+		}
+		contributors = append(contributors, &contributor)
+	}
+	return contributors, rows.Err()
+}
+
+// GetContributorsOf loads the contributors of several articles at once, keyed
+// by article id and ordered like GetArticleContributors. Articles that do not
+// exist are left out.
+func GetContributorsOf(ctx context.Context, db *sql.DB, articleIds []int) (map[int][]*Contributor, error) {
+	byArticle := map[int][]*Contributor{}
+	if len(articleIds) == 0 {
+		return byArticle, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(articleIds)), ",")
+	args := []any{ROLE_AUTHOR}
+	for _, id := range articleIds {
+		args = append(args, id)
+	}
+	for _, id := range articleIds {
+		args = append(args, id)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.id AS article_id, w.id, w.writer_name, ? AS role, 0 AS position
+		FROM articles a
+		JOIN writers w ON w.id = a.writer_id
+		WHERE a.id IN (`+placeholders+`)
+		UNION ALL
+		SELECT ac.article_id, w.id, w.writer_name, ac.role, ac.position + 1 AS position
+		FROM article_contributors ac
+		JOIN writers w ON w.id = ac.writer_id
+		WHERE ac.article_id IN (`+placeholders+`)
+		ORDER BY article_id ASC, position ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var contributor Contributor
+		var articleId, position int
+		if err := rows.Scan(&articleId, &contributor.WriterId, &contributor.Name, &contributor.Role, &position); err != nil {
+			return nil, err
+		}
+		byArticle[articleId] = append(byArticle[articleId], &contributor)
+	}
+	return byArticle, rows.Err()
+}
+
+// DropPrimaryAuthorCredits removes the author credits of writerId on the
+// articles it is now the primary author of, which are listed first already.
+// Call it whenever articles.writer_id changes.
+func DropPrimaryAuthorCredits(ctx context.Context, tx *sql.Tx, writerId int) error {
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM article_contributors
+		WHERE writer_id = ? AND role = ?
+		AND article_id IN (SELECT id FROM articles WHERE writer_id = ?)`,
+		writerId, ROLE_AUTHOR, writerId)
+	return err
+}
@@ -0,0 +1,187 @@
+package lib
+
+import (
+	"archive/zip"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+type EpubChapter struct {
+	Title  string
+	Byline string
+	Body   string
+}
+
+// EpubSection groups chapters under one heading of the table of contents
+type EpubSection struct {
+	Title    string
+	Chapters []*EpubChapter
+}
+
+type EpubResource struct {
+	Href      string
+	MediaType string
+	Data      []byte
+}
+
+type EpubBook struct {
+	Identifier string
+	Title      string
+	Language   string
+	Publisher  string
+	Modified   time.Time
+	Cover      *EpubResource
+	Sections   []*EpubSection
+	Resources  []*EpubResource
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+const epubStyle = `body { font-family: serif; line-height: 1.5; }
+h1 { font-size: 1.6em; margin-bottom: 0.2em; }
+p.byline { font-style: italic; color: #555; }
+figure { margin: 1em 0; text-align: center; }
+figure img { max-width: 100%; }
+figcaption { font-size: 0.85em; color: #555; }
+blockquote { margin: 1em 2em; font-style: italic; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #999; padding: 0.2em 0.4em; }`
+
+type epubFile struct {
+	name string
+	data []byte
+}
+
+func xhtmlPage(lang string, title string, body string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="%s" lang="%s">
+<head>
+<meta charset="UTF-8"/>
+<title>%s</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+%s
+</body>
+</html>`, lang, lang, html.EscapeString(title), body)
+}
+
+// Write packages the book as an EPUB 3 container. The mimetype entry must be
+// the first file in the archive and stored uncompressed.
+func (b *EpubBook) Write(w io.Writer) error {
+	z := zip.NewWriter(w)
+
+	mt, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mt, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	files := []epubFile{
+		{"META-INF/container.xml", []byte(epubContainer)},
+		{"OEBPS/style.css", []byte(epubStyle)},
+	}
+
+	var manifest, spine, nav strings.Builder
+	manifest.WriteString(`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	manifest.WriteString(`<item id="style" href="style.css" media-type="text/css"/>` + "\n")
+
+	if b.Cover != nil {
+		manifest.WriteString(fmt.Sprintf(
+			`<item id="cover-image" href="%s" media-type="%s" properties="cover-image"/>`+"\n",
+			html.EscapeString(b.Cover.Href), b.Cover.MediaType))
+		manifest.WriteString(`<item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>` + "\n")
+		spine.WriteString(`<itemref idref="cover"/>` + "\n")
+		coverBody := fmt.Sprintf(`<section epub:type="cover"><img src="%s" alt="%s"/></section>`,
+			html.EscapeString(b.Cover.Href), html.EscapeString(b.Title))
+		files = append(files,
+			epubFile{"OEBPS/" + b.Cover.Href, b.Cover.Data},
+			epubFile{"OEBPS/cover.xhtml", []byte(xhtmlPage(b.Language, b.Title, coverBody))},
+		)
+	}
+
+	spine.WriteString(`<itemref idref="nav"/>` + "\n")
+
+	n := 0
+	for _, section := range b.Sections {
+		if len(section.Chapters) == 0 {
+			continue
+		}
+		nav.WriteString(fmt.Sprintf("<li><span>%s</span><ol>\n", html.EscapeString(section.Title)))
+		for _, chapter := range section.Chapters {
+			n++
+			id := fmt.Sprintf("chapter-%03d", n)
+			href := id + ".xhtml"
+			manifest.WriteString(fmt.Sprintf(
+				`<item id="%s" href="%s" media-type="application/xhtml+xml"/>`+"\n", id, href))
+			spine.WriteString(fmt.Sprintf(`<itemref idref="%s"/>`+"\n", id))
+			nav.WriteString(fmt.Sprintf(`<li><a href="%s">%s</a></li>`+"\n", href, html.EscapeString(chapter.Title)))
+
+			body := fmt.Sprintf("<article>\n<h1>%s</h1>\n", html.EscapeString(chapter.Title))
+			if chapter.Byline != "" {
+				body += fmt.Sprintf("<p class=\"byline\">%s</p>\n", html.EscapeString(chapter.Byline))
+			}
+			body += chapter.Body + "</article>"
+			files = append(files, epubFile{"OEBPS/" + href, []byte(xhtmlPage(b.Language, chapter.Title, body))})
+		}
+		nav.WriteString("</ol></li>\n")
+	}
+
+	for i, r := range b.Resources {
+		manifest.WriteString(fmt.Sprintf(`<item id="res-%03d" href="%s" media-type="%s"/>`+"\n",
+			i+1, html.EscapeString(r.Href), r.MediaType))
+		files = append(files, epubFile{"OEBPS/" + r.Href, r.Data})
+	}
+
+	navBody := fmt.Sprintf(`<nav epub:type="toc" id="toc"><h1>Daftar Isi</h1><ol>
+%s</ol></nav>`, nav.String())
+	files = append(files, epubFile{"OEBPS/nav.xhtml", []byte(xhtmlPage(b.Language, b.Title, navBody))})
+
+	opf := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid" xml:lang="%s">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="bookid">%s</dc:identifier>
+<dc:title>%s</dc:title>
+<dc:language>%s</dc:language>
+<dc:publisher>%s</dc:publisher>
+<meta property="dcterms:modified">%s</meta>
+</metadata>
+<manifest>
+%s</manifest>
+<spine>
+%s</spine>
+</package>`,
+		b.Language,
+		html.EscapeString(b.Identifier),
+		html.EscapeString(b.Title),
+		b.Language,
+		html.EscapeString(b.Publisher),
+		b.Modified.UTC().Format("2006-01-02T15:04:05Z"),
+		manifest.String(),
+		spine.String(),
+	)
+	files = append(files, epubFile{"OEBPS/content.opf", []byte(opf)})
+
+	for _, f := range files {
+		fw, err := z.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
@@ -0,0 +1,161 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ICAL_PRODID = "-//Paroki Kosambi Baru//Kegiatan//ID"
+
+// ICAL_UID_DOMAIN keeps event UIDs the same whichever host serves the feed,
+// calendar apps match updates on them.
+const ICAL_UID_DOMAIN = "parokikosambibaru"
+
+// icalLineLimit is the maximum line length in octets before folding.
+const icalLineLimit = 75
+
+type ICalEvent struct {
+	UID          string
+	Sequence     int
+	LastModified time.Time
+	Start        time.Time
+	End          *time.Time
+	Summary      string
+	Description  string
+	Location     string
+	Categories   string
+	// Url may be relative to the site, WriteICalendar resolves it against
+	// SiteOrigin or leaves it out when the origin is not configured
+	Url   string
+	RRule string
+}
+
+type ICalendar struct {
+	Name   string
+	Events []*ICalEvent
+}
+
+func BeritaEventUID(beritaId int) string {
+	return fmt.Sprintf("berita-%d@%s", beritaId, ICAL_UID_DOMAIN)
+}
+
+// Write renders the calendar as RFC 5545 text. Times are written in parish
+// time so recurring events keep their wall clock time.
+func (c *ICalendar) Write(w io.Writer) error {
+	tzid := PARISH_TIMEZONE
+	local := func(t time.Time) string {
+		return t.In(ParishLocation()).Format("20060102T150405")
+	}
+	utc := func(t time.Time) string {
+		return t.UTC().Format("20060102T150405Z")
+	}
+
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeICalLine(bw, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", ICAL_PRODID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", icalEscape(c.Name))
+	}
+	line("X-WR-TIMEZONE", tzid)
+	// WIB has had no daylight saving since 1964
+	line("BEGIN", "VTIMEZONE")
+	line("TZID", tzid)
+	line("BEGIN", "STANDARD")
+	line("DTSTART", "19700101T000000")
+	line("TZOFFSETFROM", "+0700")
+	line("TZOFFSETTO", "+0700")
+	line("TZNAME", "WIB")
+	line("END", "STANDARD")
+	line("END", "VTIMEZONE")
+
+	for _, event := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("SEQUENCE", fmt.Sprint(event.Sequence))
+		line("DTSTAMP", utc(event.LastModified))
+		line("LAST-MODIFIED", utc(event.LastModified))
+		line("DTSTART;TZID="+tzid, local(event.Start))
+		if event.End != nil {
+			line("DTEND;TZID="+tzid, local(*event.End))
+		}
+		if event.RRule != "" {
+			line("RRULE", event.RRule)
+		}
+		line("SUMMARY", icalEscape(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", icalEscape(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION", icalEscape(event.Location))
+		}
+		if event.Categories != "" {
+			line("CATEGORIES", icalEscape(event.Categories))
+		}
+		if event.Url != "" {
+			line("URL", event.Url)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(s)
+}
+
+// writeICalLine folds content lines longer than 75 octets without splitting
+// UTF-8 sequences.
+func writeICalLine(w *bufio.Writer, s string) {
+	limit := icalLineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// continuation lines start with a space
+		limit = icalLineLimit - 1
+	}
+	w.WriteString(s + "\r\n")
+}
+
+// WriteICalendar sends a calendar as an .ics file. Subscribed calendar apps
+// poll it, so it may be cached for a while.
+func (r *Responses) WriteICalendar(ctx *gin.Context, cal *ICalendar, fileName string) {
+	origin := SiteOrigin()
+	for _, event := range cal.Events {
+		if strings.HasPrefix(event.Url, "/") {
+			// calendar apps only follow absolute links
+			if origin == "" {
+				event.Url = ""
+			} else {
+				event.Url = origin + event.Url
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Write(&buf); err != nil {
+		r.AbortWithStatusJSON(ctx, err, "failed to build calendar", err.Error(), http.StatusInternalServerError, nil)
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileName))
+	ctx.Header("Cache-Control", "public, max-age=900")
+	r.SuccessWithData(ctx, "text/calendar; charset=utf-8", buf.Bytes(), fileName)
+}
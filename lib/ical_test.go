@@ -0,0 +1,73 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func foldICalLine(s string) string {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	writeICalLine(w, s)
+	w.Flush()
+	return buf.String()
+}
+
+func TestWriteICalLine(t *testing.T) {
+	a := func(n int) string { return strings.Repeat("a", n) }
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"short", "SUMMARY:Misa", "SUMMARY:Misa\r\n"},
+		{"exactly 75 octets", a(75), a(75) + "\r\n"},
+		{"76 octets", a(76), a(75) + "\r\n a\r\n"},
+		{"continuation lines hold 74 octets", a(75 + 74 + 1), a(75) + "\r\n " + a(74) + "\r\n a\r\n"},
+		// é is two octets; the one straddling octet 75 moves to the next line
+		{"two-octet rune at the limit", a(74) + "é", a(74) + "\r\n é\r\n"},
+		{"two-octet rune before the limit", a(73) + "éb", a(73) + "é\r\n b\r\n"},
+		// 🙏 is four octets
+		{"four-octet rune at the limit", a(72) + "🙏", a(72) + "\r\n 🙏\r\n"},
+		{"four-octet rune ends at the limit", a(71) + "🙏b", a(71) + "🙏\r\n b\r\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := foldICalLine(test.line); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// TestWriteICalLineUnfolds checks the folding rules on long multi-byte text:
+// every line fits in 75 octets, holds whole runes and unfolds to the input.
+func TestWriteICalLineUnfolds(t *testing.T) {
+	for _, line := range []string{
+		"DESCRIPTION:" + strings.Repeat("Misa syukur ulang tahun paroki\\, ", 8),
+		"SUMMARY:" + strings.Repeat("Ziarah ke Gua Maria — ", 12),
+		"LOCATION:" + strings.Repeat("ç🙏日", 40),
+	} {
+		folded := foldICalLine(line)
+		if !strings.HasSuffix(folded, "\r\n") {
+			t.Fatalf("%q does not end with CRLF", folded)
+		}
+		for i, l := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+			if len(l) > icalLineLimit {
+				t.Errorf("line %d is %d octets: %q", i, len(l), l)
+			}
+			if i > 0 && !strings.HasPrefix(l, " ") {
+				t.Errorf("continuation line %d does not start with a space: %q", i, l)
+			}
+			if !utf8.ValidString(l) {
+				t.Errorf("line %d splits a UTF-8 sequence: %q", i, l)
+			}
+		}
+		if unfolded := strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""); unfolded != line {
+			t.Errorf("unfolds to %q, want %q", unfolded, line)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const INTENTION_PENDING = "pending"
+const INTENTION_CONFIRMED = "confirmed"
+const INTENTION_REJECTED = "rejected"
+
+var INTENTION_STATUSES = []string{INTENTION_PENDING, INTENTION_CONFIRMED, INTENTION_REJECTED}
+
+// Kinds of intentions, in the order the lector reads them.
+const INTENTION_SYUKUR = "syukur"
+const INTENTION_ULANG_TAHUN = "ulang-tahun"
+const INTENTION_PERMOHONAN = "permohonan"
+const INTENTION_ARWAH = "arwah"
+
+var INTENTION_KINDS = []string{INTENTION_SYUKUR, INTENTION_ULANG_TAHUN, INTENTION_PERMOHONAN, INTENTION_ARWAH}
+
+var intentionKindLabels = map[string]string{
+	INTENTION_SYUKUR:      "Ujud Syukur",
+	INTENTION_ULANG_TAHUN: "Ujud Ulang Tahun",
+	INTENTION_PERMOHONAN:  "Ujud Permohonan",
+	INTENTION_ARWAH:       "Ujud Arwah",
+}
+
+// Intentions are taken for Masses at least INTENTION_NOTICE ahead and at most
+// INTENTION_MAX_DAYS days ahead.
+const INTENTION_NOTICE = 3 * time.Hour
+const INTENTION_MAX_DAYS = 92
+
+func IntentionKindLabel(kind string) string {
+	if label, ok := intentionKindLabels[kind]; ok {
+		return label
+	}
+	return kind
+}
+
+// ValidMassRef checks a reference to a Mass of the schedule: a date with the
+// scheduleId of a regular Mass or the exceptionId of one added for that date.
+func ValidMassRef(date string, scheduleId, exceptionId *int) error {
+	if _, err := time.Parse(time.DateOnly, date); err != nil {
+		return errors.New("invalid date (use YYYY-MM-DD)")
+	}
+	if (scheduleId == nil) == (exceptionId == nil) {
+		return errors.New("give either the scheduleId or the exceptionId of the Mass")
+	}
+	return nil
+}
+
+// LectorList is what the lector reads out at the Masses of one day: the
+// confirmed intentions of every Mass, grouped by kind.
+type LectorList struct {
+	Date   time.Time
+	Masses []*LectorMass
+}
+
+type LectorMass struct {
+	Mass   *Mass
+	Groups []*LectorGroup
+}
+
+type LectorGroup struct {
+	Kind       string
+	Label      string
+	Intentions []*LectorIntention
+}
+
+type LectorIntention struct {
+	ForName   string
+	Intention string
+}
+
+// GetLectorList collects the confirmed intentions for the Masses of date,
+// locationId > 0 keeps a single church or chapel. Masses without intentions
+// and cancelled Masses are left out.
+func GetLectorList(ctx context.Context, db *sql.DB, date time.Time, locationId int) (*LectorList, error) {
+	days, err := GetMassSchedule(ctx, db, date, date, locationId)
+	if err != nil {
+		return nil, err
+	}
+	day := date.In(ParishLocation()).Format(time.DateOnly)
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT kind, for_name, intention, schedule_id, exception_id
+		FROM mass_intentions
+		WHERE mass_date = ? AND status = ?
+		ORDER BY created_at ASC, id ASC`, day, INTENTION_CONFIRMED)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	masses := map[*Mass]*LectorMass{}
+	for rows.Next() {
+		var kind, forName string
+		var intention sql.NullString
+		var scheduleId, exceptionId *int
+		if err := rows.Scan(&kind, &forName, &intention, &scheduleId, &exceptionId); err != nil {
+			return nil, err
+		}
+		mass := FindMass(days, day, scheduleId, exceptionId)
+		if mass == nil || mass.Cancelled {
+			continue
+		}
+		entry, ok := masses[mass]
+		if !ok {
+			entry = &LectorMass{Mass: mass}
+			masses[mass] = entry
+		}
+		var group *LectorGroup
+		for _, g := range entry.Groups {
+			if g.Kind == kind {
+				group = g
+			}
+		}
+		if group == nil {
+			group = &LectorGroup{Kind: kind, Label: IntentionKindLabel(kind)}
+			entry.Groups = append(entry.Groups, group)
+		}
+		group.Intentions = append(group.Intentions, &LectorIntention{ForName: forName, Intention: intention.String})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	list := LectorList{Date: date, Masses: []*LectorMass{}}
+	for _, massDay := range days {
+		for _, mass := range massDay.Masses {
+			entry, ok := masses[mass]
+			if !ok {
+				continue
+			}
+			slices.SortStableFunc(entry.Groups, func(a, b *LectorGroup) int {
+				return intentionKindRank(a.Kind) - intentionKindRank(b.Kind)
+			})
+			list.Masses = append(list.Masses, entry)
+		}
+	}
+	return &list, nil
+}
+
+func intentionKindRank(kind string) int {
+	if i := slices.Index(INTENTION_KINDS, kind); i >= 0 {
+		return i
+	}
+	return len(INTENTION_KINDS)
+}
+
+func (l *LectorList) Heading() string {
+	return "Ujud Misa " + FormatTanggal(l.Date)
+}
+
+var lectorTemplate = template.Must(template.New("lector").Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<title>{{.Heading}}</title>
+<style>
+body { font-family: Georgia, serif; max-width: 40em; margin: 2em auto; padding: 0 1em; font-size: 1.15em; line-height: 1.6; color: #000; }
+section { margin-bottom: 2em; }
+h1 { font-size: 1.4em; text-align: center; }
+h2 { font-size: 1.15em; border-bottom: 1px solid #000; }
+h3 { font-size: 1em; margin-bottom: 0.2em; }
+ol { margin-top: 0; }
+span.ujud { font-style: italic; }
+@media print { body { margin: 0; } section { page-break-after: always; } }
+</style>
+</head>
+<body>
+<h1>{{.Heading}}</h1>
+{{range .Masses}}<section>
+<h2>Misa {{.Mass.Describe}}</h2>
+{{range .Groups}}<h3>{{.Label}}</h3>
+<ol>
+{{range .Intentions}}<li>{{.ForName}}{{with .Intention}}<br><span class="ujud">{{.}}</span>{{end}}</li>
+{{end}}</ol>
+{{end}}</section>
+{{else}}<p>Belum ada ujud yang dikonfirmasi.</p>
+{{end}}</body>
+</html>
+`))
+
+func (l *LectorList) WriteHTML(w io.Writer) error {
+	return lectorTemplate.Execute(w, l)
+}
+
+// WritePDF prints every Mass on its own page, one for each lector.
+func (l *LectorList) WritePDF(w io.Writer) error {
+	doc := PdfDocument{
+		Title:   l.Heading(),
+		Author:  BULLETIN_PUBLISHER,
+		Created: time.Now(),
+		Footer:  l.Heading(),
+	}
+	if len(l.Masses) == 0 {
+		doc.Centered(l.Heading(), PdfBold, 16)
+		doc.Space(8)
+		doc.Text("Belum ada ujud yang dikonfirmasi.", PdfItalic, 12, 0)
+	}
+	for _, entry := range l.Masses {
+		doc.PageBreak()
+		doc.Centered(l.Heading(), PdfBold, 16)
+		doc.Space(6)
+		doc.Text("Misa "+entry.Mass.Describe(), PdfBold, 12, 0)
+		doc.Rule()
+		for _, group := range entry.Groups {
+			doc.Space(8)
+			doc.KeepTogether(50)
+			doc.Text(group.Label, PdfBold, 12, 0)
+			for i, intention := range group.Intentions {
+				doc.Space(2)
+				doc.KeepTogether(30)
+				doc.Text(fmt.Sprintf("%d. %s", i+1, intention.ForName), PdfRegular, 12, 10)
+				if intention.Intention != "" {
+					doc.Text(intention.Intention, PdfItalic, 11, 24)
+				}
+			}
+		}
+	}
+	return doc.Write(w)
+}
+
+// WriteLectorList sends the list as "html" or "pdf".
+func (r *Responses) WriteLectorList(ctx *gin.Context, l *LectorList, format string) {
+	var buf bytes.Buffer
+	var err error
+	var contentType string
+	switch format {
+	case "pdf":
+		err = l.WritePDF(&buf)
+		contentType = "application/pdf"
+	default:
+		format = "html"
+		err = l.WriteHTML(&buf)
+		contentType = "text/html; charset=utf-8"
+	}
+	if err != nil {
+		r.AbortWithStatusJSON(ctx, err, "failed to build intention list", err.Error(), http.StatusInternalServerError, nil)
+		return
+	}
+
+	fileName := fmt.Sprintf("ujud-misa-%s.%s", l.Date.In(ParishLocation()).Format(time.DateOnly), format)
+	ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileName))
+	r.SuccessWithData(ctx, contentType, buf.Bytes(), fileName)
+}
@@ -0,0 +1,251 @@
+package lib
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const LIST_OFFSET = "offset"
+const LIST_CURSOR = "cursor"
+
+// ListSpec describes what a list endpoint accepts in its query string:
+//
+//	?page=2&limit=20          offset paging
+//	?cursor=&limit=20         cursor paging, pass meta.nextCursor to continue
+//	?sort=-publishedAt        sort key, "-" for descending
+//	?q=term                   search on the Search columns
+//	?<filter>=value           equality filters
+type ListSpec struct {
+	DefaultLimit int
+	MaxLimit     int
+	// Sorts maps public sort keys to SQL columns. NULLs sort first in
+	// ascending order and last in descending order, as MySQL does.
+	Sorts       map[string]string
+	DefaultSort string
+	// SortAliases keeps older ?sort= values working, e.g. "za": "-name".
+	SortAliases map[string]string
+	// LenientSort falls back to DefaultSort on an unknown ?sort= instead of
+	// failing, for endpoints whose clients always got a list.
+	LenientSort bool
+	Filters     map[string]string
+	Search      []string
+	// IdColumn breaks ties between equal sort values and anchors the cursor.
+	IdColumn string
+}
+
+type ListMeta struct {
+	Mode       string            `json:"mode"`
+	Limit      int               `json:"limit"`
+	Sort       string            `json:"sort"`
+	Filters    map[string]string `json:"filters"`
+	Page       int               `json:"page,omitempty"`
+	Total      int               `json:"total"`
+	TotalPages int               `json:"totalPages,omitempty"`
+	HasMore    bool              `json:"hasMore"`
+	NextCursor *string           `json:"nextCursor"`
+}
+
+type listCursor struct {
+	Value *string `json:"v"`
+	Id    int     `json:"id"`
+}
+
+type listRow struct {
+	value sql.NullString
+	id    int
+}
+
+// ListQuery is a parsed ListSpec. Handlers build their SQL from Filter (for
+// COUNT), Where, OrderBy and Paginate, select SortColumn as the last column and
+// report every scanned row through Scanned before calling Trim and Meta.
+type ListQuery struct {
+	spec    ListSpec
+	Mode    string
+	Page    int
+	Limit   int
+	sort    string
+	column  string
+	desc    bool
+	cursor  *listCursor
+	conds   []string
+	args    []any
+	filters map[string]string
+	rows    []listRow
+}
+
+func ParseListQuery(ctx *gin.Context, spec ListSpec) (*ListQuery, error) {
+	q := &ListQuery{spec: spec, Mode: LIST_OFFSET, Page: 1, Limit: spec.DefaultLimit, filters: map[string]string{}}
+
+	if limit, err := strconv.Atoi(ctx.Query("limit")); err == nil && limit > 0 && limit <= spec.MaxLimit {
+		q.Limit = limit
+	}
+	if raw, ok := ctx.GetQuery("cursor"); ok {
+		q.Mode = LIST_CURSOR
+		if raw != "" {
+			cursor, err := decodeCursor(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cursor")
+			}
+			q.cursor = cursor
+		}
+	} else if page, err := strconv.Atoi(ctx.DefaultQuery("page", "1")); err == nil && page > 1 {
+		q.Page = page
+	}
+
+	sort := ctx.DefaultQuery("sort", spec.DefaultSort)
+	if alias, ok := spec.SortAliases[sort]; ok {
+		sort = alias
+	}
+	key, desc := strings.CutPrefix(sort, "-")
+	column, ok := spec.Sorts[key]
+	if !ok && spec.LenientSort {
+		sort = spec.DefaultSort
+		key, desc = strings.CutPrefix(sort, "-")
+		column, ok = spec.Sorts[key]
+	}
+	if !ok {
+		keys := slices.Sorted(maps.Keys(spec.Sorts))
+		return nil, fmt.Errorf("sort must be one of %s (prefix with - for descending)", strings.Join(keys, ", "))
+	}
+	q.sort, q.column, q.desc = sort, column, desc
+
+	for param, col := range spec.Filters {
+		if v := ctx.Query(param); v != "" {
+			q.AddCondition(col+" = ?", v)
+			q.filters[param] = v
+		}
+	}
+	if search := strings.TrimSpace(ctx.Query("q")); search != "" && len(spec.Search) > 0 {
+		likes := make([]string, len(spec.Search))
+		args := make([]any, len(spec.Search))
+		for i, col := range spec.Search {
+			likes[i] = col + " LIKE ?"
+			args[i] = "%" + search + "%"
+		}
+		q.AddCondition("("+strings.Join(likes, " OR ")+")", args...)
+		q.filters["q"] = search
+	}
+
+	return q, nil
+}
+
+func decodeCursor(raw string) (*listCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+func encodeCursor(cursor listCursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// AddCondition adds a filter the handler computes itself.
+func (q *ListQuery) AddCondition(cond string, args ...any) {
+	q.conds = append(q.conds, cond)
+	q.args = append(q.args, args...)
+}
+
+// Filter returns the filter conditions as " AND ..." for the COUNT query.
+func (q *ListQuery) Filter() (string, []any) {
+	if len(q.conds) == 0 {
+		return "", []any{}
+	}
+	return " AND " + strings.Join(q.conds, " AND "), append([]any{}, q.args...)
+}
+
+// Where returns the filter conditions plus the cursor position as " AND ...".
+func (q *ListQuery) Where() (string, []any) {
+	where, args := q.Filter()
+	if q.cursor == nil {
+		return where, args
+	}
+	op := ">"
+	if q.desc {
+		op = "<"
+	}
+	col, id := q.column, q.spec.IdColumn
+	switch {
+	case q.cursor.Value == nil && q.desc:
+		// NULLs come last, only the remaining NULLs follow
+		where += fmt.Sprintf(" AND (%s IS NULL AND %s %s ?)", col, id, op)
+		return where, append(args, q.cursor.Id)
+	case q.cursor.Value == nil:
+		// NULLs come first, the remaining NULLs and every value follow
+		where += fmt.Sprintf(" AND ((%s IS NULL AND %s %s ?) OR %s IS NOT NULL)", col, id, op, col)
+		return where, append(args, q.cursor.Id)
+	case q.desc:
+		where += fmt.Sprintf(" AND (%s %s ? OR (%s = ? AND %s %s ?) OR %s IS NULL)", col, op, col, id, op, col)
+	default:
+		where += fmt.Sprintf(" AND (%s %s ? OR (%s = ? AND %s %s ?))", col, op, col, id, op)
+	}
+	return where, append(args, *q.cursor.Value, *q.cursor.Value, q.cursor.Id)
+}
+
+func (q *ListQuery) OrderBy() string {
+	dir := "ASC"
+	if q.desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("%s %s, %s %s", q.column, dir, q.spec.IdColumn, dir)
+}
+
+// Paginate fetches one row more than the page size to know whether more follow.
+func (q *ListQuery) Paginate() (string, []any) {
+	if q.Mode == LIST_CURSOR {
+		return "LIMIT ?", []any{q.Limit + 1}
+	}
+	return "LIMIT ? OFFSET ?", []any{q.Limit + 1, (q.Page - 1) * q.Limit}
+}
+
+func (q *ListQuery) SortColumn() string {
+	return q.column
+}
+
+// Scanned records the sort value and id of a row in the order it was read.
+func (q *ListQuery) Scanned(value sql.NullString, id int) {
+	q.rows = append(q.rows, listRow{value, id})
+}
+
+// Trim returns how many of the scanned rows belong to the page.
+func (q *ListQuery) Trim() int {
+	return min(len(q.rows), q.Limit)
+}
+
+func (q *ListQuery) Meta(total int) *ListMeta {
+	meta := &ListMeta{
+		Mode:    q.Mode,
+		Limit:   q.Limit,
+		Sort:    q.sort,
+		Filters: q.filters,
+		Total:   total,
+		HasMore: len(q.rows) > q.Limit,
+	}
+	if q.Mode == LIST_OFFSET {
+		meta.Page = q.Page
+		meta.TotalPages = (total + q.Limit - 1) / q.Limit
+	} else if meta.HasMore {
+		last := q.rows[q.Limit-1]
+		cursor := listCursor{Id: last.id}
+		if last.value.Valid {
+			cursor.Value = &last.value.String
+		}
+		next := encodeCursor(cursor)
+		meta.NextCursor = &next
+	}
+	return meta
+}
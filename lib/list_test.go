@@ -0,0 +1,94 @@
+package lib
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListCursorRoundTrip(t *testing.T) {
+	value := func(s string) *string { return &s }
+	for _, cursor := range []listCursor{
+		{Value: value("2024-03-01 00:00:00"), Id: 1},
+		{Value: value(""), Id: 7},
+		{Value: value("Paskah & \"Natal\" / 日本 ?=+"), Id: 42},
+		{Value: nil, Id: 3},
+	} {
+		raw := encodeCursor(cursor)
+		if _, err := base64.RawURLEncoding.DecodeString(raw); err != nil {
+			t.Errorf("%q is not unpadded base64url: %v", raw, err)
+		}
+		got, err := decodeCursor(raw)
+		if err != nil {
+			t.Fatalf("decodeCursor(%q): %v", raw, err)
+		}
+		if got.Id != cursor.Id || (got.Value == nil) != (cursor.Value == nil) ||
+			(got.Value != nil && *got.Value != *cursor.Value) {
+			t.Errorf("%+v came back as %+v", cursor, got)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for name, raw := range map[string]string{
+		"not base64":      "not a cursor!",
+		"padded base64":   base64.URLEncoding.EncodeToString([]byte(`{"v":"a","id":1}`)),
+		"standard base64": base64.RawStdEncoding.EncodeToString([]byte(`{"v":"??>","id":1}`)),
+		"not JSON":        base64.RawURLEncoding.EncodeToString([]byte("v=a;id=1")),
+		"wrong id type":   base64.RawURLEncoding.EncodeToString([]byte(`{"v":"a","id":"1"}`)),
+	} {
+		if cursor, err := decodeCursor(raw); err == nil {
+			t.Errorf("%s: decodeCursor(%q) = %+v, want an error", name, raw, cursor)
+		}
+	}
+}
+
+func TestParseListQueryCursor(t *testing.T) {
+	spec := ListSpec{
+		DefaultLimit: 10,
+		MaxLimit:     50,
+		Sorts:        map[string]string{"date": "published_at"},
+		DefaultSort:  "-date",
+		IdColumn:     "id",
+	}
+	gin.SetMode(gin.TestMode)
+	parse := func(query string) (*ListQuery, error) {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+		return ParseListQuery(ctx, spec)
+	}
+
+	if _, err := parse("cursor=not+a+cursor"); err == nil {
+		t.Error("an invalid cursor was accepted")
+	}
+
+	q, err := parse("cursor=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Mode != LIST_CURSOR || q.cursor != nil {
+		t.Errorf("empty cursor: mode %q, cursor %+v, want the first cursor page", q.Mode, q.cursor)
+	}
+
+	// the cursor a page hands out resumes after its last row
+	q.Limit = 1
+	q.Scanned(sql.NullString{String: "2024-03-01", Valid: true}, 5)
+	q.Scanned(sql.NullString{String: "2024-01-07", Valid: true}, 4)
+	meta := q.Meta(2)
+	if !meta.HasMore || meta.NextCursor == nil {
+		t.Fatalf("meta %+v, want a next cursor", meta)
+	}
+	next, err := parse("cursor=" + *meta.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	where, args := next.Where()
+	want := " AND (published_at < ? OR (published_at = ? AND id < ?) OR published_at IS NULL)"
+	if where != want || len(args) != 3 || args[0] != "2024-03-01" || args[2] != 5 {
+		t.Errorf("Where() = %q, %v, want %q after 2024-03-01 and id 5", where, args, want)
+	}
+}
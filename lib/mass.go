@@ -0,0 +1,355 @@
+package lib
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const MASS_CANCEL = "cancel"
+const MASS_CHANGE = "change"
+const MASS_ADD = "add"
+
+var MASS_ACTIONS = []string{MASS_CANCEL, MASS_CHANGE, MASS_ADD}
+
+const MASS_DEFAULT_LANGUAGE = "Indonesia"
+
+var clockPattern = regexp.MustCompile(`^(\d{1,2})[:.](\d{2})(?::(\d{2}))?$`)
+
+type MassLocation struct {
+	Id      int     `json:"id"`
+	Name    string  `json:"name"`
+	Slug    string  `json:"slug"`
+	Address *string `json:"address"`
+	order   int
+}
+
+// Mass is one celebration on a given date, either from the weekly schedule
+// (ScheduleId) or added for that date only (ExceptionId). Changed and
+// cancelled regular Masses carry both.
+type Mass struct {
+	Date        string        `json:"date"`
+	Start       time.Time     `json:"start"`
+	Time        string        `json:"time"`
+	Location    *MassLocation `json:"location"`
+	Language    string        `json:"language"`
+	Label       *string       `json:"label"`
+	Notes       *string       `json:"notes"`
+	ScheduleId  *int          `json:"scheduleId"`
+	ExceptionId *int          `json:"exceptionId"`
+	Changed     bool          `json:"changed"`
+	Cancelled   bool          `json:"cancelled"`
+}
+
+type MassDay struct {
+	Date    string   `json:"date"`
+	Weekday string   `json:"weekday"`
+	Feasts  []string `json:"feasts"`
+	Masses  []*Mass  `json:"masses"`
+}
+
+type massSchedule struct {
+	id         int
+	locationId int
+	weekday    int
+	startTime  string
+	language   string
+	label      *string
+	notes      *string
+	validFrom  *string
+	validUntil *string
+}
+
+type massException struct {
+	id         int
+	date       string
+	action     string
+	scheduleId *int
+	locationId *int
+	startTime  *string
+	language   *string
+	label      *string
+	notes      *string
+	feast      *string
+}
+
+// ParseClock reads a time of day as written in the parish ("7.30", "07:30")
+// and returns it the way MySQL stores TIME, "07:30:00".
+func ParseClock(s string) (string, error) {
+	m := clockPattern.FindStringSubmatch(s)
+	if m == nil {
+		return "", errors.New("invalid time (use HH:MM)")
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	second := 0
+	if m[3] != "" {
+		second, _ = strconv.Atoi(m[3])
+	}
+	if hour > 23 || minute > 59 || second > 59 {
+		return "", errors.New("invalid time (use HH:MM)")
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", hour, minute, second), nil
+}
+
+// FormatJam writes a stored TIME the Indonesian way, "07.30".
+func FormatJam(clock string) string {
+	if len(clock) < 5 {
+		return clock
+	}
+	return clock[:2] + "." + clock[3:5]
+}
+
+func massStart(date string, clock string) time.Time {
+	t, err := time.ParseInLocation(time.DateOnly+" 15:04:05", date+" "+clock, ParishLocation())
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func GetMassLocations(ctx context.Context, db *sql.DB) ([]*MassLocation, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, name, slug, address, sort_order
+		FROM mass_locations
+		ORDER BY sort_order ASC, name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []*MassLocation{}
+	for rows.Next() {
+		var location MassLocation
+		if err := rows.Scan(&location.Id, &location.Name, &location.Slug, &location.Address, &location.order); err != nil {
+			return nil, err
+		}
+		locations = append(locations, &location)
+	}
+	return locations, rows.Err()
+}
+
+// GetMassSchedule works out the Masses celebrated on every day from from to
+// to (inclusive, parish dates): the weekly schedule valid on that day with
+// the exceptions for it applied. Cancelled Masses are kept and flagged so the
+// site can say so. locationId > 0 keeps a single church or chapel.
+func GetMassSchedule(ctx context.Context, db *sql.DB, from, to time.Time, locationId int) ([]*MassDay, error) {
+	fromDate := from.In(ParishLocation()).Format(time.DateOnly)
+	toDate := to.In(ParishLocation()).Format(time.DateOnly)
+
+	locationList, err := GetMassLocations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	locations := map[int]*MassLocation{}
+	for _, location := range locationList {
+		locations[location.Id] = location
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, location_id, weekday, start_time, language, label, notes, valid_from, valid_until
+		FROM mass_schedules
+		WHERE (valid_from IS NULL OR valid_from <= ?) AND (valid_until IS NULL OR valid_until >= ?)
+		ORDER BY start_time ASC, id ASC`, toDate, fromDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	schedules := []*massSchedule{}
+	for rows.Next() {
+		var s massSchedule
+		if err := rows.Scan(&s.id, &s.locationId, &s.weekday, &s.startTime, &s.language,
+			&s.label, &s.notes, &s.validFrom, &s.validUntil); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	exceptionRows, err := db.QueryContext(ctx, `
+		SELECT id, mass_date, action, schedule_id, location_id, start_time, language, label, notes, feast
+		FROM mass_exceptions
+		WHERE mass_date BETWEEN ? AND ?
+		ORDER BY mass_date ASC, id ASC`, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	defer exceptionRows.Close()
+	exceptions := map[string][]*massException{}
+	for exceptionRows.Next() {
+		var e massException
+		if err := exceptionRows.Scan(&e.id, &e.date, &e.action, &e.scheduleId, &e.locationId,
+			&e.startTime, &e.language, &e.label, &e.notes, &e.feast); err != nil {
+			return nil, err
+		}
+		exceptions[e.date] = append(exceptions[e.date], &e)
+	}
+	if err := exceptionRows.Err(); err != nil {
+		return nil, err
+	}
+
+	days := []*MassDay{}
+	for day := from.In(ParishLocation()); day.Format(time.DateOnly) <= toDate; day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		massDay := MassDay{Date: date, Weekday: indonesianDays[day.Weekday()], Feasts: []string{}, Masses: []*Mass{}}
+
+		for _, s := range schedules {
+			if s.weekday != int(day.Weekday()) ||
+				(s.validFrom != nil && *s.validFrom > date) || (s.validUntil != nil && *s.validUntil < date) {
+				continue
+			}
+			scheduleId := s.id
+			massDay.Masses = append(massDay.Masses, &Mass{
+				Date:       date,
+				Start:      massStart(date, s.startTime),
+				Time:       FormatJam(s.startTime),
+				Location:   locations[s.locationId],
+				Language:   s.language,
+				Label:      s.label,
+				Notes:      s.notes,
+				ScheduleId: &scheduleId,
+			})
+		}
+
+		for _, e := range exceptions[date] {
+			if e.feast != nil && !slices.Contains(massDay.Feasts, *e.feast) {
+				massDay.Feasts = append(massDay.Feasts, *e.feast)
+			}
+			exceptionId := e.id
+			switch e.action {
+			case MASS_ADD:
+				if e.locationId == nil || e.startTime == nil {
+					continue
+				}
+				mass := Mass{
+					Date:        date,
+					Start:       massStart(date, *e.startTime),
+					Time:        FormatJam(*e.startTime),
+					Location:    locations[*e.locationId],
+					Language:    MASS_DEFAULT_LANGUAGE,
+					Label:       e.label,
+					Notes:       e.notes,
+					ExceptionId: &exceptionId,
+				}
+				if e.language != nil {
+					mass.Language = *e.language
+				}
+				massDay.Masses = append(massDay.Masses, &mass)
+			case MASS_CANCEL, MASS_CHANGE:
+				for _, mass := range massDay.Masses {
+					// Masses added for the day are edited through their own exception
+					if mass.ScheduleId == nil {
+						continue
+					}
+					if e.scheduleId != nil && *e.scheduleId != *mass.ScheduleId {
+						continue
+					}
+					if e.action == MASS_CANCEL {
+						if e.scheduleId == nil && e.locationId != nil && mass.Location != nil && mass.Location.Id != *e.locationId {
+							continue
+						}
+						mass.Cancelled = true
+						if e.notes != nil {
+							mass.Notes = e.notes
+						}
+					} else {
+						if e.scheduleId == nil {
+							continue
+						}
+						mass.Changed = true
+						if e.startTime != nil {
+							mass.Start = massStart(date, *e.startTime)
+							mass.Time = FormatJam(*e.startTime)
+						}
+						if e.locationId != nil {
+							mass.Location = locations[*e.locationId]
+						}
+						if e.language != nil {
+							mass.Language = *e.language
+						}
+						if e.label != nil {
+							mass.Label = e.label
+						}
+						if e.notes != nil {
+							mass.Notes = e.notes
+						}
+					}
+					mass.ExceptionId = &exceptionId
+				}
+			}
+		}
+
+		if locationId > 0 {
+			massDay.Masses = slices.DeleteFunc(massDay.Masses, func(m *Mass) bool {
+				return m.Location == nil || m.Location.Id != locationId
+			})
+		}
+		slices.SortStableFunc(massDay.Masses, func(a, b *Mass) int {
+			if c := a.Start.Compare(b.Start); c != 0 {
+				return c
+			}
+			if a.Location == nil || b.Location == nil {
+				return 0
+			}
+			return a.Location.order - b.Location.order
+		})
+		days = append(days, &massDay)
+	}
+	return days, nil
+}
+
+// Describe names the Mass in words, e.g.
+// "Minggu, 18 Oktober 2026 pukul 07.30, Gereja St. Matias Rasul".
+func (m *Mass) Describe() string {
+	s := FormatTanggal(m.Start) + " pukul " + m.Time
+	if m.Location != nil {
+		s += ", " + m.Location.Name
+	}
+	return s
+}
+
+// FindMass picks a Mass out of a computed schedule. Regular Masses are
+// known by their scheduleId, those added for the day by their exceptionId.
+func FindMass(days []*MassDay, date string, scheduleId, exceptionId *int) *Mass {
+	for _, day := range days {
+		if day.Date != date {
+			continue
+		}
+		for _, mass := range day.Masses {
+			if scheduleId != nil && mass.ScheduleId != nil && *mass.ScheduleId == *scheduleId {
+				return mass
+			}
+			if scheduleId == nil && exceptionId != nil && mass.ScheduleId == nil &&
+				mass.ExceptionId != nil && *mass.ExceptionId == *exceptionId {
+				return mass
+			}
+		}
+	}
+	return nil
+}
+
+// GetMass looks up a single Mass of date (YYYY-MM-DD) in the schedule. It
+// returns sql.ErrNoRows when no such Mass is celebrated that day; cancelled
+// Masses are returned, flagged.
+func GetMass(ctx context.Context, db *sql.DB, date string, scheduleId, exceptionId *int) (*Mass, error) {
+	day, err := time.ParseInLocation(time.DateOnly, date, ParishLocation())
+	if err != nil {
+		return nil, err
+	}
+	days, err := GetMassSchedule(ctx, db, day, day, 0)
+	if err != nil {
+		return nil, err
+	}
+	mass := FindMass(days, date, scheduleId, exceptionId)
+	if mass == nil {
+		return nil, sql.ErrNoRows
+	}
+	return mass, nil
+}
@@ -0,0 +1,285 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// A4 in points, the bulletin is printed on A4 at the secretariat.
+const pdfPageWidth = 595.0
+const pdfPageHeight = 842.0
+const pdfMargin = 56.0
+const pdfLineSpacing = 1.35
+
+type PdfFont int
+
+const (
+	PdfRegular PdfFont = iota
+	PdfBold
+	PdfItalic
+)
+
+var pdfFontNames = []string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique"}
+
+// Advance widths of the standard Helvetica faces for ' ' through '~', in
+// 1/1000 of the font size. The oblique face shares the regular widths.
+var pdfHelveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var pdfHelveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// pdfWinAnsi maps the punctuation word processors like to produce to
+// WinAnsiEncoding. Latin-1 maps to itself, anything else prints as "?".
+var pdfWinAnsi = map[rune]byte{
+	'€': 128, '…': 133, '‘': 145, '’': 146, '“': 147, '”': 148,
+	'•': 149, '–': 150, '—': 151,
+}
+
+// PdfDocument lays out flowing text on A4 pages with the standard PDF fonts,
+// which every reader has, so nothing needs to be embedded. It is meant for
+// simple printouts, not for arbitrary HTML.
+type PdfDocument struct {
+	Title   string
+	Author  string
+	Created time.Time
+	// Footer is printed at the bottom of every page, next to the page number
+	Footer string
+
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func pdfEncode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 32 && r <= 126, r >= 160 && r <= 255:
+			out = append(out, byte(r))
+		case pdfWinAnsi[r] != 0:
+			out = append(out, pdfWinAnsi[r])
+		case r == '\t':
+			out = append(out, ' ')
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+func pdfEscape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c == '(' || c == ')' || c == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// PdfTextWidth measures s in points.
+func PdfTextWidth(s string, font PdfFont, size float64) float64 {
+	widths := &pdfHelveticaWidths
+	if font == PdfBold {
+		widths = &pdfHelveticaBoldWidths
+	}
+	total := 0
+	for _, c := range pdfEncode(s) {
+		if c >= 32 && c <= 126 {
+			total += widths[c-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// pdfWrap breaks text into lines no wider than width. Newlines in text start
+// a new line, words longer than a line are split.
+func pdfWrap(text string, font PdfFont, size float64, width float64) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if PdfTextWidth(candidate, font, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = word
+			for len([]rune(line)) > 1 && PdfTextWidth(line, font, size) > width {
+				runes := []rune(line)
+				cut := len(runes) - 1
+				for cut > 1 && PdfTextWidth(string(runes[:cut]), font, size) > width {
+					cut--
+				}
+				lines = append(lines, string(runes[:cut]))
+				line = string(runes[cut:])
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (d *PdfDocument) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.newPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *PdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// ensure starts a new page unless height points still fit on this one.
+func (d *PdfDocument) ensure(height float64) {
+	if len(d.pages) == 0 || d.y-height < pdfMargin {
+		d.newPage()
+	}
+}
+
+func (d *PdfDocument) drawText(x, y float64, s string, font PdfFont, size float64) {
+	fmt.Fprintf(d.page(), "BT /F%d %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+		int(font)+1, size, x, y, pdfEscape(pdfEncode(s)))
+}
+
+// Text adds a wrapped paragraph, indented by indent points.
+func (d *PdfDocument) Text(text string, font PdfFont, size float64, indent float64) {
+	lineHeight := size * pdfLineSpacing
+	for _, line := range pdfWrap(text, font, size, pdfPageWidth-2*pdfMargin-indent) {
+		d.ensure(lineHeight)
+		d.y -= lineHeight
+		if line != "" {
+			d.drawText(pdfMargin+indent, d.y+size*(pdfLineSpacing-1), line, font, size)
+		}
+	}
+}
+
+// Centered adds a single centered line, it is not wrapped.
+func (d *PdfDocument) Centered(text string, font PdfFont, size float64) {
+	lineHeight := size * pdfLineSpacing
+	d.ensure(lineHeight)
+	d.y -= lineHeight
+	x := (pdfPageWidth - PdfTextWidth(text, font, size)) / 2
+	d.drawText(max(x, pdfMargin), d.y+size*(pdfLineSpacing-1), text, font, size)
+}
+
+// KeepTogether starts a new page when less than height points are left, so a
+// heading does not end up alone at the bottom of a page.
+func (d *PdfDocument) KeepTogether(height float64) {
+	d.ensure(height)
+}
+
+// PageBreak continues on a fresh page, unless this one is still empty.
+func (d *PdfDocument) PageBreak() {
+	if len(d.pages) > 0 && d.y < pdfPageHeight-pdfMargin {
+		d.newPage()
+	}
+}
+
+func (d *PdfDocument) Space(height float64) {
+	if len(d.pages) == 0 {
+		d.newPage()
+	}
+	d.y -= height
+}
+
+// Rule draws a horizontal line across the text area.
+func (d *PdfDocument) Rule() {
+	d.ensure(8)
+	d.y -= 4
+	fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n",
+		pdfMargin, d.y, pdfPageWidth-pdfMargin, d.y)
+	d.y -= 4
+}
+
+func pdfDate(t time.Time) string {
+	return t.UTC().Format("D:20060102150405Z")
+}
+
+// Write renders the document as PDF 1.4.
+func (d *PdfDocument) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		d.newPage()
+	}
+
+	var buf bytes.Buffer
+	offsets := []int{}
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// 1 catalog, 2 page tree, 3 info, 4-6 fonts, then a page and its
+	// content stream for every page
+	const firstPage = 7
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	created := d.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+	object(fmt.Sprintf("<< /Title (%s) /Author (%s) /CreationDate (%s) >>",
+		pdfEscape(pdfEncode(d.Title)), pdfEscape(pdfEncode(d.Author)), pdfDate(created)))
+	for _, name := range pdfFontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+	for i, page := range d.pages {
+		var content bytes.Buffer
+		content.Write(page.Bytes())
+		size := 8.0
+		number := fmt.Sprintf("%d / %d", i+1, len(d.pages))
+		fmt.Fprintf(&content, "BT /F1 %.1f Tf %.2f %.2f Td (%s) Tj ET\n", size,
+			pdfPageWidth-pdfMargin-PdfTextWidth(number, PdfRegular, size), pdfMargin/2, number)
+		if d.Footer != "" {
+			fmt.Fprintf(&content, "BT /F1 %.1f Tf %.2f %.2f Td (%s) Tj ET\n", size,
+				pdfMargin, pdfMargin/2, pdfEscape(pdfEncode(d.Footer)))
+		}
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 4 0 R /F2 5 0 R /F3 6 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
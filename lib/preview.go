@@ -0,0 +1,78 @@
+package lib
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/conf"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const PreviewArticle = "article"
+const PreviewEdition = "edition"
+
+type PreviewClaims struct {
+	Kind string `json:"kind"`
+	Id   int    `json:"id"`
+	jwt.RegisteredClaims
+}
+
+// ArticleId returns the previewed article id, or 0 if the token is for an edition
+func (p *PreviewClaims) ArticleId() int {
+	if p == nil || p.Kind != PreviewArticle {
+		return 0
+	}
+	return p.Id
+}
+
+// EditionId returns the previewed edition id, or 0 if the token is for an article
+func (p *PreviewClaims) EditionId() int {
+	if p == nil || p.Kind != PreviewEdition {
+		return 0
+	}
+	return p.Id
+}
+
+// preview tokens are signed with a key derived from the admin secret so they
+// can never be replayed as admin login tokens and vice versa
+func previewKey() []byte {
+	mac := hmac.New(sha256.New, []byte(conf.JWT_SECRET))
+	mac.Write([]byte("zaitun-preview"))
+	return mac.Sum(nil)
+}
+
+func NewPreviewToken(kind string, id int, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl).UTC()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, PreviewClaims{
+		Kind: kind,
+		Id:   id,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   fmt.Sprintf("%s:%d", kind, id),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	})
+	signed, err := token.SignedString(previewKey())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+func ParsePreviewToken(tokenString string) (*PreviewClaims, error) {
+	var claims PreviewClaims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		return previewKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.Id < 1 ||
+		(claims.Kind != PreviewArticle && claims.Kind != PreviewEdition) {
+		return nil, errors.New("malformed preview token")
+	}
+	return &claims, nil
+}
@@ -0,0 +1,57 @@
+package lib
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter allows at most limit hits per key within a sliding window.
+// Keys are forgotten once their window has passed.
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	hits      map[string][]time.Time
+	lastSweep time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window, hits: map[string][]time.Time{}, lastSweep: time.Now()}
+}
+
+// Allow records a hit for key and reports whether it is within the limit.
+// Rejected hits are not recorded.
+func (r *RateLimiter) Allow(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	since := now.Add(-r.window)
+	if now.Sub(r.lastSweep) > r.window {
+		for k, hits := range r.hits {
+			if len(hits) == 0 || hits[len(hits)-1].Before(since) {
+				delete(r.hits, k)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	hits := r.hits[key]
+	i := 0
+	for i < len(hits) && hits[i].Before(since) {
+		i++
+	}
+	hits = hits[i:]
+	if len(hits) >= r.limit {
+		r.hits[key] = hits
+		return false
+	}
+	r.hits[key] = append(hits, now)
+	return true
+}
+
+// CommentRateLimiter throttles reader comment submissions per client.
+var CommentRateLimiter = NewRateLimiter(5, 10*time.Minute)
+
+// IntentionRateLimiter throttles Mass intention submissions per client.
+var IntentionRateLimiter = NewRateLimiter(5, time.Hour)
@@ -46,6 +46,20 @@ func (r *Responses) SuccessWithStatusJSON(ctx *gin.Context, status int, reqData
 	r.logger.Info(ctx.Copy(), id, reqData, res)
 }
 
+// SuccessWithMeta answers a list request, adding the paging block next to data.
+func (r *Responses) SuccessWithMeta(ctx *gin.Context, reqData any, resData any, meta *ListMeta) {
+	id := uuid.New().String()
+	res := gin.H{
+		"_id":       id,
+		"timestamp": time.Now().UnixMilli(),
+		"data":      resData,
+		"meta":      meta,
+	}
+
+	ctx.JSON(http.StatusOK, res)
+	r.logger.Info(ctx.Copy(), id, reqData, res)
+}
+
 func (r *Responses) SuccessWithData(ctx *gin.Context, contentType string, data []byte, fileName string) {
 	id := uuid.New().String()
 	ctx.Data(http.StatusOK, contentType, data)
@@ -105,6 +119,12 @@ func (r *Responses) AbortInvalidCategory(ctx *gin.Context, err error,
 		details, http.StatusBadRequest, reqData)
 }
 
+func (r *Responses) AbortInvalidWriter(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrInvalidWriter.Error(),
+		details, http.StatusBadRequest, reqData)
+}
+
 func (r *Responses) AbortInvalidYear(ctx *gin.Context, err error,
 	details string, reqData any) {
 	r.AbortWithStatusJSON(ctx, err, ErrInvalidYear.Error(),
@@ -128,3 +148,129 @@ func (r *Responses) AbortEditionNotFound(ctx *gin.Context, err error,
 	r.AbortWithStatusJSON(ctx, err, ErrArticleNotFound.Error(),
 		details, http.StatusNotFound, reqData)
 }
+
+func (r *Responses) AbortWriterNotFound(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrWriterNotFound.Error(),
+		details, http.StatusNotFound, reqData)
+}
+
+func (r *Responses) AbortCategoryNotFound(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrCategoryNotFound.Error(),
+		details, http.StatusNotFound, reqData)
+}
+
+func (r *Responses) AbortAdNotFound(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrAdNotFound.Error(),
+		details, http.StatusNotFound, reqData)
+}
+
+func (r *Responses) AbortInvalidPreview(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrInvalidPreview.Error(),
+		details, http.StatusUnauthorized, reqData)
+}
+
+func (r *Responses) AbortInvalidComment(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrInvalidComment.Error(),
+		details, http.StatusBadRequest, reqData)
+}
+
+func (r *Responses) AbortCommentNotFound(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrCommentNotFound.Error(),
+		details, http.StatusNotFound, reqData)
+}
+
+func (r *Responses) AbortInvalidSection(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrInvalidSection.Error(),
+		details, http.StatusBadRequest, reqData)
+}
+
+func (r *Responses) AbortSectionNotFound(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrSectionNotFound.Error(),
+		details, http.StatusNotFound, reqData)
+}
+
+func (r *Responses) AbortInvalidAttachment(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrInvalidAttachment.Error(),
+		details, http.StatusBadRequest, reqData)
+}
+
+func (r *Responses) AbortAttachmentNotFound(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrAttachmentNotFound.Error(),
+		details, http.StatusNotFound, reqData)
+}
+
+func (r *Responses) AbortInvalidBulletin(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrInvalidBulletin.Error(),
+		details, http.StatusBadRequest, reqData)
+}
+
+func (r *Responses) AbortBulletinNotFound(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrBulletinNotFound.Error(),
+		details, http.StatusNotFound, reqData)
+}
+
+func (r *Responses) AbortInvalidMassLocation(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrInvalidMassLocation.Error(),
+		details, http.StatusBadRequest, reqData)
+}
+
+func (r *Responses) AbortMassLocationNotFound(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrMassLocationNotFound.Error(),
+		details, http.StatusNotFound, reqData)
+}
+
+func (r *Responses) AbortInvalidMassSchedule(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrInvalidMassSchedule.Error(),
+		details, http.StatusBadRequest, reqData)
+}
+
+func (r *Responses) AbortMassScheduleNotFound(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrMassScheduleNotFound.Error(),
+		details, http.StatusNotFound, reqData)
+}
+
+func (r *Responses) AbortInvalidMassException(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrInvalidMassException.Error(),
+		details, http.StatusBadRequest, reqData)
+}
+
+func (r *Responses) AbortMassExceptionNotFound(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrMassExceptionNotFound.Error(),
+		details, http.StatusNotFound, reqData)
+}
+
+func (r *Responses) AbortInvalidIntention(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrInvalidIntention.Error(),
+		details, http.StatusBadRequest, reqData)
+}
+
+func (r *Responses) AbortIntentionNotFound(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrIntentionNotFound.Error(),
+		details, http.StatusNotFound, reqData)
+}
+
+func (r *Responses) AbortTooManyRequests(ctx *gin.Context, err error,
+	details string, reqData any) {
+	r.AbortWithStatusJSON(ctx, err, ErrTooManyRequests.Error(),
+		details, http.StatusTooManyRequests, reqData)
+}
@@ -0,0 +1,343 @@
+package lib
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const RRULE_MAX_COUNT = 1000
+
+// rruleMaxPeriods bounds the expansion of rules that rarely or never match,
+// such as BYMONTHDAY=31 with INTERVAL=2.
+const rruleMaxPeriods = 5000
+
+const (
+	RRULE_DAILY   = "DAILY"
+	RRULE_WEEKLY  = "WEEKLY"
+	RRULE_MONTHLY = "MONTHLY"
+	RRULE_YEARLY  = "YEARLY"
+)
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// RRule is the part of RFC 5545 recurrence rules parish events need: FREQ,
+// INTERVAL, COUNT, UNTIL, BYDAY and BYMONTHDAY, e.g. "FREQ=WEEKLY;BYDAY=TU"
+// for a weekly practice or "FREQ=MONTHLY;BYDAY=1SU" for every first Sunday.
+type RRule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []RRuleDay
+	ByMonthDay []int
+}
+
+// RRuleDay is a BYDAY entry. N picks the nth such weekday of the month,
+// counted from the end when negative; 0 means every one of them.
+type RRuleDay struct {
+	N       int
+	Weekday time.Weekday
+}
+
+func ParseRRule(s string) (*RRule, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	r := RRule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRRule, part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: %s given twice", ErrInvalidRRule, key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			switch value {
+			case RRULE_DAILY, RRULE_WEEKLY, RRULE_MONTHLY, RRULE_YEARLY:
+				r.Freq = value
+			default:
+				return nil, fmt.Errorf("%w: FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY", ErrInvalidRRule)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 366 {
+				return nil, fmt.Errorf("%w: INTERVAL must be between 1 and 366", ErrInvalidRRule)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > RRULE_MAX_COUNT {
+				return nil, fmt.Errorf("%w: COUNT must be between 1 and %d", ErrInvalidRRule, RRULE_MAX_COUNT)
+			}
+			r.Count = n
+		case "UNTIL":
+			t, err := parseRRuleTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: UNTIL must look like 20260131 or 20260131T170000Z", ErrInvalidRRule)
+			}
+			r.Until = &t
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				if len(day) < 2 {
+					return nil, fmt.Errorf("%w: BYDAY %q", ErrInvalidRRule, day)
+				}
+				weekday, ok := rruleWeekdays[day[len(day)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("%w: BYDAY %q", ErrInvalidRRule, day)
+				}
+				n := 0
+				if prefix := day[:len(day)-2]; prefix != "" {
+					var err error
+					n, err = strconv.Atoi(prefix)
+					if err != nil || n == 0 || n < -5 || n > 5 {
+						return nil, fmt.Errorf("%w: BYDAY %q", ErrInvalidRRule, day)
+					}
+				}
+				r.ByDay = append(r.ByDay, RRuleDay{N: n, Weekday: weekday})
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("%w: BYMONTHDAY %q", ErrInvalidRRule, day)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "WKST":
+			// weeks always start on Monday here
+			if value != "MO" {
+				return nil, fmt.Errorf("%w: only WKST=MO is supported", ErrInvalidRRule)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %s is not supported", ErrInvalidRRule, key)
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRRule)
+	case r.Count > 0 && r.Until != nil:
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRRule)
+	case len(r.ByMonthDay) > 0 && r.Freq != RRULE_MONTHLY:
+		return nil, fmt.Errorf("%w: BYMONTHDAY needs FREQ=MONTHLY", ErrInvalidRRule)
+	case len(r.ByMonthDay) > 0 && len(r.ByDay) > 0:
+		return nil, fmt.Errorf("%w: BYDAY and BYMONTHDAY cannot be combined", ErrInvalidRRule)
+	case len(r.ByDay) > 0 && r.Freq == RRULE_YEARLY:
+		return nil, fmt.Errorf("%w: BYDAY is not supported with FREQ=YEARLY", ErrInvalidRRule)
+	}
+	if r.Freq != RRULE_MONTHLY {
+		for _, day := range r.ByDay {
+			if day.N != 0 {
+				return nil, fmt.Errorf("%w: numbered BYDAY needs FREQ=MONTHLY", ErrInvalidRRule)
+			}
+		}
+	}
+	return &r, nil
+}
+
+// parseRRuleTime reads UNTIL values. Floating times are parish time, a bare
+// date includes the whole day.
+func parseRRuleTime(s string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", s, ParishLocation()); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("20060102", s, ParishLocation())
+	if err != nil {
+		return t, err
+	}
+	return t.Add(24*time.Hour - time.Second), nil
+}
+
+// String gives the rule in a canonical form, ready for an iCalendar RRULE.
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = strings.ToUpper(day.Weekday.String()[:2])
+			if day.N != 0 {
+				days[i] = strconv.Itoa(day.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences returns the starts of the occurrences of an event first held
+// at dtstart and lasting duration that overlap [from, to), at most limit of
+// them when limit > 0. The rule is expanded in the time zone of dtstart.
+func (r *RRule) Occurrences(dtstart time.Time, duration time.Duration, from, to time.Time, limit int) []time.Time {
+	occurrences := []time.Time{}
+	count := 0
+	period := 0
+	// COUNT has to be counted from the start, anything else can skip ahead
+	if r.Count == 0 {
+		period = r.periodsBefore(dtstart, from.Add(-duration))
+	}
+	for i := 0; i < rruleMaxPeriods; i, period = i+1, period+1 {
+		for _, t := range r.candidates(dtstart, period) {
+			if t.Before(dtstart) {
+				continue
+			}
+			if (r.Until != nil && t.After(*r.Until)) || !t.Before(to) {
+				return occurrences
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return occurrences
+			}
+			if (duration == 0 && !t.Before(from)) || t.Add(duration).After(from) {
+				occurrences = append(occurrences, t)
+				if limit > 0 && len(occurrences) >= limit {
+					return occurrences
+				}
+			}
+		}
+	}
+	return occurrences
+}
+
+// periodsBefore is a safe number of whole periods to skip before reaching t.
+func (r *RRule) periodsBefore(dtstart, t time.Time) int {
+	if !t.After(dtstart) {
+		return 0
+	}
+	var periods int
+	switch r.Freq {
+	case RRULE_DAILY:
+		periods = int(t.Sub(dtstart).Hours()/24) / r.Interval
+	case RRULE_WEEKLY:
+		periods = int(t.Sub(dtstart).Hours()/24/7) / r.Interval
+	case RRULE_MONTHLY:
+		periods = ((t.Year()-dtstart.Year())*12 + int(t.Month()-dtstart.Month())) / r.Interval
+	case RRULE_YEARLY:
+		periods = (t.Year() - dtstart.Year()) / r.Interval
+	}
+	return max(periods-1, 0)
+}
+
+// candidates lists the times the rule produces in its nth period, in order.
+func (r *RRule) candidates(dtstart time.Time, period int) []time.Time {
+	loc := dtstart.Location()
+	year, month, day := dtstart.Date()
+	hour, minute, second := dtstart.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, minute, second, 0, loc)
+	}
+	matchesDay := func(t time.Time) bool {
+		if len(r.ByDay) == 0 {
+			return true
+		}
+		return slices.ContainsFunc(r.ByDay, func(d RRuleDay) bool { return d.Weekday == t.Weekday() })
+	}
+
+	step := period * r.Interval
+	switch r.Freq {
+	case RRULE_DAILY:
+		t := at(year, month, day+step)
+		if !matchesDay(t) {
+			return nil
+		}
+		return []time.Time{t}
+
+	case RRULE_WEEKLY:
+		monday := day - (int(dtstart.Weekday())+6)%7 + 7*step
+		if len(r.ByDay) == 0 {
+			return []time.Time{at(year, month, day+7*step)}
+		}
+		times := []time.Time{}
+		for offset := range 7 {
+			if t := at(year, month, monday+offset); matchesDay(t) {
+				times = append(times, t)
+			}
+		}
+		return times
+
+	case RRULE_MONTHLY:
+		first := at(year, month+time.Month(step), 1)
+		y, m := first.Year(), first.Month()
+		daysIn := time.Date(y, m+1, 0, 0, 0, 0, 0, loc).Day()
+		days := []int{}
+		switch {
+		case len(r.ByMonthDay) > 0:
+			for _, d := range r.ByMonthDay {
+				if d < 0 {
+					d = daysIn + d + 1
+				}
+				if d >= 1 && d <= daysIn {
+					days = append(days, d)
+				}
+			}
+		case len(r.ByDay) > 0:
+			for _, byDay := range r.ByDay {
+				matches := []int{}
+				for d := 1; d <= daysIn; d++ {
+					if at(y, m, d).Weekday() == byDay.Weekday {
+						matches = append(matches, d)
+					}
+				}
+				switch {
+				case byDay.N == 0:
+					days = append(days, matches...)
+				case byDay.N > 0 && byDay.N <= len(matches):
+					days = append(days, matches[byDay.N-1])
+				case byDay.N < 0 && -byDay.N <= len(matches):
+					days = append(days, matches[len(matches)+byDay.N])
+				}
+			}
+		case day <= daysIn:
+			days = append(days, day)
+		}
+		slices.Sort(days)
+		days = slices.Compact(days)
+		times := make([]time.Time, len(days))
+		for i, d := range days {
+			times[i] = at(y, m, d)
+		}
+		return times
+
+	case RRULE_YEARLY:
+		// 29 February only comes back in leap years
+		if t := at(year+step, month, day); t.Month() == month {
+			return []time.Time{t}
+		}
+	}
+	return nil
+}
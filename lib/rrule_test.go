@@ -0,0 +1,215 @@
+package lib
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func rruleTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	v, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func rruleTimes(t *testing.T, s ...string) []time.Time {
+	t.Helper()
+	times := []time.Time{}
+	for _, v := range s {
+		times = append(times, rruleTime(t, v))
+	}
+	return times
+}
+
+func TestRRuleOccurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		dtstart  string
+		duration time.Duration
+		from, to string
+		limit    int
+		want     []string
+	}{
+		{
+			"weekly far from the start",
+			"FREQ=WEEKLY;BYDAY=TU", "2026-01-06 17:00", time.Hour,
+			"2026-03-01 00:00", "2026-03-15 00:00", 0,
+			[]string{"2026-03-03 17:00", "2026-03-10 17:00"},
+		},
+		{
+			"daily every third day from the start",
+			"FREQ=DAILY;INTERVAL=3", "2026-01-01 06:00", 0,
+			"2026-01-01 00:00", "2026-01-12 00:00", 0,
+			[]string{"2026-01-01 06:00", "2026-01-04 06:00", "2026-01-07 06:00", "2026-01-10 06:00"},
+		},
+		{
+			"an occurrence still running at from",
+			"FREQ=DAILY", "2026-01-01 23:00", 2 * time.Hour,
+			"2026-01-10 00:00", "2026-01-11 00:00", 0,
+			[]string{"2026-01-09 23:00", "2026-01-10 23:00"},
+		},
+		{
+			"limit",
+			"FREQ=WEEKLY;BYDAY=MO,WE,FR", "2026-01-05 07:00", 0,
+			"2026-01-01 00:00", "2027-01-01 00:00", 4,
+			[]string{"2026-01-05 07:00", "2026-01-07 07:00", "2026-01-09 07:00", "2026-01-12 07:00"},
+		},
+		{
+			"COUNT counts from dtstart",
+			"FREQ=DAILY;COUNT=3", "2026-01-01 08:00", 0,
+			"2026-01-02 00:00", "2026-01-31 00:00", 0,
+			[]string{"2026-01-02 08:00", "2026-01-03 08:00"},
+		},
+		{
+			"COUNT spent before from",
+			"FREQ=DAILY;COUNT=3", "2026-01-01 08:00", 0,
+			"2026-01-05 00:00", "2026-01-31 00:00", 0,
+			[]string{},
+		},
+		{
+			"UNTIL includes its own time",
+			"FREQ=DAILY;UNTIL=20260103T080000Z", "2026-01-01 08:00", 0,
+			"2026-01-01 00:00", "2026-01-31 00:00", 0,
+			[]string{"2026-01-01 08:00", "2026-01-02 08:00", "2026-01-03 08:00"},
+		},
+		{
+			"UNTIL before from",
+			"FREQ=WEEKLY;UNTIL=20260201T000000Z", "2026-01-04 08:00", 0,
+			"2026-03-01 00:00", "2026-04-01 00:00", 0,
+			[]string{},
+		},
+		{
+			"first Sunday of the month",
+			"FREQ=MONTHLY;BYDAY=1SU", "2026-01-04 09:00", 0,
+			"2026-01-01 00:00", "2026-04-01 00:00", 0,
+			[]string{"2026-01-04 09:00", "2026-02-01 09:00", "2026-03-01 09:00"},
+		},
+		{
+			"last Sunday of the month",
+			"FREQ=MONTHLY;BYDAY=-1SU", "2026-01-25 09:00", 0,
+			"2026-01-01 00:00", "2026-04-01 00:00", 0,
+			[]string{"2026-01-25 09:00", "2026-02-22 09:00", "2026-03-29 09:00"},
+		},
+		{
+			"fifth Sunday only in months that have one",
+			"FREQ=MONTHLY;BYDAY=5SU", "2026-01-01 09:00", 0,
+			"2026-01-01 00:00", "2026-07-01 00:00", 0,
+			[]string{"2026-03-29 09:00", "2026-05-31 09:00"},
+		},
+		{
+			"last day of the month",
+			"FREQ=MONTHLY;BYMONTHDAY=-1", "2028-01-31 18:00", 0,
+			"2028-01-01 00:00", "2028-04-01 00:00", 0,
+			[]string{"2028-01-31 18:00", "2028-02-29 18:00", "2028-03-31 18:00"},
+		},
+		{
+			"first and second to last day of the month",
+			"FREQ=MONTHLY;BYMONTHDAY=1,-2", "2026-02-01 18:00", 0,
+			"2026-02-01 00:00", "2026-04-01 00:00", 0,
+			[]string{"2026-02-01 18:00", "2026-02-27 18:00", "2026-03-01 18:00", "2026-03-30 18:00"},
+		},
+		{
+			"monthly on the 31st skips shorter months",
+			"FREQ=MONTHLY", "2026-01-31 10:00", 0,
+			"2026-01-01 00:00", "2026-06-01 00:00", 0,
+			[]string{"2026-01-31 10:00", "2026-03-31 10:00", "2026-05-31 10:00"},
+		},
+		{
+			"29 February comes back in leap years",
+			"FREQ=YEARLY", "2024-02-29 10:00", 0,
+			"2024-01-01 00:00", "2033-01-01 00:00", 0,
+			[]string{"2024-02-29 10:00", "2028-02-29 10:00", "2032-02-29 10:00"},
+		},
+		{
+			"29 February far from the start",
+			"FREQ=YEARLY", "2024-02-29 10:00", 0,
+			"2030-01-01 00:00", "2037-01-01 00:00", 0,
+			[]string{"2032-02-29 10:00", "2036-02-29 10:00"},
+		},
+		{
+			"29 February with COUNT",
+			"FREQ=YEARLY;COUNT=2", "2024-02-29 10:00", 0,
+			"2024-01-01 00:00", "2040-01-01 00:00", 0,
+			[]string{"2024-02-29 10:00", "2028-02-29 10:00"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := ParseRRule(test.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := r.Occurrences(rruleTime(t, test.dtstart), test.duration,
+				rruleTime(t, test.from), rruleTime(t, test.to), test.limit)
+			if want := rruleTimes(t, test.want...); !slices.EqualFunc(got, want, time.Time.Equal) {
+				t.Errorf("%s: got %v, want %v", test.rule, got, want)
+			}
+		})
+	}
+}
+
+func TestRRulePeriodsBefore(t *testing.T) {
+	tests := []struct {
+		rule    string
+		dtstart string
+		at      string
+		want    int
+	}{
+		{"FREQ=DAILY", "2026-01-01 08:00", "2025-12-01 00:00", 0},
+		{"FREQ=DAILY", "2026-01-01 08:00", "2026-01-01 08:00", 0},
+		{"FREQ=DAILY", "2026-01-01 08:00", "2026-01-11 08:00", 9},
+		{"FREQ=DAILY;INTERVAL=3", "2026-01-01 08:00", "2026-01-11 08:00", 2},
+		{"FREQ=WEEKLY", "2026-01-05 08:00", "2026-02-09 08:00", 4},
+		{"FREQ=WEEKLY;INTERVAL=2", "2026-01-05 08:00", "2026-02-09 08:00", 1},
+		{"FREQ=MONTHLY", "2026-01-31 08:00", "2026-04-01 00:00", 2},
+		{"FREQ=MONTHLY;INTERVAL=2", "2026-01-31 08:00", "2027-01-01 00:00", 5},
+		{"FREQ=YEARLY", "2024-02-29 10:00", "2030-01-01 00:00", 5},
+		{"FREQ=YEARLY;INTERVAL=4", "2024-02-29 10:00", "2030-01-01 00:00", 0},
+	}
+	for _, test := range tests {
+		r, err := ParseRRule(test.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.periodsBefore(rruleTime(t, test.dtstart), rruleTime(t, test.at)); got != test.want {
+			t.Errorf("%s from %s to %s: %d periods, want %d", test.rule, test.dtstart, test.at, got, test.want)
+		}
+	}
+}
+
+// TestRRuleSkipAhead checks that skipping whole periods never loses an
+// occurrence: a window far from dtstart sees what expanding from dtstart sees.
+func TestRRuleSkipAhead(t *testing.T) {
+	rules := []string{
+		"FREQ=DAILY;INTERVAL=5",
+		"FREQ=WEEKLY;BYDAY=SU,WE",
+		"FREQ=WEEKLY;INTERVAL=3;BYDAY=MO",
+		"FREQ=MONTHLY;BYDAY=-1FR",
+		"FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=31",
+		"FREQ=YEARLY",
+	}
+	dtstart := rruleTime(t, "2024-02-29 19:00")
+	from, to := rruleTime(t, "2031-06-15 00:00"), rruleTime(t, "2032-06-15 00:00")
+	for _, rule := range rules {
+		r, err := ParseRRule(rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, duration := range []time.Duration{0, 36 * time.Hour} {
+			all := r.Occurrences(dtstart, duration, dtstart, to, 0)
+			want := []time.Time{}
+			for _, occurrence := range all {
+				if occurrence.Add(duration).After(from) || (duration == 0 && !occurrence.Before(from)) {
+					want = append(want, occurrence)
+				}
+			}
+			if got := r.Occurrences(dtstart, duration, from, to, 0); !slices.EqualFunc(got, want, time.Time.Equal) {
+				t.Errorf("%s lasting %v: got %v, want %v", rule, duration, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package lib
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/conf"
+	"github.com/gin-gonic/gin"
+)
+
+// Crawler routes are mounted under this prefix and get an HTML page carrying
+// the meta tags (browsers are sent on to the page itself), the /api/share
+// routes return the same metadata as JSON.
+const SHARE_HTML_PREFIX = "/share/"
+
+const SHARE_SITE_ZAITUN = "Zaitun"
+const SHARE_SITE_PAROKI = "Paroki Kosambi Baru"
+
+// ShareMeta is the Open Graph / Twitter card description of a shared page.
+// Url and Image may be site-relative, WriteShareMeta makes them absolute
+// against SiteOrigin.
+type ShareMeta struct {
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Image       string      `json:"image"`
+	Url         string      `json:"url"`
+	Type        string      `json:"type"`
+	SiteName    string      `json:"siteName"`
+	Author      string      `json:"author,omitempty"`
+	PublishedAt *time.Time  `json:"publishedAt,omitempty"`
+	TwitterCard string      `json:"twitterCard"`
+	Tags        []*ShareTag `json:"tags"`
+}
+
+// ShareTag is one <meta> element. Open Graph uses the property attribute,
+// Twitter cards the name attribute.
+type ShareTag struct {
+	Attr    string `json:"attr"`
+	Key     string `json:"key"`
+	Content string `json:"content"`
+}
+
+func (m *ShareMeta) addTag(key string, content string) {
+	if content == "" {
+		return
+	}
+	attr := "property"
+	if strings.HasPrefix(key, "twitter:") {
+		attr = "name"
+	}
+	m.Tags = append(m.Tags, &ShareTag{attr, key, content})
+}
+
+var shareTemplate = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta name="description" content="{{.Description}}">
+<link rel="canonical" href="{{.Url}}">
+{{range .Tags}}{{if eq .Attr "name"}}<meta name="{{.Key}}" content="{{.Content}}">
+{{else}}<meta property="{{.Key}}" content="{{.Content}}">
+{{end}}{{end}}</head>
+<body>
+<p><a href="{{.Url}}">{{.Title}}</a></p>
+<script>location.replace({{.Url}})</script>
+</body>
+</html>
+`))
+
+// SiteOrigin is the public origin of the site, e.g.
+// "https://parokikosambibaru.org", read from SITE_URL in the environment.
+// Links stay site-relative while it is not set. It is never derived from the
+// request: share pages and feeds are cached publicly, so a forged
+// X-Forwarded-Host would reach every reader.
+var SiteOrigin = sync.OnceValue(func() string {
+	v := strings.TrimSpace(os.Getenv("SITE_URL"))
+	if v == "" {
+		log.Println("SITE_URL is not set, links to the site stay relative")
+		return ""
+	}
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Printf("invalid SITE_URL %q, links to the site stay relative\n", v)
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + strings.TrimRight(u.Path, "/")
+})
+
+// absoluteImageURL resolves a stored image location: bucket paths point to
+// the public bucket, /static/ files to the site itself.
+func absoluteImageURL(origin string, loc string) string {
+	switch {
+	case loc == "":
+		return ""
+	case strings.HasPrefix(loc, "http://"), strings.HasPrefix(loc, "https://"):
+		return loc
+	case strings.HasPrefix(loc, "/static/"):
+		return origin + loc
+	default:
+		return "https://storage.googleapis.com/" + conf.GCLOUD_BUCKET + "/" + strings.TrimPrefix(loc, "/")
+	}
+}
+
+// WriteShareMeta completes meta and answers with the crawler HTML page when
+// the route lives under SHARE_HTML_PREFIX, as JSON otherwise.
+func (r *Responses) WriteShareMeta(ctx *gin.Context, meta *ShareMeta) {
+	origin := SiteOrigin()
+	meta.Url = origin + meta.Url
+	meta.Image = absoluteImageURL(origin, meta.Image)
+	meta.Description = Summarize(meta.Description, EXCERPT_LENGTH)
+	meta.TwitterCard = "summary"
+	if meta.Image != "" {
+		meta.TwitterCard = "summary_large_image"
+	}
+
+	meta.Tags = nil
+	meta.addTag("og:title", meta.Title)
+	meta.addTag("og:description", meta.Description)
+	meta.addTag("og:image", meta.Image)
+	meta.addTag("og:url", meta.Url)
+	meta.addTag("og:type", meta.Type)
+	meta.addTag("og:site_name", meta.SiteName)
+	meta.addTag("og:locale", "id_ID")
+	if meta.Type == "article" {
+		meta.addTag("article:author", meta.Author)
+		if meta.PublishedAt != nil {
+			meta.addTag("article:published_time", meta.PublishedAt.Format(time.RFC3339))
+		}
+	}
+	meta.addTag("twitter:card", meta.TwitterCard)
+	meta.addTag("twitter:title", meta.Title)
+	meta.addTag("twitter:description", meta.Description)
+	meta.addTag("twitter:image", meta.Image)
+
+	if !strings.HasPrefix(ctx.FullPath(), SHARE_HTML_PREFIX) {
+		r.SuccessWithStatusOKJSON(ctx, nil, meta)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := shareTemplate.Execute(&buf, meta); err != nil {
+		r.AbortWithStatusJSON(ctx, err, "failed to render share page", err.Error(), http.StatusInternalServerError, nil)
+		return
+	}
+	// crawlers refetch rarely, let shared caches keep the page for a while
+	ctx.Header("Cache-Control", "public, max-age=600")
+	r.SuccessWithData(ctx, "text/html; charset=utf-8", buf.Bytes(), meta.Url)
+}
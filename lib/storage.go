@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"log"
+	"net/url"
 
 	"cloud.google.com/go/storage"
 	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/conf"
@@ -35,3 +36,9 @@ func GetCloudStorage(ctx context.Context) (*CloudStorage, error) {
 		StorageBucket:      bkt,
 	}, nil
 }
+
+// ObjectLocation is how an object is referred to in responses, the escaped
+// path within the bucket, e.g. "/berita/1/attachments/2/formulir%20baptis.pdf".
+func ObjectLocation(objPath string) string {
+	return "/" + (&url.URL{Path: objPath}).EscapedPath()
+}
@@ -0,0 +1,26 @@
+package lib
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const TRASH_RETENTION_DAYS = 30
+
+// TrashRetention is how long deleted berita and articles stay in the trash
+// before they are purged, TRASH_RETENTION_DAYS from the environment or 30
+// days. Zero turns the automatic purge off.
+func TrashRetention() time.Duration {
+	days := TRASH_RETENTION_DAYS
+	if v, ok := os.LookupEnv("TRASH_RETENTION_DAYS"); ok {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			log.Printf("invalid TRASH_RETENTION_DAYS %q, keeping %d days\n", v, days)
+		} else {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/conf"
 	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/controllers"
@@ -34,11 +35,19 @@ func main() {
 		gin.SetMode(gin.DebugMode)
 	}
 	app := gin.Default()
+	// ctx.ClientIP() keys the rate limits and bans of public submissions, so it
+	// must not come from headers a client can forge
+	if err := app.SetTrustedProxies(lib.TrustedProxies()); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	app.TrustedPlatform = lib.ClientIPHeader()
 	app.Use(corsMiddleware())
 	db := lib.GetDB()
 	defer db.Close()
 
 	c := controllers.NewController(db)
+	go c.Editor.RunTrashRetention(lib.TrashRetention())
+	go c.Editor.BackfillReadingStats()
 
 	app.GET("/ping", c.Ping)
 
@@ -49,7 +58,22 @@ func main() {
 			---
 	*/
 	app.GET("/api/berita", c.Profile.GetAllBerita)
+	app.GET("/api/berita/calendar", c.Profile.GetBeritaCalendar)
+	app.GET("/api/berita/sections", c.Profile.GetBeritaSections)
+	app.GET("/api/berita/overview", c.Profile.GetOverviewBerita)
 	app.GET("/api/berita/:beritaId", c.Profile.GetBeritaById)
+	app.GET("/api/berita/:beritaId/event.ics", c.Profile.GetBeritaICal)
+	app.GET("/api/kegiatan", c.Profile.GetTopKegiatan)
+	app.GET("/api/kegiatan/calendar.ics", c.Profile.GetKegiatanICal)
+	app.GET("/api/kegiatan/sections/:section/calendar.ics", c.Profile.GetSectionICal)
+	app.GET("/api/warta", c.Profile.GetBulletins)
+	app.GET("/api/warta/:number", c.Profile.GetBulletinByNumber)
+	app.GET("/api/warta/:number/warta.html", c.Profile.GetBulletinHTML)
+	app.GET("/api/warta/:number/warta.pdf", c.Profile.GetBulletinPDF)
+	app.GET("/api/misa", c.Profile.GetMassSchedule)
+	app.GET("/api/misa/locations", c.Profile.GetMassLocations)
+	app.GET("/api/misa/ujud", c.Profile.GetMassIntentions)
+	app.POST("/api/misa/ujud", c.Profile.PostMassIntention)
 
 	app.GET("/api/umkm/toko", c.UMKM.GetToko)
 	app.GET("/api/umkm/products", c.UMKM.GetProduct)
@@ -58,6 +82,20 @@ func main() {
 	app.GET("/api/umkm/toko/:tokoId", c.UMKM.GetTokoById)
 	app.GET("/api/umkm/suggest", c.UMKM.GetSuggest)
 
+	/*
+		*
+		*
+			SHARE METADATA ROUTES
+			---
+	*/
+	// /api/share answers JSON, /share renders the same tags as HTML for crawlers
+	for _, prefix := range []string{"/api/share", "/share"} {
+		app.GET(prefix+"/articles/:year/:editionId/:slug", c.Zaitun.GetArticleShareMeta)
+		app.GET(prefix+"/editions/:editionId", c.Zaitun.GetEditionShareMeta)
+		app.GET(prefix+"/berita/:beritaId", c.Profile.GetBeritaShareMeta)
+		app.GET(prefix+"/umkm/products/:productId", c.UMKM.GetProductShareMeta)
+	}
+
 	/*
 		*
 		*
@@ -73,11 +111,22 @@ func main() {
 			---
 	*/
 	app.GET("/api/editions", c.Zaitun.GetAllEditions)
+	app.GET("/api/archive", c.Zaitun.GetArchive)
+	app.GET("/api/archive/years", c.Zaitun.GetArchiveYears)
 	app.GET("/api/editions/:editionId", c.Zaitun.GetEditionById)
+	app.GET("/api/editions/:editionId/toc", c.Zaitun.GetEditionToc)
+	app.GET("/api/editions/:editionId/epub", c.Zaitun.GetEditionEpub)
 
 	app.GET("/api/articles", c.Zaitun.GetArticlesByCategory)
 	app.GET("/api/articles/:year/:editionId/:slug", c.Zaitun.GetArticleBySlug)
 	app.GET("/api/articles/top", c.Zaitun.GetTopArticles)
+	app.GET("/api/articles/:year/:editionId/:slug/comments", c.Zaitun.GetArticleComments)
+	app.POST("/api/articles/:year/:editionId/:slug/comments", c.Zaitun.PostArticleComment)
+
+	app.GET("/api/writers/:writerId", c.Zaitun.GetWriterById)
+
+	app.POST("/api/ads/:campaignId/impression", c.Zaitun.RecordAdImpression)
+	app.GET("/api/ads/:campaignId/click", c.Zaitun.RecordAdClick)
 
 	/*
 		*
@@ -92,6 +141,11 @@ func main() {
 
 	app.PUT("/api/core/editions/:editionId/save-info", c.Editor.EditEditionInfo)
 	app.PUT("/api/core/editions/:editionId/publish", c.Editor.PublishEdition)
+	app.POST("/api/core/editions/:editionId/preview", c.Editor.CreateEditionPreview)
+	app.POST("/api/core/editions/:editionId/duplicate", c.Editor.DuplicateEdition)
+	app.PUT("/api/core/editions/:editionId/unpublish", c.Editor.UnpublishEdition)
+	app.PUT("/api/core/editions/:editionId/archive", c.Editor.ArchiveEdition)
+	app.PUT("/api/core/editions/:editionId/activate", c.Editor.SetActiveEdition)
 
 	app.POST("/api/core/editions/:editionId/cover", c.Image.SaveEditionCover)
 	app.PUT("/api/core/editions/:editionId/cover/thumbnail", c.Image.UpdateEditionThumbnail)
@@ -100,19 +154,10 @@ func main() {
 	app.POST("/api/core/article", c.Editor.CreateArticle)
 	app.GET("/api/core/articles/:articleId", c.Editor.GetArticleById)
 	app.GET("/api/core/articles/:articleId/info", c.Editor.GetArticleInfo)
-
-	app.PUT("/api/core/articles/:articleId/save-info", c.Editor.SaveTWC)
-	app.PUT("/api/core/articles/:articleId/save-draft", c.Editor.SaveDraft)
-	app.PUT("/api/core/articles/:articleId/publish", c.Editor.PublishArticle)
-	app.PUT("/api/core/articles/:articleId/archive", c.Editor.ArchiveArticle)
-	app.DELETE("/api/core/articles/:articleId", c.Editor.DeleteArticlePermanent)
+	app.GET("/api/core/articles/:articleId/contributors", c.Editor.GetArticleContributors)
 
 	app.GET("/api/core/articles/:articleId/cover", c.Image.GetArticleCoverImg)
 	app.GET("/api/core/articles/:articleId/contents", c.Editor.GetArticleContent)
-	app.POST("/api/core/articles/:articleId/cover", c.Image.SaveArticleCover)
-	app.POST("/api/core/articles/:articleId/images", c.Image.SaveArticleImageContents)
-	app.PUT("/api/core/articles/:articleId/cover/rename", c.Image.RenameArticleHeadline)
-	app.PUT("/api/core/articles/:articleId/cover/thumbnail", c.Image.UpdateArticleThumbnail)
 
 	app.GET("/api/core/drafts", c.Editor.GetDrafts)
 
@@ -121,14 +166,84 @@ func main() {
 	app.GET("/api/core/categories/by-article/:articleId", c.Editor.GetCategoriesByArticle)
 	app.POST("/api/core/category", c.Editor.CreateCategory)
 	app.PUT("/api/core/category", c.Editor.UpdateCategoryOrder)
+	app.PUT("/api/core/editions/:editionId/categories/order", c.Editor.ReorderCategories)
+	app.POST("/api/core/editions/:editionId/categories/merge", c.Editor.MergeCategories)
+	app.PUT("/api/core/editions/:editionId/categories/:categoryId", c.Editor.RenameCategory)
+	app.DELETE("/api/core/editions/:editionId/categories/:categoryId", c.Editor.DeleteCategory)
 
 	app.GET("/api/core/writers", c.Editor.GetAllWriters)
 	app.POST("/api/core/writer", c.Editor.CreateWriter)
+	app.GET("/api/core/writers/:writerId", c.Editor.GetWriterById)
+	app.PUT("/api/core/writers/:writerId", c.Editor.UpdateWriter)
+	app.DELETE("/api/core/writers/:writerId", c.Editor.DeleteWriter)
+	app.POST("/api/core/writers/:writerId/merge", c.Editor.MergeWriters)
+	app.POST("/api/core/writers/:writerId/photo", c.Image.SaveWriterPhoto)
+	app.PUT("/api/core/writers/:writerId/photo", c.Image.UpdateWriterPhoto)
+
+	app.GET("/api/core/ads/advertisers", c.Editor.GetAllAdvertisers)
+	app.POST("/api/core/ads/advertisers", c.Editor.CreateAdvertiser)
+	app.PUT("/api/core/ads/advertisers/:advertiserId", c.Editor.UpdateAdvertiser)
+	app.DELETE("/api/core/ads/advertisers/:advertiserId", c.Editor.DeleteAdvertiser)
+
+	app.GET("/api/core/ads/creatives", c.Editor.GetAllAdCreatives)
+	app.POST("/api/core/ads/creatives", c.Editor.CreateAdCreative)
+	app.PUT("/api/core/ads/creatives/:creativeId", c.Editor.UpdateAdCreative)
+	app.DELETE("/api/core/ads/creatives/:creativeId", c.Editor.DeleteAdCreative)
+	app.POST("/api/core/ads/creatives/:creativeId/image", c.Image.SaveAdCreativeImage)
+	app.PUT("/api/core/ads/creatives/:creativeId/image", c.Image.UpdateAdCreativeImage)
+
+	app.GET("/api/core/ads/campaigns", c.Editor.GetAllAdCampaigns)
+	app.POST("/api/core/ads/campaigns", c.Editor.CreateAdCampaign)
+	app.PUT("/api/core/ads/campaigns/:campaignId", c.Editor.UpdateAdCampaign)
+	app.DELETE("/api/core/ads/campaigns/:campaignId", c.Editor.DeleteAdCampaign)
+	app.GET("/api/core/ads/campaigns/:campaignId/stats", c.Editor.GetAdCampaignStats)
 
 	app.GET("/api/core/beritas", c.Editor.GetAllBerita)
-	app.PUT("/api/core/berita/:id/cover/thumbnail", c.Editor.UpdateBeritaThumbnail)
-	app.PUT("api/core/berita/:id", c.Editor.UpdateBeritaPublishing)
-	app.DELETE("/api/core/berita/:id", c.Editor.DeleteBeritaPermanent)
+	app.PUT("/api/core/berita/:id/content", c.Editor.UpdateBerita)
+	app.GET("/api/core/berita/:id/revisions", c.Editor.GetBeritaRevisions)
+	app.POST("/api/core/berita/:id/revisions/:revision/restore", c.Editor.RestoreBeritaRevision)
+	app.PUT("/api/core/berita/:id/pin", c.Editor.PinBerita)
+	app.DELETE("/api/core/berita/:id/pin", c.Editor.UnpinBerita)
+	app.PUT("/api/core/berita/:id/priority", c.Editor.SetBeritaPriority)
+	app.GET("/api/core/berita/:id/attachments", c.Editor.GetBeritaAttachments)
+	app.POST("/api/core/berita/:id/attachments", c.Editor.CreateBeritaAttachment)
+	app.PUT("/api/core/berita/:id/attachments/order", c.Editor.ReorderBeritaAttachments)
+	app.PUT("/api/core/berita/:id/attachments/:attachmentId", c.Editor.UpdateBeritaAttachment)
+	app.PUT("/api/core/berita/:id/attachments/:attachmentId/confirm", c.Editor.ConfirmBeritaAttachment)
+	app.DELETE("/api/core/berita/:id/attachments/:attachmentId", c.Editor.DeleteBeritaAttachment)
+
+	app.GET("/api/core/berita-sections", c.Editor.GetBeritaSections)
+	app.POST("/api/core/berita-sections", c.Editor.CreateBeritaSection)
+	app.PUT("/api/core/berita-sections/order", c.Editor.ReorderBeritaSections)
+	app.PUT("/api/core/berita-sections/:sectionId", c.Editor.UpdateBeritaSection)
+	app.DELETE("/api/core/berita-sections/:sectionId", c.Editor.DeleteBeritaSection)
+
+	app.GET("/api/core/bulletins", c.Editor.GetBulletins)
+	app.POST("/api/core/bulletins", c.Editor.CreateBulletin)
+	app.GET("/api/core/bulletins/:bulletinId", c.Editor.GetBulletinById)
+	app.PUT("/api/core/bulletins/:bulletinId", c.Editor.UpdateBulletin)
+	app.DELETE("/api/core/bulletins/:bulletinId", c.Editor.DeleteBulletin)
+	app.GET("/api/core/bulletins/:bulletinId/warta.html", c.Editor.GetBulletinHTML)
+	app.GET("/api/core/bulletins/:bulletinId/warta.pdf", c.Editor.GetBulletinPDF)
+	app.PUT("/api/core/bulletins/:bulletinId/publish", c.Editor.PublishBulletin)
+	app.DELETE("/api/core/bulletins/:bulletinId/publish", c.Editor.UnpublishBulletin)
+	app.POST("/api/core/bulletins/:bulletinId/items", c.Editor.AddBulletinItem)
+	app.PUT("/api/core/bulletins/:bulletinId/items/order", c.Editor.ReorderBulletinItems)
+	app.PUT("/api/core/bulletins/:bulletinId/items/:itemId", c.Editor.UpdateBulletinItem)
+	app.DELETE("/api/core/bulletins/:bulletinId/items/:itemId", c.Editor.DeleteBulletinItem)
+
+	app.GET("/api/core/misa/locations", c.Editor.GetMassLocations)
+	app.POST("/api/core/misa/locations", c.Editor.CreateMassLocation)
+	app.PUT("/api/core/misa/locations/:locationId", c.Editor.UpdateMassLocation)
+	app.DELETE("/api/core/misa/locations/:locationId", c.Editor.DeleteMassLocation)
+	app.GET("/api/core/misa/schedules", c.Editor.GetMassSchedules)
+	app.POST("/api/core/misa/schedules", c.Editor.CreateMassSchedule)
+	app.PUT("/api/core/misa/schedules/:scheduleId", c.Editor.UpdateMassSchedule)
+	app.DELETE("/api/core/misa/schedules/:scheduleId", c.Editor.DeleteMassSchedule)
+	app.GET("/api/core/misa/exceptions", c.Editor.GetMassExceptions)
+	app.POST("/api/core/misa/exceptions", c.Editor.CreateMassException)
+	app.PUT("/api/core/misa/exceptions/:exceptionId", c.Editor.UpdateMassException)
+	app.DELETE("/api/core/misa/exceptions/:exceptionId", c.Editor.DeleteMassException)
 
 	app.POST("/api/core/auth/login", c.Auth.Login)
 
@@ -136,20 +251,48 @@ func main() {
 
 	protected.Use(auth.AuthMiddleware())
 	{
-		protected.PUT("/api/core/articles/:articleId/save-info", c.Editor.SaveTWC)
-		protected.PUT("/api/core/articles/:articleId/save-draft", c.Editor.SaveDraft)
-		protected.PUT("/api/core/articles/:articleId/publish", c.Editor.PublishArticle)
-		protected.PUT("/api/core/articles/:articleId/archive", c.Editor.ArchiveArticle)
-		protected.DELETE("/api/core/articles/:articleId", c.Editor.DeleteArticlePermanent)
-
-		protected.POST("/api/core/articles/:articleId/cover", c.Image.SaveArticleCover)
-		protected.POST("/api/core/articles/:articleId/images", c.Image.SaveArticleImageContents)
-		protected.PUT("/api/core/articles/:articleId/cover/rename", c.Image.RenameArticleHeadline)
-		protected.PUT("/api/core/articles/:articleId/cover/thumbnail", c.Image.UpdateArticleThumbnail)
-
-		protected.PUT("/api/core/berita/:id", c.Editor.UpdateBeritaPublishing)
-		protected.DELETE("/api/core/berita/:id", c.Editor.DeleteBeritaPermanent)
-		protected.PUT("/api/core/berita/:id/cover/thumbnail", c.Editor.UpdateBeritaThumbnail)
+		protected.DELETE("/editions/:editionId", c.Editor.DeleteEdition)
+
+		protected.PUT("/articles/:articleId/save-info", c.Editor.SaveTWC)
+		protected.PUT("/articles/:articleId/contributors", c.Editor.UpdateArticleContributors)
+		protected.PUT("/articles/:articleId/save-draft", c.Editor.SaveDraft)
+		protected.PUT("/articles/:articleId/publish", c.Editor.PublishArticle)
+		protected.PUT("/articles/:articleId/archive", c.Editor.ArchiveArticle)
+		protected.POST("/articles/:articleId/preview", c.Editor.CreateArticlePreview)
+		protected.DELETE("/articles/:articleId", c.Editor.DeleteArticle)
+
+		protected.POST("/articles/:articleId/cover", c.Image.SaveArticleCover)
+		protected.POST("/articles/:articleId/images", c.Image.SaveArticleImageContents)
+		protected.PUT("/articles/:articleId/cover/rename", c.Image.RenameArticleHeadline)
+		protected.PUT("/articles/:articleId/cover/thumbnail", c.Image.UpdateArticleThumbnail)
+
+		protected.PUT("/berita/:id", c.Editor.UpdateBeritaPublishing)
+		protected.DELETE("/berita/:id", c.Editor.DeleteBerita)
+		protected.PUT("/berita/:id/cover/thumbnail", c.Editor.UpdateBeritaThumbnail)
+
+		protected.GET("/misa/ujud", c.Editor.GetMassIntentions)
+		protected.GET("/misa/ujud/lector.html", c.Editor.GetLectorListHTML)
+		protected.GET("/misa/ujud/lector.pdf", c.Editor.GetLectorListPDF)
+		protected.PUT("/misa/ujud/:intentionId", c.Editor.UpdateMassIntention)
+		protected.DELETE("/misa/ujud/:intentionId", c.Editor.DeleteMassIntention)
+		protected.PUT("/misa/ujud/:intentionId/mass", c.Editor.AssignMassIntention)
+		protected.PUT("/misa/ujud/:intentionId/confirm", c.Editor.ConfirmMassIntention)
+		protected.PUT("/misa/ujud/:intentionId/reject", c.Editor.RejectMassIntention)
+
+		protected.GET("/comments", c.Editor.GetComments)
+		protected.PUT("/comments/:commentId/approve", c.Editor.ApproveComment)
+		protected.PUT("/comments/:commentId/reject", c.Editor.RejectComment)
+		protected.PUT("/comments/:commentId/ban", c.Editor.BanCommenter)
+		protected.GET("/comment-bans", c.Editor.GetCommentBans)
+		protected.DELETE("/comment-bans/:banId", c.Editor.DeleteCommentBan)
+
+		protected.GET("/trash/articles", c.Editor.GetTrashedArticles)
+		protected.PUT("/trash/articles/:articleId/restore", c.Editor.RestoreArticle)
+		protected.DELETE("/trash/articles/:articleId", c.Editor.PurgeArticle)
+		protected.GET("/trash/berita", c.Editor.GetTrashedBerita)
+		protected.PUT("/trash/berita/:id/restore", c.Editor.RestoreBerita)
+		protected.DELETE("/trash/berita/:id", c.Editor.PurgeBerita)
+		protected.POST("/trash/purge", c.Editor.PurgeTrash)
 	}
 
 	/*
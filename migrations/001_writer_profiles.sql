@@ -0,0 +1,9 @@
+-- Writers get a public profile: a short bio, a photo, their ministry and
+-- lingkungan, links to social media, and when the profile last changed.
+ALTER TABLE writers
+  ADD COLUMN bio TEXT NULL,
+  ADD COLUMN photo_img VARCHAR(255) NULL,
+  ADD COLUMN ministry VARCHAR(128) NULL,
+  ADD COLUMN lingkungan VARCHAR(128) NULL,
+  ADD COLUMN social_links JSON NULL,
+  ADD COLUMN updated_at DATETIME NULL;
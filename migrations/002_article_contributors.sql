@@ -0,0 +1,13 @@
+-- Articles can credit more writers than their author, each with a role such
+-- as photographer or translator, in a chosen order. articles.writer_id stays
+-- the primary author and is not repeated here.
+CREATE TABLE article_contributors (
+  article_id INT NOT NULL,
+  writer_id INT NOT NULL,
+  role VARCHAR(16) NOT NULL,
+  position INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (article_id, writer_id, role),
+  KEY idx_article_contributors_writer (writer_id),
+  CONSTRAINT fk_article_contributors_article FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE,
+  CONSTRAINT fk_article_contributors_writer FOREIGN KEY (writer_id) REFERENCES writers (id)
+);
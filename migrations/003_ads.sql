@@ -0,0 +1,53 @@
+-- Advertisers, the creatives they supply, the campaigns that run those
+-- creatives on articles or editions for a period, and daily counters of the
+-- impressions and clicks of every campaign.
+CREATE TABLE advertisers (
+  id INT NOT NULL AUTO_INCREMENT,
+  name VARCHAR(255) NOT NULL,
+  contact_name VARCHAR(255) NULL,
+  contact_phone VARCHAR(64) NULL,
+  contact_email VARCHAR(255) NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NULL,
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE ad_creatives (
+  id INT NOT NULL AUTO_INCREMENT,
+  advertiser_id INT NOT NULL,
+  title VARCHAR(255) NOT NULL,
+  img VARCHAR(512) NOT NULL DEFAULT '/static/placeholder.jpg',
+  link_url VARCHAR(1024) NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_ad_creatives_advertiser FOREIGN KEY (advertiser_id) REFERENCES advertisers (id)
+);
+
+-- a campaign with neither article_id nor edition_id runs on every article
+CREATE TABLE ad_campaigns (
+  id INT NOT NULL AUTO_INCREMENT,
+  creative_id INT NOT NULL,
+  placement VARCHAR(8) NOT NULL,
+  article_id INT NULL,
+  edition_id INT NULL,
+  start_date DATETIME NOT NULL,
+  end_date DATETIME NOT NULL,
+  priority INT NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NULL,
+  PRIMARY KEY (id),
+  KEY idx_ad_campaigns_active (start_date, end_date),
+  CONSTRAINT fk_ad_campaigns_creative FOREIGN KEY (creative_id) REFERENCES ad_creatives (id),
+  CONSTRAINT fk_ad_campaigns_article FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE,
+  CONSTRAINT fk_ad_campaigns_edition FOREIGN KEY (edition_id) REFERENCES editions (id) ON DELETE CASCADE
+);
+
+CREATE TABLE ad_stats (
+  campaign_id INT NOT NULL,
+  day DATE NOT NULL,
+  impressions INT NOT NULL DEFAULT 0,
+  clicks INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (campaign_id, day),
+  CONSTRAINT fk_ad_stats_campaign FOREIGN KEY (campaign_id) REFERENCES ad_campaigns (id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- Editions can be archived: taken off the public site while editors keep
+-- them. archived_at is when that happened.
+ALTER TABLE editions ADD COLUMN archived_at DATETIME NULL;
@@ -0,0 +1,8 @@
+-- Articles keep their word count, reading time in minutes and an excerpt,
+-- all computed from the contents when a draft is saved. The excerpt is shown
+-- when thumb_text is empty. It stays NULL on existing articles until the
+-- server fills their values in at startup.
+ALTER TABLE articles
+  ADD COLUMN word_count INT NOT NULL DEFAULT 0,
+  ADD COLUMN reading_time INT NOT NULL DEFAULT 0,
+  ADD COLUMN excerpt VARCHAR(512) NULL;
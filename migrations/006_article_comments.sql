@@ -0,0 +1,33 @@
+-- Reader comments on Zaitun articles. Every comment waits for a moderator
+-- before it is shown. ip_hash is an HMAC of the client address, never the
+-- address itself.
+CREATE TABLE article_comments (
+  id INT NOT NULL AUTO_INCREMENT,
+  article_id INT NOT NULL,
+  parent_id INT NULL,
+  author_name VARCHAR(64) NOT NULL,
+  author_email VARCHAR(255) NULL,
+  body TEXT NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'pending',
+  spam_score INT NOT NULL DEFAULT 0,
+  spam_reasons VARCHAR(255) NULL,
+  ip_hash CHAR(64) NOT NULL,
+  created_at DATETIME NOT NULL,
+  moderated_at DATETIME NULL,
+  PRIMARY KEY (id),
+  KEY idx_article_comments_article (article_id, status, created_at),
+  KEY idx_article_comments_status (status, created_at),
+  KEY idx_article_comments_ip (ip_hash, created_at),
+  CONSTRAINT fk_article_comments_article FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE,
+  CONSTRAINT fk_article_comments_parent FOREIGN KEY (parent_id) REFERENCES article_comments (id) ON DELETE CASCADE
+);
+
+CREATE TABLE comment_bans (
+  id INT NOT NULL AUTO_INCREMENT,
+  kind VARCHAR(8) NOT NULL,
+  value VARCHAR(255) NOT NULL,
+  reason VARCHAR(255) NULL,
+  created_at DATETIME NOT NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY uq_comment_bans (kind, value)
+);
@@ -0,0 +1,23 @@
+-- Announcements keep who changed them last, and every edit stores the
+-- version it replaces in announcement_revisions.
+ALTER TABLE announcements
+  ADD COLUMN updated_at DATETIME NULL,
+  ADD COLUMN updated_by INT NULL;
+
+CREATE TABLE announcement_revisions (
+  id INT NOT NULL AUTO_INCREMENT,
+  announcement_id INT NOT NULL,
+  revision INT NOT NULL,
+  title VARCHAR(255) NOT NULL,
+  section VARCHAR(64) NOT NULL,
+  descriptions TEXT NOT NULL,
+  details MEDIUMTEXT NOT NULL,
+  publish_start DATETIME NULL,
+  publish_end DATETIME NULL,
+  edited_at DATETIME NULL,
+  edited_by INT NULL,
+  created_at DATETIME NOT NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY uq_announcement_revisions (announcement_id, revision),
+  CONSTRAINT fk_announcement_revisions_announcement FOREIGN KEY (announcement_id) REFERENCES announcements (id) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+-- Deleting berita and articles moves them to the trash. Rows with deleted_at
+-- set are hidden everywhere but the trash listings, and purged for good once
+-- older than the retention period.
+ALTER TABLE articles
+  ADD COLUMN deleted_at DATETIME NULL,
+  ADD COLUMN deleted_by INT NULL,
+  ADD KEY idx_articles_deleted (deleted_at);
+
+ALTER TABLE announcements
+  ADD COLUMN deleted_by INT NULL;
@@ -0,0 +1,15 @@
+-- Announcements can describe an event, held once or repeatedly following an
+-- RFC 5545 recurrence rule, separate from their publish window. Revisions
+-- keep the event as well.
+ALTER TABLE announcements
+  ADD COLUMN event_start DATETIME NULL,
+  ADD COLUMN event_end DATETIME NULL,
+  ADD COLUMN location VARCHAR(255) NULL,
+  ADD COLUMN rrule VARCHAR(255) NULL,
+  ADD KEY idx_announcements_event (event_start);
+
+ALTER TABLE announcement_revisions
+  ADD COLUMN event_start DATETIME NULL,
+  ADD COLUMN event_end DATETIME NULL,
+  ADD COLUMN location VARCHAR(255) NULL,
+  ADD COLUMN rrule VARCHAR(255) NULL;
@@ -0,0 +1,38 @@
+-- Berita sections become a managed list. Every section typed so far becomes
+-- a row, slugged the same way the editor does it. announcements.section stays
+-- as a copy of the label for existing readers and revisions, and is rewritten
+-- whenever a section is renamed.
+CREATE TABLE announcement_sections (
+  id INT NOT NULL AUTO_INCREMENT,
+  label VARCHAR(64) NOT NULL,
+  slug VARCHAR(64) NOT NULL,
+  color CHAR(7) NULL,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY uq_announcement_sections_slug (slug)
+);
+
+INSERT INTO announcement_sections (label, slug, sort_order, created_at)
+SELECT MIN(TRIM(section)),
+  TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(TRIM(section)), '[^a-z0-9]+', '-')) AS slug,
+  0, UTC_TIMESTAMP()
+FROM announcements
+WHERE TRIM(section) <> ''
+GROUP BY slug;
+
+UPDATE announcement_sections s
+JOIN (SELECT id, ROW_NUMBER() OVER (ORDER BY label) - 1 AS position FROM announcement_sections) o ON o.id = s.id
+SET s.sort_order = o.position;
+
+ALTER TABLE announcements
+  ADD COLUMN section_id INT NULL,
+  ADD CONSTRAINT fk_announcements_section FOREIGN KEY (section_id) REFERENCES announcement_sections (id);
+
+UPDATE announcements a
+JOIN announcement_sections s ON s.slug = TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(TRIM(a.section)), '[^a-z0-9]+', '-'))
+SET a.section_id = s.id, a.section = s.label;
+
+ALTER TABLE announcement_revisions
+  ADD COLUMN section_id INT NULL;
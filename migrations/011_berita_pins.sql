@@ -0,0 +1,11 @@
+-- Berita can be pinned to the top of the homepage overview, until a given
+-- time or indefinitely, and ranked with a priority. Sections may cap how many
+-- berita they show there.
+ALTER TABLE announcements
+  ADD COLUMN priority INT NOT NULL DEFAULT 0,
+  ADD COLUMN pinned_at DATETIME NULL,
+  ADD COLUMN pinned_until DATETIME NULL,
+  ADD COLUMN pinned_by INT NULL;
+
+ALTER TABLE announcement_sections
+  ADD COLUMN overview_limit INT NULL;
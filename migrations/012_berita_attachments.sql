@@ -0,0 +1,20 @@
+-- Files attached to a berita, such as PDF forms and photos, shown in the
+-- order the editor chose. A row is created when the upload URL is handed out
+-- and only becomes public once the upload is confirmed (uploaded_at).
+CREATE TABLE announcement_attachments (
+  id INT NOT NULL AUTO_INCREMENT,
+  announcement_id INT NOT NULL,
+  object_path VARCHAR(512) NOT NULL,
+  file_name VARCHAR(255) NOT NULL,
+  content_type VARCHAR(64) NOT NULL,
+  size_bytes BIGINT NULL,
+  caption VARCHAR(500) NULL,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL,
+  created_by INT NULL,
+  uploaded_at DATETIME NULL,
+  PRIMARY KEY (id),
+  KEY idx_announcement_attachments_announcement (announcement_id, sort_order),
+  CONSTRAINT fk_announcement_attachments_announcement FOREIGN KEY (announcement_id)
+    REFERENCES announcements (id) ON DELETE CASCADE
+);
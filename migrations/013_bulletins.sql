@@ -0,0 +1,41 @@
+-- The weekly warta paroki. A bulletin copies the berita active in its week
+-- (Sunday to Saturday) into bulletin_items, which editors then reorder and
+-- annotate. Numbers are handed out on first publication so the archive has no
+-- gaps from discarded drafts.
+CREATE TABLE bulletins (
+  id INT NOT NULL AUTO_INCREMENT,
+  number INT NULL,
+  title VARCHAR(255) NOT NULL,
+  week_start DATE NOT NULL,
+  week_end DATE NOT NULL,
+  intro TEXT NULL,
+  published_at DATETIME NULL,
+  published_by INT NULL,
+  created_at DATETIME NOT NULL,
+  created_by INT NULL,
+  updated_at DATETIME NULL,
+  updated_by INT NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY uq_bulletins_number (number),
+  UNIQUE KEY uq_bulletins_week_start (week_start)
+);
+
+CREATE TABLE bulletin_items (
+  id INT NOT NULL AUTO_INCREMENT,
+  bulletin_id INT NOT NULL,
+  announcement_id INT NULL,
+  section VARCHAR(64) NOT NULL DEFAULT '',
+  title VARCHAR(255) NOT NULL,
+  descriptions TEXT NOT NULL,
+  event_start DATETIME NULL,
+  event_end DATETIME NULL,
+  location VARCHAR(255) NULL,
+  note TEXT NULL,
+  sort_order INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (id),
+  KEY idx_bulletin_items_bulletin (bulletin_id, sort_order),
+  CONSTRAINT fk_bulletin_items_bulletin FOREIGN KEY (bulletin_id)
+    REFERENCES bulletins (id) ON DELETE CASCADE,
+  CONSTRAINT fk_bulletin_items_announcement FOREIGN KEY (announcement_id)
+    REFERENCES announcements (id) ON DELETE SET NULL
+);
@@ -0,0 +1,57 @@
+-- Jadwal misa. Every church and chapel has a regular weekly schedule,
+-- optionally limited to a period (valid_from, valid_until). Exceptions apply
+-- to a single date: they cancel regular Masses, change one (time, language,
+-- place) or add a special Mass, and may name the feast that causes them.
+CREATE TABLE mass_locations (
+  id INT NOT NULL AUTO_INCREMENT,
+  name VARCHAR(128) NOT NULL,
+  slug VARCHAR(64) NOT NULL,
+  address VARCHAR(255) NULL,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY uq_mass_locations_slug (slug)
+);
+
+CREATE TABLE mass_schedules (
+  id INT NOT NULL AUTO_INCREMENT,
+  location_id INT NOT NULL,
+  weekday TINYINT NOT NULL,
+  start_time TIME NOT NULL,
+  language VARCHAR(32) NOT NULL DEFAULT 'Indonesia',
+  label VARCHAR(128) NULL,
+  notes VARCHAR(255) NULL,
+  valid_from DATE NULL,
+  valid_until DATE NULL,
+  created_at DATETIME NOT NULL,
+  created_by INT NULL,
+  updated_at DATETIME NULL,
+  updated_by INT NULL,
+  PRIMARY KEY (id),
+  KEY idx_mass_schedules_weekday (weekday, start_time),
+  CONSTRAINT fk_mass_schedules_location FOREIGN KEY (location_id) REFERENCES mass_locations (id)
+);
+
+CREATE TABLE mass_exceptions (
+  id INT NOT NULL AUTO_INCREMENT,
+  mass_date DATE NOT NULL,
+  action ENUM('cancel', 'change', 'add') NOT NULL,
+  schedule_id INT NULL,
+  location_id INT NULL,
+  start_time TIME NULL,
+  language VARCHAR(32) NULL,
+  label VARCHAR(128) NULL,
+  notes VARCHAR(255) NULL,
+  feast VARCHAR(128) NULL,
+  created_at DATETIME NOT NULL,
+  created_by INT NULL,
+  updated_at DATETIME NULL,
+  updated_by INT NULL,
+  PRIMARY KEY (id),
+  KEY idx_mass_exceptions_date (mass_date),
+  CONSTRAINT fk_mass_exceptions_schedule FOREIGN KEY (schedule_id)
+    REFERENCES mass_schedules (id) ON DELETE CASCADE,
+  CONSTRAINT fk_mass_exceptions_location FOREIGN KEY (location_id)
+    REFERENCES mass_locations (id) ON DELETE CASCADE
+);
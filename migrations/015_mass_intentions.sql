@@ -0,0 +1,36 @@
+-- Ujud misa. Parishioners request an intention for a Mass of the schedule,
+-- the secretariat confirms it and may move it to another Mass. A Mass is a
+-- date with the regular schedule_id, or the exception_id of a Mass added for
+-- that date. requested_mass keeps what was asked for in words. Names are only
+-- shown on the website when publish_name is set; contact details never are.
+-- ip_hash is an HMAC of the client address.
+CREATE TABLE mass_intentions (
+  id INT NOT NULL AUTO_INCREMENT,
+  kind VARCHAR(16) NOT NULL,
+  for_name VARCHAR(128) NOT NULL,
+  intention VARCHAR(500) NULL,
+  requester_name VARCHAR(64) NOT NULL,
+  phone VARCHAR(32) NULL,
+  email VARCHAR(255) NULL,
+  publish_name BOOLEAN NOT NULL DEFAULT FALSE,
+  mass_date DATE NOT NULL,
+  schedule_id INT NULL,
+  exception_id INT NULL,
+  requested_mass VARCHAR(255) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'pending',
+  admin_notes VARCHAR(255) NULL,
+  ip_hash CHAR(64) NOT NULL,
+  created_at DATETIME NOT NULL,
+  confirmed_at DATETIME NULL,
+  confirmed_by INT NULL,
+  updated_at DATETIME NULL,
+  updated_by INT NULL,
+  PRIMARY KEY (id),
+  KEY idx_mass_intentions_status (status, mass_date),
+  KEY idx_mass_intentions_mass (mass_date, schedule_id, exception_id),
+  KEY idx_mass_intentions_ip (ip_hash, created_at),
+  CONSTRAINT fk_mass_intentions_schedule FOREIGN KEY (schedule_id)
+    REFERENCES mass_schedules (id) ON DELETE SET NULL,
+  CONSTRAINT fk_mass_intentions_exception FOREIGN KEY (exception_id)
+    REFERENCES mass_exceptions (id) ON DELETE SET NULL
+);
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/storage"
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+	"google.golang.org/api/iterator"
+)
+
+// DeleteObjects removes the given objects and every object under the given
+// prefixes. Missing objects are not an error. It returns how many objects were
+// deleted; on failure it keeps going and returns the first error.
+func DeleteObjects(ctx context.Context, objPaths []string, prefixes []string) (int, error) {
+	client, err := lib.GetCloudStorage(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer client.CloudStorageClient.Close()
+
+	seen := map[string]bool{}
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			continue
+		}
+		it := client.StorageBucket.Objects(ctx, &storage.Query{Prefix: prefix})
+		for {
+			attrs, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return 0, err
+			}
+			seen[attrs.Name] = true
+		}
+	}
+	for _, obj := range objPaths {
+		if obj != "" {
+			seen[obj] = true
+		}
+	}
+
+	deleted := 0
+	var firstErr error
+	for obj := range seen {
+		err := client.StorageBucket.Object(obj).Delete(ctx)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			continue
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		deleted++
+	}
+	return deleted, firstErr
+}
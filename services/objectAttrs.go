@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/storage"
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+)
+
+// GetObjectAttrs returns the metadata of an uploaded object, such as its size
+// and content type, without reading it.
+func GetObjectAttrs(ctx context.Context, objPath string) (*storage.ObjectAttrs, error) {
+	client, err := lib.GetCloudStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.CloudStorageClient.Close()
+
+	attrs, err := client.StorageBucket.Object(objPath).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, lib.ErrNoObject
+	}
+	if err != nil {
+		return nil, err
+	}
+	return attrs, nil
+}
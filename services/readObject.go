@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/conf"
+	"github.com/Komsos-Matias-Rasul/parokikosambibaru-be-v2/lib"
+)
+
+// ObjectPathFromURL turns a stored image location ("/zaitun/...", escaped) or a
+// public bucket URL into an object name. It returns "" for anything that does
+// not live in the bucket, such as the static placeholder.
+func ObjectPathFromURL(loc string) string {
+	if _, after, found := strings.Cut(loc, "storage.googleapis.com/"+conf.GCLOUD_BUCKET); found {
+		loc = after
+	} else if strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://") {
+		return ""
+	}
+	loc, _, _ = strings.Cut(loc, "?")
+	if loc == "" || strings.HasPrefix(loc, "/static/") {
+		return ""
+	}
+	obj, err := url.PathUnescape(strings.TrimPrefix(loc, "/"))
+	if err != nil {
+		return ""
+	}
+	return obj
+}
+
+// ObjectReader reads many objects over one storage client, opened on the
+// first Read. The zero value is ready to use; Close it when done.
+type ObjectReader struct {
+	client *lib.CloudStorage
+	err    error
+}
+
+func (r *ObjectReader) Read(ctx context.Context, objPath string) ([]byte, string, error) {
+	if r.client == nil && r.err == nil {
+		r.client, r.err = lib.GetCloudStorage(ctx)
+	}
+	if r.err != nil {
+		return nil, "", r.err
+	}
+
+	reader, err := r.client.StorageBucket.Object(objPath).NewReader(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil, "", lib.ErrNoObject
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer reader.Close()
+
+	p, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", lib.ErrReadFailure
+	}
+
+	return p, reader.Attrs.ContentType, nil
+}
+
+func (r *ObjectReader) Close() {
+	if r.client != nil {
+		r.client.CloudStorageClient.Close()
+		r.client = nil
+	}
+}